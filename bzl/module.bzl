@@ -6,42 +6,6 @@ This replaces UnrealBuildTool's .Build.cs files with native Bazel rules.
 load("@rules_cc//cc:defs.bzl", "cc_library")
 load("//bzl:uht.bzl", "uht_codegen")
 
-def _generate_uht_outputs(name, hdrs):
-    """Generate list of expected UHT output files.
-
-    For each header, UHT MAY generate:
-    - Path_To_HeaderName.generated.h
-    - Path_To_HeaderName.gen.cpp
-
-    Plus always:
-    - ModuleName.init.gen.cpp
-
-    We create empty placeholders for all of them. UHT overwrites with content
-    if the header contains UCLASS/USTRUCT/UENUM, otherwise leaves empty.
-
-    To avoid filename collisions (e.g., Public/Algo/AnyOf.h and Public/Concepts/AnyOf.h),
-    we preserve the directory structure by replacing slashes with underscores.
-    """
-    outputs = [name + ".init.gen.cpp"]
-
-    for hdr in hdrs:
-        # Skip genrule outputs (":ModuleName_uht") - only process actual header files
-        if hdr.startswith(":"):
-            continue
-
-        # Skip .inl files - they're inline implementations, not UHT targets
-        if hdr.endswith(".inl"):
-            continue
-
-        # Preserve full path to avoid collisions
-        # e.g., "Public/Algo/AnyOf.h" → "Public_Algo_AnyOf"
-        path_without_ext = hdr.rsplit(".", 1)[0]
-        sanitized = path_without_ext.replace("/", "_")
-        outputs.append(sanitized + ".generated.h")
-        outputs.append(sanitized + ".gen.cpp")
-
-    return outputs
-
 # Old genrule-based UHT implementation removed - now using uht_codegen custom rule (bzl/uht.bzl)
 
 def ue_module(
@@ -4,96 +4,64 @@ This module provides a custom rule for running Epic's UnrealHeaderTool
 to generate reflection code from UCLASS/USTRUCT/UENUM macros.
 """
 
-def _filter_headers_for_uht(hdrs):
-    """Filter headers to only those UHT should process.
-
-    UHT requirements:
-    - Unique basenames (no "Public/Foo.h" and "Private/Foo.h")
-    - No implementation details (Detail/, Impl/)
-    - No inline files (.inl)
-    - No platform-specific headers
-
-    Args:
-        hdrs: List of header File objects
-
-    Returns:
-        List of header Files suitable for UHT processing
-    """
-    uht_hdrs = []
-    seen_basenames = {}
-
-    for hdr in hdrs:
-        path = hdr.path
-
-        # Skip .inl files
-        if path.endswith(".inl"):
-            continue
-
-        # Skip Detail/, Impl/, Private/ subdirectories
-        if "/Detail/" in path or "/Impl/" in path or "/Private/" in path:
-            continue
-
-        # Skip platform-specific subdirectories
-        platform_dirs = ["/Windows/", "/Microsoft/", "/Apple/", "/Unix/", "/Linux/", "/IOS/", "/Android/"]
-        skip = False
-        for pdir in platform_dirs:
-            if pdir in path:
-                skip = True
-                break
-        if skip:
-            continue
-
-        # Check for basename conflicts
-        basename = hdr.basename
-        if basename in seen_basenames:
-            # Skip duplicate (prefer shorter path)
-            if len(path) < len(seen_basenames[basename].path):
-                uht_hdrs.remove(seen_basenames[basename])
-                uht_hdrs.append(hdr)
-                seen_basenames[basename] = hdr
-            continue
-
-        uht_hdrs.append(hdr)
-        seen_basenames[basename] = hdr
-
-    return uht_hdrs
+# Module host types of .uproject descriptors (UBT's ModuleHostType). The generated .uproject
+# lists the module with its module_type, so only these are valid; "uht manifest" maps them to
+# the ModuleType UHT expects.
+_MODULE_HOST_TYPES = [
+    "Runtime",
+    "RuntimeNoCommandlet",
+    "RuntimeAndProgram",
+    "CookedOnly",
+    "UncookedOnly",
+    "Developer",
+    "DeveloperTool",
+    "Editor",
+    "EditorNoCommandlet",
+    "EditorAndProgram",
+    "Program",
+    "ServerOnly",
+    "ClientOnly",
+    "ClientOnlyNoCommandlet",
+]
 
 def _uht_codegen_impl(ctx):
     """Implementation of uht_codegen rule."""
 
-    # Filter headers for UHT
-    filtered_hdrs = _filter_headers_for_uht(ctx.files.hdrs)
+    # UHT writes its outputs into one directory. Which headers it processes is only known once
+    # "uht manifest --classify-headers" has read them, so the outputs are a tree artifact.
+    output_dir = ctx.actions.declare_directory(ctx.attr.module_name + "_uht_gen")
 
-    # Declare output files
-    outputs = []
-    outputs.append(ctx.actions.declare_file(ctx.attr.module_name + ".init.gen.cpp"))
+    # Headers are classified relative to the module's directory, as UBT does
+    base_dir = ctx.label.package
+    if ctx.label.workspace_root:
+        base_dir = ctx.label.workspace_root + "/" + base_dir
 
-    for hdr in filtered_hdrs:
-        # Sanitize path: Public/Foo/Bar.h → Public_Foo_Bar
-        path_no_ext = hdr.path.rsplit(".", 1)[0]
-        sanitized = path_no_ext.replace("/", "_")
-        outputs.append(ctx.actions.declare_file(sanitized + ".generated.h"))
-        outputs.append(ctx.actions.declare_file(sanitized + ".gen.cpp"))
-
-    # Generate manifest
+    # Generate manifest. Paths are relative to the execroot placeholder, so the action can run
+    # sandboxed and remotely; they are resolved right before UHT runs.
     manifest = ctx.actions.declare_file(ctx.attr.module_name + ".uhtmanifest")
-    header_paths = ",".join([hdr.path for hdr in filtered_hdrs])
+
+    # Headers go in a params file, so big modules don't hit command-line length limits. The
+    # command stays on the command line: only "uht manifest" reads params files, in this format.
+    args = ctx.actions.args()
+    args.add_all(["--config", "none"])
+    args.add("--module-name", ctx.attr.module_name)
+    args.add("--module-type", ctx.attr.module_type)
+    args.add("--base-dir", base_dir)
+    args.add("--output-dir", output_dir.path)
+    args.add_all(ctx.files.hdrs, before_each = "--header")
+    args.add("--classify-headers")
+    args.add("--relocatable")
+    args.add("--output", manifest)
+    args.use_param_file("@%s", use_always = True)
+    args.set_param_file_format("multiline")
 
     ctx.actions.run(
         executable = ctx.executable._gitdeps,
-        arguments = [
-            "uht", "manifest",
-            "--module-name", ctx.attr.module_name,
-            "--module-type", ctx.attr.module_type,
-            "--base-dir", ctx.bin_dir.path,
-            "--output-dir", ctx.bin_dir.path,
-            "--headers", header_paths,
-            "--output", manifest.path,
-        ],
+        arguments = ["uht", "manifest", args],
+        inputs = ctx.files.hdrs,  # Read to find the headers with reflection markup
         outputs = [manifest],
         mnemonic = "UHTManifest",
         progress_message = "Generating UHT manifest for %s" % ctx.attr.module_name,
-        execution_requirements = {"no-sandbox": "1"},  # gitDeps needs real execroot to resolve relative paths
     )
 
     # Generate .uproject file
@@ -114,57 +82,50 @@ def _uht_codegen_impl(ctx):
             DOTNET="$EXECROOT/{dotnet}"
             UBT="$EXECROOT/{ubt}"
             PROJECT="$EXECROOT/{project}"
-            MANIFEST="$EXECROOT/{manifest}"
-            OUTPUT_DIR="$EXECROOT/{output_dir}"
+
+            # Resolve the relocatable manifest against this execroot
+            MANIFEST_DIR=$(mktemp -d)
+            trap 'rm -rf "$MANIFEST_DIR"' EXIT
+            MANIFEST="$MANIFEST_DIR/$(basename {manifest})"
+            "$EXECROOT/{gitdeps}" uht manifest resolve --config none --execroot "$EXECROOT" --output "$MANIFEST" "$EXECROOT/{manifest}"
 
             # UHT requires running from UE root and needs to write to Engine/Saved/
             cd {ue_root}
             "$DOTNET" "$UBT" -Mode=UnrealHeaderTool "$PROJECT" "$MANIFEST" -Verbose || true
-
-            # Return to execroot for file operations
             cd "$EXECROOT"
 
-            # Copy UHT outputs to Bazel output locations
-            # UHT generates TestEnum.gen.cpp, we expect Public_TestEnum.gen.cpp
-            for out in {outputs}; do
-                OUTBASE=$(basename "$out")
-                # Try direct match first
-                if [ -f "{output_dir}/$OUTBASE" ]; then
-                    cp "{output_dir}/$OUTBASE" "$out"
-                    continue
-                fi
-                # Try unsanitized name (Public_TestEnum.gen.cpp → TestEnum.gen.cpp)
-                UNSANITIZED=$(echo "$OUTBASE" | sed 's/^[^_]*_//')
-                if [ -f "{output_dir}/$UNSANITIZED" ]; then
-                    cp "{output_dir}/$UNSANITIZED" "$out"
-                    continue
+            # Every file "uht outputs" predicts must exist for the compile actions. Create empty
+            # ones for those UHT didn't write.
+            "$EXECROOT/{gitdeps}" uht outputs --config none --format text --manifest "$MANIFEST" > "$MANIFEST_DIR/outputs"
+            while IFS= read -r out; do
+                if [ ! -f "$out" ]; then
+                    echo "UHT did not generate $out, writing an empty file" >&2
+                    touch "$out"
                 fi
-                # File not generated, create placeholder
-                touch "$out"
-            done
+            done < "$MANIFEST_DIR/outputs"
         """.format(
             ue_root = ctx.file.ubt.dirname + "/../../..",
             dotnet = ctx.file.dotnet.path,
             ubt = ctx.file.ubt.path,
             project = uproject.path,
             manifest = manifest.path,
-            output_dir = ctx.bin_dir.path,
-            outputs = " ".join([f.path for f in outputs]),
+            gitdeps = ctx.executable._gitdeps.path,
         ),
-        inputs = [manifest, uproject, ctx.file.dotnet, ctx.file.ubt] + filtered_hdrs,
-        outputs = outputs,
+        inputs = [manifest, uproject, ctx.file.dotnet, ctx.file.ubt] + ctx.files.hdrs,
+        tools = [ctx.executable._gitdeps],
+        outputs = [output_dir],
         mnemonic = "UHTCodegen",
         progress_message = "Running UHT for %s" % ctx.attr.module_name,
         execution_requirements = {"no-sandbox": "1"},  # UBT writes to Engine/Saved/
     )
 
-    return [DefaultInfo(files = depset(outputs))]
+    return [DefaultInfo(files = depset([output_dir]))]
 
 uht_codegen = rule(
     implementation = _uht_codegen_impl,
     attrs = {
         "module_name": attr.string(mandatory = True),
-        "module_type": attr.string(default = "Runtime"),
+        "module_type": attr.string(default = "Runtime", values = _MODULE_HOST_TYPES),
         "hdrs": attr.label_list(allow_files = [".h", ".hpp", ".inl", ".cpp"]),  # .cpp for unity builds
         "_gitdeps": attr.label(
             default = Label("//:rules_unreal_engine"),
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// ProjectConfigFile is looked up in the working directory and its parents
+	ProjectConfigFile = ".rules-unreal-engine.yaml"
+
+	// EnvPrefix is prepended to a flag's name in upper snake case to get its environment
+	// variable, e.g. GITDEPS_PACKS_DIR for --packs-dir. Every command reads environment
+	// variables unless run with --config none.
+	EnvPrefix = "GITDEPS_"
+
+	// noConfig as --config disables config files and environment variables, e.g. for hermetic
+	// Bazel repository rules
+	noConfig = "none"
+
+	// configKeysAnnotation lists the flags of a command, separated by commas, that config files
+	// may set. Other flags, e.g. --output or --output-dir, can only be set on the command line or
+	// from the environment, so a key never changes what an unrelated command does.
+	configKeysAnnotation = "configKeys"
+)
+
+// globalConfigKeys are the persistent flags config files may set for every command
+var globalConfigKeys = []string{"log-format"}
+
+// Config sets flag defaults. Keys are flag names; a key applies to the commands that list it in
+// configKeysAnnotation (see configKeys) and is ignored by the others, and keys no command lists
+// are rejected. Lists set repeatable flags.
+//
+//	defaults:
+//	  workers: 8
+//	  log-format: json
+//	profiles:
+//	  linux-server:
+//	    prefix: [Engine/Binaries/Linux, Engine/Source/Programs]
+type Config struct {
+	Defaults map[string]any            `yaml:"defaults"`
+	Profiles map[string]map[string]any `yaml:"profiles"`
+}
+
+// userConfigPath returns the per-user config file, e.g. ~/.config/rules-unreal-engine/config.yaml
+func userConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "rules-unreal-engine", "config.yaml")
+}
+
+// projectConfigPath returns the closest ProjectConfigFile in dir or its parents, or "" if none
+func projectConfigPath(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectConfigFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readConfig parses a config file. Every key must be in keys, see allConfigKeys.
+func readConfig(path string, keys map[string]bool) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	check := func(settings map[string]any, where string) error {
+		for key := range settings {
+			if !keys[key] {
+				return fmt.Errorf("invalid config file %s: unsupported key %q in %s", path, key, where)
+			}
+		}
+		return nil
+	}
+	if err := check(config.Defaults, "defaults"); err != nil {
+		return nil, err
+	}
+	for name, profile := range config.Profiles {
+		if err := check(profile, "profile "+name); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// configKeys returns the flags config files may set for cmd: globalConfigKeys and the flags
+// listed in its configKeysAnnotation
+func configKeys(cmd *cobra.Command) map[string]bool {
+	keys := map[string]bool{}
+	for _, key := range globalConfigKeys {
+		keys[key] = true
+	}
+	if list := cmd.Annotations[configKeysAnnotation]; list != "" {
+		for _, key := range strings.Split(list, ",") {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// allConfigKeys returns the config keys of cmd and all its subcommands
+func allConfigKeys(cmd *cobra.Command) map[string]bool {
+	keys := configKeys(cmd)
+	for _, sub := range cmd.Commands() {
+		for key := range allConfigKeys(sub) {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// loadConfig reads the config file given with --config, or merges the user and project config
+// files, project settings taking precedence. Keys must be in keys.
+func loadConfig(path string, keys map[string]bool) (*Config, error) {
+	if path == noConfig {
+		return &Config{}, nil
+	}
+	if path != "" {
+		return readConfig(path, keys)
+	}
+
+	merged := &Config{Defaults: map[string]any{}, Profiles: map[string]map[string]any{}}
+	var paths []string
+	if user := userConfigPath(); user != "" {
+		paths = append(paths, user)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if project := projectConfigPath(wd); project != "" {
+			paths = append(paths, project)
+		}
+	}
+
+	for _, p := range paths {
+		config, err := readConfig(p, keys)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		logrus.Debugf("using config file %s", p)
+		for k, v := range config.Defaults {
+			merged.Defaults[k] = v
+		}
+		for name, profile := range config.Profiles {
+			if merged.Profiles[name] == nil {
+				merged.Profiles[name] = map[string]any{}
+			}
+			for k, v := range profile {
+				merged.Profiles[name][k] = v
+			}
+		}
+	}
+	return merged, nil
+}
+
+// settings returns the flag values of the config for the given profile ("" for none)
+func (c *Config) settings(profile string) (map[string]any, error) {
+	settings := map[string]any{}
+	for k, v := range c.Defaults {
+		settings[k] = v
+	}
+	if profile == "" {
+		return settings, nil
+	}
+
+	p, ok := c.Profiles[profile]
+	if !ok {
+		var names []string
+		for name := range c.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown profile %q (available profiles: %s)", profile, strings.Join(names, ", "))
+	}
+	for k, v := range p {
+		settings[k] = v
+	}
+	return settings, nil
+}
+
+// envName returns the environment variable for a flag, e.g. GITDEPS_PACKS_DIR for packs-dir
+func envName(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+// setFlag sets a flag from a config value. Lists replace the values of repeatable flags.
+func setFlag(flags *pflag.FlagSet, f *pflag.Flag, value any) error {
+	if list, ok := value.([]any); ok {
+		values := make([]string, len(list))
+		for i, v := range list {
+			values[i] = fmt.Sprint(v)
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			if err := sv.Replace(values); err != nil {
+				return err
+			}
+			f.Changed = true
+			return nil
+		}
+		value = strings.Join(values, ",")
+	}
+	return flags.Set(f.Name, fmt.Sprint(value))
+}
+
+// applyConfig fills in every flag of cmd that was not given on the command line, first from its
+// environment variable, then, for the flags of configKeys, from the selected profile and then
+// the config defaults. Environment variables are not read with --config none, which hermetic
+// callers such as the Bazel rules pass.
+func applyConfig(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+
+	// --config and --profile can come from the environment too
+	fromEnv := func(name string) error {
+		if f := flags.Lookup(name); f != nil && !f.Changed {
+			if v, ok := os.LookupEnv(envName(name)); ok {
+				return flags.Set(name, v)
+			}
+		}
+		return nil
+	}
+	if err := fromEnv("config"); err != nil {
+		return err
+	}
+	configPath, _ := flags.GetString("config")
+	useEnv := configPath != noConfig
+	if useEnv {
+		if err := fromEnv("profile"); err != nil {
+			return err
+		}
+	}
+
+	profile, _ := flags.GetString("profile")
+	config, err := loadConfig(configPath, allConfigKeys(cmd.Root()))
+	if err != nil {
+		return err
+	}
+	settings, err := config.settings(profile)
+	if err != nil {
+		return err
+	}
+	keys := configKeys(cmd)
+
+	var applyErr error
+	flags.VisitAll(func(f *pflag.Flag) {
+		if applyErr != nil || f.Changed {
+			return
+		}
+		if v, ok := os.LookupEnv(envName(f.Name)); ok && useEnv {
+			if err := flags.Set(f.Name, v); err != nil {
+				applyErr = fmt.Errorf("invalid value for %s: %w", envName(f.Name), err)
+			}
+			return
+		}
+		if v, ok := settings[f.Name]; ok && keys[f.Name] {
+			if err := setFlag(flags, f, v); err != nil {
+				applyErr = fmt.Errorf("invalid config value for %s: %w", f.Name, err)
+			}
+		}
+	})
+	return applyErr
+}
+
+// applyLogFormat configures logrus from --log-format
+func applyLogFormat(cmd *cobra.Command) error {
+	format, _ := cmd.Flags().GetString("log-format")
+	switch format {
+	case "text":
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	case "json":
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q (valid values are 'text' and 'json')", format)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// precedenceFlags are named after the source expected to set them
+var precedenceFlags = []string{"from-flag", "from-env", "from-profile", "from-project", "from-user"}
+
+// configTestCommand adds a command with precedenceFlags, which config files may set, and a
+// --prefix flag, which they may not, to parent. It parses args and applies the config like a
+// command run from a project directory would. The user and project config files set the flags
+// as written by writeConfigs.
+func configTestCommand(t *testing.T, parent *cobra.Command, args ...string) (*cobra.Command, error) {
+	t.Helper()
+	cmd := &cobra.Command{
+		Use:         "config-test",
+		Annotations: map[string]string{configKeysAnnotation: strings.Join(precedenceFlags, ",")},
+	}
+	for _, name := range precedenceFlags {
+		cmd.Flags().String(name, "default", "")
+	}
+	cmd.Flags().StringSlice("prefix", []string{}, "")
+	parent.AddCommand(cmd)
+	t.Cleanup(func() {
+		parent.RemoveCommand(cmd)
+		for _, name := range []string{"config", "profile"} {
+			f := rootCmd.PersistentFlags().Lookup(name)
+			f.Value.Set(f.DefValue)
+			f.Changed = false
+		}
+	})
+
+	require.NoError(t, cmd.ParseFlags(args))
+	return cmd, applyConfig(cmd)
+}
+
+// writeConfigs writes a user config and a project config, and makes the project the working directory
+func writeConfigs(t *testing.T, user, project string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	userConfig := userConfigPath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(userConfig), 0755))
+	require.NoError(t, os.WriteFile(userConfig, []byte(user), 0644))
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ProjectConfigFile), []byte(project), 0644))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(projectDir))
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+const (
+	userConfig = `defaults:
+  from-flag: user
+  from-env: user
+  from-profile: user
+  from-project: user
+  from-user: user
+`
+	projectConfig = `defaults:
+  from-flag: project
+  from-env: project
+  from-profile: project
+  from-project: project
+profiles:
+  test:
+    from-flag: profile
+    from-env: profile
+    from-profile: profile
+`
+)
+
+func flagValues(cmd *cobra.Command) map[string]string {
+	values := map[string]string{}
+	for _, name := range precedenceFlags {
+		values[name], _ = cmd.Flags().GetString(name)
+	}
+	return values
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	writeConfigs(t, userConfig, projectConfig)
+	t.Setenv(envName("from-flag"), "env")
+	t.Setenv(envName("from-env"), "env")
+
+	t.Run("gitDeps", func(t *testing.T) {
+		cmd, err := configTestCommand(t, gitDepsCmd, "--from-flag", "flag", "--profile", "test")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"from-flag":    "flag",
+			"from-env":     "env",
+			"from-profile": "profile",
+			"from-project": "project",
+			"from-user":    "user",
+		}, flagValues(cmd))
+	})
+
+	t.Run("profile from env", func(t *testing.T) {
+		t.Setenv(envName("profile"), "test")
+		cmd, err := configTestCommand(t, extractCmd)
+		assert.NoError(t, err)
+		assert.Equal(t, "env", flagValues(cmd)["from-env"])
+		assert.Equal(t, "profile", flagValues(cmd)["from-profile"])
+	})
+
+	t.Run("uht", func(t *testing.T) {
+		cmd, err := configTestCommand(t, uhtCmd, "--from-flag", "flag", "--profile", "test")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"from-flag":    "flag",
+			"from-env":     "env",
+			"from-profile": "profile",
+			"from-project": "project",
+			"from-user":    "user",
+		}, flagValues(cmd))
+	})
+
+	t.Run("config none", func(t *testing.T) {
+		t.Setenv(envName("profile"), "test")
+		cmd, err := configTestCommand(t, gitDepsCmd, "--config", "none")
+		assert.NoError(t, err)
+		for name, value := range flagValues(cmd) {
+			assert.Equal(t, "default", value, name)
+		}
+	})
+
+	t.Run("config none from env", func(t *testing.T) {
+		t.Setenv(envName("config"), "none")
+		cmd, err := configTestCommand(t, gitDepsCmd)
+		assert.NoError(t, err)
+		assert.Equal(t, "default", flagValues(cmd)["from-env"])
+	})
+}
+
+func TestConfigUnknownKey(t *testing.T) {
+	writeConfigs(t, userConfig, projectConfig+"    not-a-flag: 1\n")
+	_, err := configTestCommand(t, gitDepsCmd)
+	assert.ErrorContains(t, err, `unsupported key "not-a-flag" in profile test`)
+}
+
+func TestConfigKeys(t *testing.T) {
+	assert.True(t, configKeys(extractCmd)["prefix"])
+	assert.True(t, configKeys(gitDepsCmd)["cache-dir"])
+	// The --prefix of pack selects what to pack, not what to sync
+	assert.False(t, configKeys(packCmd)["prefix"])
+	assert.False(t, configKeys(printUrlsCmd)["output"])
+	assert.True(t, configKeys(uhtManifestCmd)["log-format"])
+	assert.False(t, configKeys(uhtManifestCmd)["output-dir"])
+
+	t.Run("ignored by commands that do not list them", func(t *testing.T) {
+		writeConfigs(t, "", "defaults:\n  prefix: [Engine]\n")
+		cmd, err := configTestCommand(t, gitDepsCmd)
+		assert.NoError(t, err)
+		prefixes, _ := cmd.Flags().GetStringSlice("prefix")
+		assert.Empty(t, prefixes)
+	})
+
+	t.Run("flags no command lists are rejected", func(t *testing.T) {
+		writeConfigs(t, "", "defaults:\n  output-dir: out\n")
+		_, err := configTestCommand(t, gitDepsCmd)
+		assert.ErrorContains(t, err, `unsupported key "output-dir" in defaults`)
+	})
+}
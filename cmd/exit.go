@@ -1,6 +1,84 @@
 package cmd
 
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"slices"
+
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// Exit codes. The Bazel repository rule (internal/repo/rule.bzl) maps these back to
+// descriptions, so keep the values stable and update the rule when adding new ones.
 const (
 	NormalExitCode int = iota
 	UnknownExitCode
+	CancelledExitCode       // Interrupted by SIGINT/SIGTERM
+	ManifestExitCode        // The manifest could not be read or parsed
+	NetworkExitCode         // A pack could not be downloaded (CDN down, 404, connection reset)
+	IntegrityExitCode       // Pack or file data does not match the manifest
+	PathSafetyExitCode      // The manifest tried to write outside the output directory
+	LocallyModifiedExitCode // A locally modified file blocked extraction (--modified=fail)
+	IncompleteExitCode      // Some requested files were not produced (--strict)
+	ExtraFilesExitCode      // The directory has files the manifest doesn't list (verify --strict)
 )
+
+// exitCodeForError maps an error returned by pkg/gitDeps to the most specific exit code
+func exitCodeForError(err error) int {
+	var parseErr *gitDeps.ParseError
+	var conflictErr *gitDeps.ManifestConflictError
+	var httpErr *gitDeps.HTTPError
+	var integrityErr *gitDeps.IntegrityError
+	var pathErr *gitDeps.PathSafetyError
+	var incompleteErr *gitDeps.IncompleteError
+	var flateErr flate.CorruptInputError
+
+	switch {
+	case err == nil:
+		return NormalExitCode
+	case errors.Is(err, context.Canceled):
+		return CancelledExitCode
+	case errors.As(err, &parseErr), errors.As(err, &conflictErr):
+		return ManifestExitCode
+	// A corrupt download is an integrity failure even when it is reported with its HTTP request
+	case errors.As(err, &integrityErr), errors.Is(err, gzip.ErrHeader), errors.Is(err, gzip.ErrChecksum), errors.As(err, &flateErr):
+		return IntegrityExitCode
+	case errors.As(err, &httpErr):
+		return NetworkExitCode
+	case errors.As(err, &pathErr):
+		return PathSafetyExitCode
+	case errors.Is(err, gitDeps.LocallyModifiedError):
+		return LocallyModifiedExitCode
+	case errors.As(err, &incompleteErr):
+		return IncompleteExitCode
+	default:
+		return UnknownExitCode
+	}
+}
+
+// exitCodeSeverity orders exit codes from most to least severe
+var exitCodeSeverity = []int{
+	CancelledExitCode,
+	PathSafetyExitCode,
+	IntegrityExitCode,
+	LocallyModifiedExitCode,
+	ManifestExitCode,
+	NetworkExitCode,
+	IncompleteExitCode,
+	UnknownExitCode,
+}
+
+// exitCodeForErrors returns the exit code of the most severe of errs, so the code does not depend
+// on the order in which parallel workers failed
+func exitCodeForErrors(errs []error) int {
+	code := NormalExitCode
+	for _, err := range errs {
+		c := exitCodeForError(err)
+		if code == NormalExitCode || slices.Index(exitCodeSeverity, c) < slices.Index(exitCodeSeverity, code) {
+			code = c
+		}
+	}
+	return code
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+func TestExitCodeForError(t *testing.T) {
+	httpErr := &gitDeps.HTTPError{URL: "https://example.com/pack", StatusCode: http.StatusNotFound}
+	tests := map[string]struct {
+		err  error
+		code int
+	}{
+		"nil":       {nil, NormalExitCode},
+		"cancelled": {fmt.Errorf("failed to download: %w", context.Canceled), CancelledExitCode},
+		"network":   {httpErr, NetworkExitCode},
+		"integrity": {&gitDeps.IntegrityError{Name: "a"}, IntegrityExitCode},
+		"corrupt gzip in an HTTP response": {
+			&gitDeps.HTTPError{URL: "https://example.com/pack", StatusCode: http.StatusOK, Err: gzip.ErrHeader},
+			IntegrityExitCode,
+		},
+		"unknown": {errors.New("boom"), UnknownExitCode},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.code, exitCodeForError(tt.err))
+		})
+	}
+}
+
+func TestGitDepsCorruptPackExitCode(t *testing.T) {
+	manifestPath := filepath.Join(t.TempDir(), "Commit.gitdeps.xml")
+	packTree(t, map[string]string{"Engine/a.txt": "a"}, t.TempDir(), manifestPath)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("this is not gzip"))
+	}))
+	defer ts.Close()
+
+	code := runCommand(t, "gitDeps", "--config", "none", "--input", manifestPath,
+		"--output-dir", t.TempDir(), "--base-url", ts.URL)
+	assert.Equal(t, IntegrityExitCode, code)
+}
+
+func TestExtractTruncatedPackExitCode(t *testing.T) {
+	packsDir := t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "Commit.gitdeps.xml")
+	packTree(t, map[string]string{"Engine/a.txt": strings.Repeat("a", 4096)}, packsDir, manifestPath)
+
+	// Cut every pack short, as an interrupted copy would, so gzip reports io.ErrUnexpectedEOF
+	packs, err := os.ReadDir(packsDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, packs)
+	for _, pack := range packs {
+		path := filepath.Join(packsDir, pack.Name())
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data[:len(data)/2], 0644))
+	}
+
+	code := runCommand(t, "extract", "--config", "none", "--manifest", manifestPath,
+		"--packs-dir", packsDir, "--output-dir", t.TempDir())
+	assert.Equal(t, IntegrityExitCode, code)
+}
+
+func TestExitCodeForErrors(t *testing.T) {
+	network := &gitDeps.HTTPError{URL: "https://example.com/pack", StatusCode: http.StatusServiceUnavailable}
+	integrity := &gitDeps.IntegrityError{Name: "a"}
+	unknown := errors.New("boom")
+
+	assert.Equal(t, NormalExitCode, exitCodeForErrors(nil))
+	assert.Equal(t, UnknownExitCode, exitCodeForErrors([]error{unknown}))
+	// The most severe failure wins whatever order the packs failed in
+	assert.Equal(t, IntegrityExitCode, exitCodeForErrors([]error{network, integrity, unknown}))
+	assert.Equal(t, IntegrityExitCode, exitCodeForErrors([]error{unknown, integrity, network}))
+	assert.Equal(t, NetworkExitCode, exitCodeForErrors([]error{unknown, network}))
+}
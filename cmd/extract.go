@@ -1,34 +1,42 @@
 package cmd
 
 import (
-	"compress/gzip"
-	"fmt"
+	"bytes"
+	"context"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"io"
 	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
 	"os"
-	"path/filepath"
 	"runtime"
-	"strings"
-	"sync"
-	"sync/atomic"
 )
 
 var extractCmd = &cobra.Command{
 	Use:   "extract",
 	Short: "Extract pre-downloaded pack files using a manifest",
+	// Flags config files may set, see configKeys
+	Annotations: map[string]string{configKeysAnnotation: "prefix,workers"},
 	Long: `Extract Unreal Engine dependencies from pre-downloaded pack files.
 
 This command is used by the Bazel repository rule to extract packs
 that were downloaded using Bazel's HTTP cache (repo_ctx.download).`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get flags
-		packsDir, _ := cmd.Flags().GetString("packs-dir")
-		manifestPath, _ := cmd.Flags().GetString("manifest")
+		manifestPaths, _ := cmd.Flags().GetStringArray("manifest")
 		outputDir, _ := cmd.Flags().GetString("output-dir")
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		prefixes, _ := cmd.Flags().GetStringSlice("prefix")
+		verify, _ := cmd.Flags().GetBool("verify")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		planFormat, _ := cmd.Flags().GetString("plan-format")
+		strict, _ := cmd.Flags().GetBool("strict")
+		reportPath, _ := cmd.Flags().GetString("report")
+		bundlePath, _ := cmd.Flags().GetString("bundle")
+		statsFile, _ := cmd.Flags().GetString("stats-file")
+		numWorkers, _ := cmd.Flags().GetInt("workers")
+		if numWorkers <= 0 {
+			numWorkers = runtime.NumCPU()
+		}
 
 		// Set log level
 		if verbose {
@@ -38,148 +46,128 @@ that were downloaded using Bazel's HTTP cache (repo_ctx.download).`,
 		}
 
 		// Parse manifest
-		logrus.Infof("parsing manifest from: %s", manifestPath)
-		manifest, err := gitDeps.GetManifestFromInput(manifestPath)
+		logrus.Infof("parsing manifests from: %v", manifestPaths)
+		manifest, err := manifestFromInputs(manifestPaths)
 		if err != nil {
 			logrus.Errorf("failed to parse manifest: %s", err)
-			logrus.Exit(UnknownExitCode)
+			logrus.Exit(ManifestExitCode)
 		}
 
 		logrus.Infof("found %d packs in manifest", len(manifest.Packs))
 
-		// Filter files by prefixes if specified
-		filesToExtract := manifest.Files
-		if len(prefixes) > 0 {
-			var filtered []gitDeps.File
-			for _, f := range manifest.Files {
-				for _, prefix := range prefixes {
-					if strings.HasPrefix(f.Name, prefix) {
-						filtered = append(filtered, f)
-						break // Don't add same file twice if it matches multiple prefixes
-					}
-				}
+		workingManifest := workingManifestPath(cmd, manifestPaths)
+		extractOpts, err := extractOptionsFromFlags(cmd, workingManifest)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+		extractOpts.VerifyHashes = verify
+
+		var locator *gitDeps.PackLocator
+		if bundlePath == "" {
+			locator, err = packLocatorFromFlags(cmd)
+			if err != nil {
+				logrus.Error(err)
+				logrus.Exit(UnknownExitCode)
 			}
-			filesToExtract = filtered
-			logrus.Infof("prefix filters %v: extracting %d/%d files", prefixes, len(filtered), len(manifest.Files))
 		}
 
-		// Extract packs in parallel using worker pool (one worker per CPU)
-		numWorkers := runtime.NumCPU()
-		logrus.Infof("extracting %d packs using %d workers", len(manifest.Packs), numWorkers)
+		if dryRun {
+			plan, err := gitDeps.PlanExtraction(*manifest, outputDir, gitDeps.PlanOptions{
+				Prefixes:       prefixes,
+				Packs:          locator,
+				ModifiedPolicy: extractOpts.ModifiedPolicy,
+				Workers:        numWorkers,
+			})
+			if err != nil {
+				logrus.Errorf("failed to plan extraction: %s", err)
+				logrus.Exit(UnknownExitCode)
+			}
+			if err := printPlan(plan, planFormat); err != nil {
+				logrus.Error(err)
+				logrus.Exit(UnknownExitCode)
+			}
+			return
+		}
 
-		// Create work queue and error channel
-		type packJob struct {
-			index int
-			pack  gitDeps.Pack
+		filesToExtract := gitDeps.FilterFiles(manifest.Files, prefixes)
+		if len(prefixes) > 0 {
+			logrus.Infof("prefix filters %v: extracting %d/%d files", prefixes, len(filesToExtract), len(manifest.Files))
 		}
-		packQueue := make(chan packJob, numWorkers*2)
-		errorChan := make(chan error, len(manifest.Packs))
-		var wg sync.WaitGroup
-		var processed atomic.Int64
-
-		// Start worker pool
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			go func(workerID int) {
-				defer wg.Done()
-
-				for job := range packQueue {
-					pack := job.pack
-					packFile := filepath.Join(packsDir, fmt.Sprintf("%s.pack.gz", pack.Hash))
-
-					// Check if pack exists
-					if _, err := os.Stat(packFile); os.IsNotExist(err) {
-						logrus.Warnf("pack file not found: %s", packFile)
-						processed.Add(1)
-						continue
-					}
-
-					// Open and decompress the pack
-					f, err := os.Open(packFile)
-					if err != nil {
-						errorChan <- fmt.Errorf("failed to open pack %s: %w", packFile, err)
-						processed.Add(1)
-						continue
-					}
-
-					gzr, err := gzip.NewReader(f)
-					if err != nil {
-						f.Close()
-						errorChan <- fmt.Errorf("failed to decompress pack %s: %w", packFile, err)
-						processed.Add(1)
-						continue
-					}
-
-					// Read decompressed data
-					packData, err := io.ReadAll(gzr)
-					gzr.Close()
-					f.Close()
-
-					if err != nil {
-						errorChan <- fmt.Errorf("failed to read pack %s: %w", packFile, err)
-						processed.Add(1)
-						continue
-					}
-
-					// Find blobs and files for this pack
-					var packBlobs []gitDeps.Blob
-					for _, blob := range manifest.Blobs {
-						if blob.PackHash == pack.Hash {
-							packBlobs = append(packBlobs, blob)
-						}
-					}
-
-					var packFiles []gitDeps.File
-					for _, file := range filesToExtract {
-						for _, blob := range packBlobs {
-							if file.Hash == blob.Hash {
-								packFiles = append(packFiles, file)
-								break
-							}
-						}
-					}
-
-					// Extract files from pack
-					if err := gitDeps.ExtractUEPack(packData, packBlobs, packFiles, outputDir, *manifest); err != nil {
-						errorChan <- fmt.Errorf("failed to extract pack %s: %w", pack.Hash, err)
-						processed.Add(1)
-						continue
-					}
-
-					// Log progress every 100 packs
-					count := processed.Add(1)
-					if count%100 == 0 {
-						logrus.Infof("extracted %d/%d packs", count, len(manifest.Packs))
-					}
-				}
-			}(w)
+
+		ctx := cmd.Context()
+
+		progress := gitDeps.NewProgress(len(gitDeps.PacksWithFiles(*manifest, filesToExtract)), 0)
+		extractOpts.Progress = progress
+		extractOpts.Workers = numWorkers
+		stats := gitDeps.NewSyncStats(numWorkers)
+		extractOpts.Stats = stats
+		stopProgress := startProgress(progress)
+
+		summary := &gitDeps.ExtractSummary{Requested: filesToExtract, Result: &gitDeps.ExtractResult{}}
+		if bundlePath != "" {
+			bundleResult, skipped, err := extractBundle(ctx, bundlePath, *manifest, filesToExtract, outputDir, extractOpts)
+			summary.Result.Merge(bundleResult)
+			summary.SkippedPacks = skipped
+			if err != nil && ctx.Err() == nil {
+				summary.Errors = append(summary.Errors, err)
+			}
+		} else {
+			summary, _ = gitDeps.ExtractAll(ctx, *manifest, outputDir, gitDeps.ExtractAllOptions{
+				ExtractOptions: extractOpts,
+				Prefixes:       prefixes,
+				Packs:          locator,
+			})
 		}
+		result := summary.Result
 
-		// Send all packs to work queue
-		for i, pack := range manifest.Packs {
-			packQueue <- packJob{index: i, pack: pack}
+		stopProgress()
+		logrus.Info(progress.Snapshot().String())
+		if err := writeStatsFile(statsFile, stats); err != nil {
+			logrus.Errorf("failed to write stats file: %s", err)
 		}
-		close(packQueue)
 
-		// Wait for all workers to finish
-		wg.Wait()
-		close(errorChan)
+		result.Sort()
+		logModifiedFiles(result)
 
-		// Check for errors
-		var errors []error
-		for err := range errorChan {
-			errors = append(errors, err)
+		if ctx.Err() != nil {
+			logrus.Error("interrupted, stopping extraction")
+			logrus.Exit(CancelledExitCode)
 		}
 
-		if len(errors) > 0 {
-			logrus.Errorf("encountered %d errors during extraction:", len(errors))
-			for _, err := range errors {
+		report := summary.Report(*manifest)
+		if reportPath != "" {
+			if err := writeCompletenessReport(reportPath, report); err != nil {
+				logrus.Errorf("failed to write report: %s", err)
+				logrus.Exit(UnknownExitCode)
+			}
+		}
+
+		if len(summary.Errors) > 0 {
+			logrus.Errorf("encountered %d errors during extraction:", len(summary.Errors))
+			for _, err := range summary.Errors {
 				logrus.Error(err)
 			}
+			logrus.Exit(exitCodeForErrors(summary.Errors))
+		}
+
+		if !report.Complete() {
+			for _, m := range report.Missing {
+				logrus.Debugf("missing %s: %s", m.Name, m.Reason)
+			}
+			if strict {
+				logrus.Error(report.Err())
+				logrus.Exit(IncompleteExitCode)
+			}
+			logrus.Warn(report.Err())
+		}
+
+		if err := writeWorkingManifest(workingManifest, *manifest, extractOpts, result); err != nil {
+			logrus.Errorf("failed to write working manifest: %s", err)
 			logrus.Exit(UnknownExitCode)
 		}
 
-		logrus.Info("all packs extracted successfully")
+		logrus.Infof("all packs extracted successfully (%d files written, %d unchanged)", len(result.Written), len(result.Unchanged))
 	},
 }
 
@@ -187,12 +175,47 @@ func init() {
 	rootCmd.AddCommand(extractCmd)
 
 	// Define flags
-	extractCmd.Flags().String("packs-dir", "", "Directory containing downloaded .pack.gz files")
-	extractCmd.Flags().String("manifest", "", "Path to .gitdeps.xml manifest file")
+	extractCmd.Flags().StringArray("packs-dir", []string{}, "Directory containing downloaded packs as <hash>.pack.gz, <hash> or <RemotePath>/<hash> (repeatable, searched in order)")
+	extractCmd.Flags().String("bundle", "", "Read packs from a tar, tar.gz or zip archive instead of --packs-dir ('-' reads the archive from stdin)")
+	extractCmd.Flags().String("repository-cache", "", "Bazel repository cache to search for packs listed in --lockfile")
+	extractCmd.Flags().String("lockfile", "", "Pack lockfile mapping pack hashes to sha256, see 'gitDeps lock'")
+	extractCmd.Flags().StringArray("manifest", []string{}, "Path to .gitdeps.xml manifest file (repeatable, manifests are merged)")
 	extractCmd.Flags().StringP("output-dir", "o", ".", "Directory to extract files to")
 	extractCmd.Flags().Bool("verbose", false, "Enable verbose logging")
+	extractCmd.Flags().Int("workers", 0, "Number of packs to extract in parallel (default: number of CPUs)")
+	extractCmd.Flags().Bool("verify", false, "Fail instead of warning when an extracted file does not match its SHA1 in the manifest")
+	extractCmd.Flags().Bool("dry-run", false, "Print what would be extracted without writing any files")
+	extractCmd.Flags().String("plan-format", "text", "Format of the --dry-run plan. Valid values are 'text' and 'json'.")
+	extractCmd.Flags().Bool("strict", false, "Fail when any requested file could not be produced, e.g. because its pack is missing from --packs-dir")
+	extractCmd.Flags().String("stats-file", "", "Write JSON timing statistics (time per phase, slowest packs, cache hits) to this path")
+	extractCmd.Flags().String("report", "", "Write a JSON report of produced and missing files and skipped packs to this path")
 	extractCmd.Flags().StringSlice("prefix", []string{}, "Only extract files with these path prefixes (repeatable, e.g., --prefix=Engine/Binaries --prefix=Engine/Source/Programs)")
 
-	extractCmd.MarkFlagRequired("packs-dir")
+	addModifiedFileFlags(extractCmd)
+
 	extractCmd.MarkFlagRequired("manifest")
 }
+
+// writeCompletenessReport writes report as JSON to path
+func writeCompletenessReport(path string, report *gitDeps.CompletenessReport) error {
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		return err
+	}
+	return gitDeps.WriteFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// extractBundle extracts files from the pack bundle at path, or from stdin if path is "-"
+func extractBundle(ctx context.Context, path string, manifest gitDeps.WorkingManifest, files []gitDeps.File, outputDir string, opts gitDeps.ExtractOptions) (*gitDeps.ExtractResult, []gitDeps.SkippedPack, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	logrus.Infof("extracting packs from bundle %s", path)
+	return gitDeps.ExtractBundle(ctx, r, manifest, files, outputDir, opts)
+}
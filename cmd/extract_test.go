@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractPathsWithCommas(t *testing.T) {
+	packsDir := filepath.Join(t.TempDir(), "packs,v1")
+	outputDir := t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "Commit,v1.gitdeps.xml")
+	packTree(t, map[string]string{"Engine/a.txt": "a"}, packsDir, manifestPath)
+
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none",
+		"--manifest", manifestPath, "--packs-dir", packsDir, "--output-dir", outputDir, "--strict"))
+	got, err := os.ReadFile(filepath.Join(outputDir, "Engine/a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(got))
+
+	assert.Equal(t, NormalExitCode, runCommand(t, "gitDeps", "printUrls", "--config", "none", "--input", manifestPath))
+}
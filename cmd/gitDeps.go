@@ -4,15 +4,18 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"runtime"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
-	"net/http"
 )
 
 var gitDepsCmd = &cobra.Command{
 	Use:   "gitDeps",
 	Short: "Downloads and extracts Unreal Engine dependencies",
+	// Flags config files may set, see configKeys
+	Annotations: map[string]string{configKeysAnnotation: "base-url,mirror,cache-dir,workers"},
 	Long: `Downloads and extracts all dependency packs from a .ue4dependencies manifest file.
 
 This command replaces Epic's Setup.sh script with a faster, more reliable implementation.`,
@@ -22,6 +25,14 @@ This command replaces Epic's Setup.sh script with a faster, more reliable implem
 		outputDir, _ := cmd.Flags().GetString("output-dir")
 		verify, _ := cmd.Flags().GetBool("verify")
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		planFormat, _ := cmd.Flags().GetString("plan-format")
+		checkRemote, _ := cmd.Flags().GetBool("check-remote")
+		statsFile, _ := cmd.Flags().GetString("stats-file")
+		baseUrl, _ := cmd.Flags().GetString("base-url")
+		mirrors, _ := cmd.Flags().GetStringSlice("mirror")
+		cacheDir, _ := cmd.Flags().GetString("cache-dir")
+		workers, _ := cmd.Flags().GetInt("workers")
 
 		// Set log level
 		if verbose {
@@ -35,16 +46,95 @@ This command replaces Epic's Setup.sh script with a faster, more reliable implem
 		manifest, err := gitDeps.GetManifestFromInput(input)
 		if err != nil {
 			logrus.Errorf("failed to parse manifest: %s", err)
-			logrus.Exit(UnknownExitCode)
+			logrus.Exit(ManifestExitCode)
 		}
 
+		overrideBaseUrl(manifest, baseUrl)
 		logrus.Infof("found %d packs to download", len(manifest.Packs))
 		logrus.Infof("base URL: %s", manifest.BaseUrl)
 
+		workingManifest := workingManifestPath(cmd, []string{input})
+		extractOpts, err := extractOptionsFromFlags(cmd, workingManifest)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+		extractOpts.VerifyHashes = verify
+		extractOpts.Mirrors = mirrors
+		extractOpts.CacheDir = cacheDir
+
+		httpClient, err := httpClientFromFlags(cmd)
+		if err != nil {
+			logrus.Errorf("invalid network options: %s", err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		if dryRun {
+			plan, err := gitDeps.PlanExtraction(*manifest, outputDir, gitDeps.PlanOptions{
+				ModifiedPolicy: extractOpts.ModifiedPolicy,
+				Workers:        workers,
+			})
+			if err != nil {
+				logrus.Errorf("failed to plan download: %s", err)
+				logrus.Exit(UnknownExitCode)
+			}
+			if checkRemote {
+				if err := gitDeps.CheckRemotePacks(cmd.Context(), *httpClient, plan); err != nil {
+					logrus.Errorf("failed to check remote packs: %s", err)
+					logrus.Exit(exitCodeForError(err))
+				}
+			}
+			if err := printPlan(plan, planFormat); err != nil {
+				logrus.Error(err)
+				logrus.Exit(UnknownExitCode)
+			}
+			return
+		}
+
+		neededPacks := gitDeps.PacksWithFiles(*manifest, manifest.Files)
+		var downloadBytes int64
+		for _, pack := range manifest.Packs {
+			if neededPacks[pack.Hash] {
+				downloadBytes += int64(pack.CompressedSize)
+			}
+		}
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+		progress := gitDeps.NewProgress(len(neededPacks), downloadBytes)
+		extractOpts.Progress = progress
+		extractOpts.Workers = workers
+		stats := gitDeps.NewSyncStats(workers)
+		extractOpts.Stats = stats
+		stopProgress := startProgress(progress)
+
 		// Download and extract all packs
-		err = gitDeps.DownloadAllPacks(*http.DefaultClient, *manifest, outputDir, verify)
+		summary, err := gitDeps.ExtractAll(cmd.Context(), *manifest, outputDir, gitDeps.ExtractAllOptions{
+			ExtractOptions: extractOpts,
+			HTTPClient:     httpClient,
+		})
+		stopProgress()
+		logrus.Info(progress.Snapshot().String())
+		if err := writeStatsFile(statsFile, stats); err != nil {
+			logrus.Errorf("failed to write stats file: %s", err)
+		}
+		result := summary.Result
+		result.Sort()
+		logModifiedFiles(result)
+		if cmd.Context().Err() != nil {
+			logrus.Error("interrupted, stopping download")
+			logrus.Exit(CancelledExitCode)
+		}
 		if err != nil {
-			logrus.Errorf("failed to download packs: %s", err)
+			logrus.Errorf("failed to download %d packs:", len(summary.Errors))
+			for _, err := range summary.Errors {
+				logrus.Error(err)
+			}
+			logrus.Exit(exitCodeForErrors(summary.Errors))
+		}
+
+		if err := writeWorkingManifest(workingManifest, *manifest, extractOpts, result); err != nil {
+			logrus.Errorf("failed to write working manifest: %s", err)
 			logrus.Exit(UnknownExitCode)
 		}
 
@@ -58,6 +148,16 @@ func init() {
 	// Define flags
 	gitDepsCmd.Flags().StringP("input", "i", ".", "Path to .ue4dependencies file or directory containing it")
 	gitDepsCmd.Flags().StringP("output-dir", "o", ".", "Directory to extract dependencies to")
-	gitDepsCmd.Flags().BoolP("verify", "v", true, "Verify SHA1 checksums of downloaded packs")
+	gitDepsCmd.Flags().BoolP("verify", "v", true, "Fail instead of warning when an extracted file does not match its SHA1 in the manifest")
 	gitDepsCmd.Flags().Bool("verbose", false, "Enable verbose logging")
+	gitDepsCmd.Flags().Int("workers", 0, "Number of packs to download and extract in parallel (default: number of CPUs)")
+	gitDepsCmd.Flags().String("base-url", "", "Download packs from this base URL instead of the one in the manifest")
+	gitDepsCmd.Flags().StringSlice("mirror", []string{}, "Base URL to download a pack from when the primary one fails (repeatable, tried in order)")
+	gitDepsCmd.Flags().String("cache-dir", "", "Directory to reuse downloaded packs from and store new ones in as <hash>.pack.gz")
+	addModifiedFileFlags(gitDepsCmd)
+	addNetworkFlags(gitDepsCmd)
+	gitDepsCmd.Flags().String("stats-file", "", "Write JSON timing statistics (time per phase, slowest packs) to this path")
+	gitDepsCmd.Flags().Bool("dry-run", false, "Print what would be downloaded and extracted without writing any files")
+	gitDepsCmd.Flags().String("plan-format", "text", "Format of the --dry-run plan. Valid values are 'text' and 'json'.")
+	gitDepsCmd.Flags().Bool("check-remote", false, "With --dry-run, send a HEAD request for every pack to check it is available")
 }
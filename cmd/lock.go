@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Writes a lockfile mapping pack hashes to sha256",
+	Long: `Writes a lockfile mapping the packs of a .gitdeps.xml manifest to the sha256 of
+their compressed content.
+
+Pack hashes are SHA1 of the uncompressed data, so Bazel's repository cache,
+which is keyed by sha256, can't be searched with them directly. With a
+lockfile, 'extract --repository-cache <dir> --lockfile <file>' reuses packs
+that Bazel already downloaded without copying them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		packsDirs, _ := cmd.Flags().GetStringArray("packs-dir")
+		output, _ := cmd.Flags().GetString("output")
+
+		manifest, err := gitDeps.GetManifestFromInput(manifestPath)
+		if err != nil {
+			logrus.Errorf("failed to parse manifest: %s", err)
+			logrus.Exit(ManifestExitCode)
+		}
+
+		lock, err := gitDeps.NewPackLockfile(*manifest, &gitDeps.PackLocator{Dirs: packsDirs})
+		if err != nil {
+			logrus.Errorf("failed to hash packs: %s", err)
+			logrus.Exit(UnknownExitCode)
+		}
+		if missing := len(manifest.Packs) - len(lock.Packs); missing > 0 {
+			logrus.Warnf("%d packs were not found in %v and are not locked", missing, packsDirs)
+		}
+
+		if err := gitDeps.WritePackLockfile(output, lock); err != nil {
+			logrus.Errorf("failed to write lockfile: %s", err)
+			logrus.Exit(UnknownExitCode)
+		}
+		logrus.Infof("wrote %d packs to %s", len(lock.Packs), output)
+	},
+}
+
+func init() {
+	gitDepsCmd.AddCommand(lockCmd)
+
+	// Define flags
+	lockCmd.Flags().String("manifest", "", "Path to .gitdeps.xml manifest file")
+	lockCmd.Flags().StringArray("packs-dir", []string{}, "Directory containing downloaded packs (repeatable)")
+	lockCmd.Flags().String("output", "gitdeps.lock.json", "Path of the lockfile to write")
+
+	lockCmd.MarkFlagRequired("manifest")
+	lockCmd.MarkFlagRequired("packs-dir")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// manifestFromInputs parses every manifest in inputs (see gitDeps.GetManifestFromInput) and
+// merges them into one
+func manifestFromInputs(inputs []string) (*gitDeps.WorkingManifest, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("no manifest given")
+	}
+
+	var manifests []gitDeps.WorkingManifest
+	for _, input := range inputs {
+		manifest, err := gitDeps.GetManifestFromInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", input, err)
+		}
+		manifests = append(manifests, *manifest)
+	}
+
+	if len(manifests) > 1 {
+		logrus.Infof("merging %d manifests", len(manifests))
+	}
+	return gitDeps.MergeManifests(manifests...)
+}
+
+// overrideBaseUrl points the manifest and all its packs at baseUrl, if set
+func overrideBaseUrl(manifest *gitDeps.WorkingManifest, baseUrl string) {
+	if baseUrl == "" {
+		return
+	}
+	manifest.BaseUrl = baseUrl
+	for i := range manifest.Packs {
+		manifest.Packs[i].BaseUrl = baseUrl
+	}
+}
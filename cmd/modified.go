@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+const (
+	// workingManifestName is the default working manifest in the output directory, the file
+	// Epic's GitDependencies records its syncs in
+	workingManifestName = ".ue4dependencies"
+
+	// noWorkingManifest as --working-manifest disables the record of the last sync
+	noWorkingManifest = "none"
+)
+
+// addModifiedFileFlags registers the flags that control how locally modified files are handled
+func addModifiedFileFlags(cmd *cobra.Command) {
+	cmd.Flags().String("modified", string(gitDeps.ModifiedFileBackup), "What to do with files modified locally since the last sync. Valid values are 'skip', 'overwrite', 'fail' and 'backup' (rename to <file>.bak). Without a previous sync in the working manifest, every file that differs from the manifest counts as modified.")
+	cmd.Flags().String("working-manifest", "", "Manifest recording the last sync of the output directory (default: <output-dir>/"+workingManifestName+", '"+noWorkingManifest+"' disables it). Files matching it are not considered modified; it is created or updated after every sync.")
+}
+
+// workingManifestPath returns the working manifest to read and update: --working-manifest,
+// .ue4dependencies in the output directory by default, or "" if disabled. The default is not used
+// when it is one of the input manifests, so they are never overwritten.
+func workingManifestPath(cmd *cobra.Command, inputs []string) string {
+	path, _ := cmd.Flags().GetString("working-manifest")
+	switch path {
+	case noWorkingManifest:
+		return ""
+	case "":
+		outputDir, _ := cmd.Flags().GetString("output-dir")
+		path = filepath.Join(outputDir, workingManifestName)
+		for _, input := range inputs {
+			if sameFile(path, input) {
+				logrus.Debugf("not recording the sync in %s, it is an input manifest", path)
+				return ""
+			}
+		}
+	}
+	return path
+}
+
+// sameFile reports whether a and b are the same path, or a and the .ue4dependencies file in
+// directory b are
+func sameFile(a, b string) bool {
+	if info, err := os.Stat(b); err == nil && info.IsDir() {
+		b = filepath.Join(b, workingManifestName)
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// extractOptionsFromFlags builds extraction options from the --modified flag and the working
+// manifest at workingManifest, if any. Without a previous sync recorded there, every file whose
+// content differs from the new manifest is handled by the --modified policy.
+func extractOptionsFromFlags(cmd *cobra.Command, workingManifest string) (gitDeps.ExtractOptions, error) {
+	policyStr, _ := cmd.Flags().GetString("modified")
+
+	policy, err := gitDeps.ParseModifiedFilePolicy(policyStr)
+	if err != nil {
+		return gitDeps.ExtractOptions{}, err
+	}
+
+	opts := gitDeps.ExtractOptions{ModifiedPolicy: policy}
+	if workingManifest == "" {
+		return opts, nil
+	}
+	if _, err := os.Stat(workingManifest); os.IsNotExist(err) {
+		logrus.Debugf("working manifest %s does not exist yet, applying --modified=%s to every file that differs", workingManifest, policy)
+		return opts, nil
+	}
+	previous, err := gitDeps.GetManifestFromInput(workingManifest)
+	if err != nil {
+		return opts, err
+	}
+	opts.PreviousFiles = gitDeps.FilesByName(*previous)
+	return opts, nil
+}
+
+// logModifiedFiles prints the locally modified files found during extraction
+func logModifiedFiles(result *gitDeps.ExtractResult) {
+	if len(result.Modified) == 0 {
+		return
+	}
+	logrus.Warnf("found %d locally modified files:", len(result.Modified))
+	for _, m := range result.Modified {
+		if m.BackupPath != "" {
+			logrus.Warnf("  %s: %s (saved as %s)", m.Action, m.Name, m.BackupPath)
+		} else {
+			logrus.Warnf("  %s: %s", m.Action, m.Name)
+		}
+	}
+}
+
+// writeWorkingManifest records which manifest entries are now on disk. Written and unchanged files
+// take their entry from manifest; every other file keeps its previous entry so that skipped local
+// modifications are still detected on the next sync.
+func writeWorkingManifest(path string, manifest gitDeps.WorkingManifest, opts gitDeps.ExtractOptions, result *gitDeps.ExtractResult) error {
+	if path == "" {
+		return nil
+	}
+
+	files := make(map[string]gitDeps.File, len(opts.PreviousFiles))
+	for name, f := range opts.PreviousFiles {
+		files[name] = f
+	}
+	current := gitDeps.FilesByName(manifest)
+	for _, name := range append(append([]string{}, result.Written...), result.Unchanged...) {
+		files[name] = current[name]
+	}
+
+	working := gitDeps.WorkingManifest{BaseUrl: manifest.BaseUrl}
+	for _, f := range files {
+		working.Files = append(working.Files, f)
+	}
+	sort.Slice(working.Files, func(i, j int) bool { return working.Files[i].Name < working.Files[j].Name })
+
+	return gitDeps.WriteManifestFile(path, working)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// packTree packs files (relative path -> content) into packsDir and writes their manifest to
+// manifestPath
+func packTree(t *testing.T, files map[string]string, packsDir, manifestPath string) {
+	t.Helper()
+	srcDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	manifest, err := gitDeps.CreatePacks(srcDir, packsDir, gitDeps.CreatePackOptions{})
+	require.NoError(t, err)
+	require.NoError(t, gitDeps.WriteManifestFile(manifestPath, *manifest))
+}
+
+// backups returns the .bak files under dir
+func backups(t *testing.T, dir string) []string {
+	t.Helper()
+	var found []string
+	assert.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.HasSuffix(path, ".bak") {
+			found = append(found, path)
+		}
+		return err
+	}))
+	return found
+}
+
+func TestExtractResyncDefaultWorkingManifest(t *testing.T) {
+	packsDir, outputDir := t.TempDir(), t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "Commit.gitdeps.xml")
+
+	packTree(t, map[string]string{"Engine/a.txt": "v1", "Engine/b.txt": "same"}, packsDir, manifestPath)
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none",
+		"--manifest", manifestPath, "--packs-dir", packsDir, "--output-dir", outputDir))
+	assert.FileExists(t, filepath.Join(outputDir, ".ue4dependencies"))
+
+	// The sync is recorded in the output directory, so a plain re-sync after the dependencies
+	// changed upstream replaces out-of-date files in place
+	packTree(t, map[string]string{"Engine/a.txt": "v2", "Engine/b.txt": "same"}, packsDir, manifestPath)
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none",
+		"--manifest", manifestPath, "--packs-dir", packsDir, "--output-dir", outputDir))
+
+	got, err := os.ReadFile(filepath.Join(outputDir, "Engine/a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(got))
+	assert.Empty(t, backups(t, outputDir))
+}
+
+func TestExtractFirstSyncBacksUpDifferentFiles(t *testing.T) {
+	packsDir, outputDir := t.TempDir(), t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "Commit.gitdeps.xml")
+	packTree(t, map[string]string{"Engine/a.txt": "v1", "Engine/b.txt": "v1"}, packsDir, manifestPath)
+
+	// Without a recorded sync, a file that differs from the manifest may be a local patch
+	require.NoError(t, os.MkdirAll(filepath.Join(outputDir, "Engine"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "Engine/a.txt"), []byte("patched"), 0644))
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none",
+		"--manifest", manifestPath, "--packs-dir", packsDir, "--output-dir", outputDir))
+
+	assert.Equal(t, []string{filepath.Join(outputDir, "Engine/a.txt.bak")}, backups(t, outputDir))
+	got, err := os.ReadFile(filepath.Join(outputDir, "Engine/a.txt.bak"))
+	assert.NoError(t, err)
+	assert.Equal(t, "patched", string(got))
+}
+
+func TestExtractWorkingManifestNotWritten(t *testing.T) {
+	packsDir, outputDir := t.TempDir(), t.TempDir()
+	manifestPath := filepath.Join(outputDir, ".ue4dependencies")
+	packTree(t, map[string]string{"Engine/a.txt": "v1"}, packsDir, manifestPath)
+
+	// The input manifest is never replaced by the default working manifest
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none",
+		"--manifest", outputDir, "--packs-dir", packsDir, "--output-dir", outputDir))
+	manifest, err := gitDeps.GetManifestFromInput(manifestPath)
+	require.NoError(t, err)
+	assert.NotEmpty(t, manifest.Packs)
+
+	otherDir := t.TempDir()
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none", "--working-manifest", "none",
+		"--manifest", manifestPath, "--packs-dir", packsDir, "--output-dir", otherDir))
+	assert.NoFileExists(t, filepath.Join(otherDir, ".ue4dependencies"))
+}
+
+func TestExtractResyncWithWorkingManifest(t *testing.T) {
+	packsDir, outputDir := t.TempDir(), t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "Commit.gitdeps.xml")
+	workingManifest := filepath.Join(outputDir, ".ue4dependencies")
+	extract := func() int {
+		return runCommand(t, "extract", "--config", "none", "--manifest", manifestPath,
+			"--packs-dir", packsDir, "--output-dir", outputDir, "--working-manifest", workingManifest)
+	}
+
+	packTree(t, map[string]string{"Engine/a.txt": "v1", "Engine/b.txt": "v1"}, packsDir, manifestPath)
+	assert.Equal(t, NormalExitCode, extract())
+	assert.NoError(t, os.WriteFile(filepath.Join(outputDir, "Engine/b.txt"), []byte("local"), 0644))
+
+	// Only the file changed locally since the last sync is backed up
+	packTree(t, map[string]string{"Engine/a.txt": "v2", "Engine/b.txt": "v2"}, packsDir, manifestPath)
+	assert.Equal(t, NormalExitCode, extract())
+	assert.Equal(t, []string{filepath.Join(outputDir, "Engine/b.txt.bak")}, backups(t, outputDir))
+	for _, name := range []string{"Engine/a.txt", "Engine/b.txt"} {
+		got, err := os.ReadFile(filepath.Join(outputDir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, "v2", string(got), name)
+	}
+}
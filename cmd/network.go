@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// addNetworkFlags registers the flags that limit how hard downloads hit the network
+func addNetworkFlags(cmd *cobra.Command) {
+	cmd.Flags().String("limit-rate", "", "Maximum total download rate shared by all workers, e.g. 10M or 512KiB")
+	cmd.Flags().Int("max-conns-per-host", 0, "Maximum concurrent connections per host, 0 for unlimited")
+	cmd.Flags().String("polite-hours", "", "Time window using --polite-limit instead, e.g. \"Mon-Fri 09:00-18:00\" in local time")
+	cmd.Flags().String("polite-limit", "", "Download rate during --polite-hours, e.g. 1M")
+}
+
+// httpClientFromFlags builds the download client from the network flags
+func httpClientFromFlags(cmd *cobra.Command) (*http.Client, error) {
+	var opts gitDeps.NetworkOptions
+
+	if rate := cmd.Flags().Lookup("limit-rate").Value.String(); rate != "" {
+		limit, err := gitDeps.ParseByteSize(rate)
+		if err != nil {
+			return nil, err
+		}
+		opts.BytesPerSecond = limit
+	}
+
+	opts.MaxConnsPerHost, _ = cmd.Flags().GetInt("max-conns-per-host")
+
+	if hours := cmd.Flags().Lookup("polite-hours").Value.String(); hours != "" {
+		limit, err := gitDeps.ParseByteSize(cmd.Flags().Lookup("polite-limit").Value.String())
+		if err != nil {
+			return nil, err
+		}
+		opts.Polite, err = gitDeps.ParsePoliteSchedule(hours, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return gitDeps.NewHTTPClient(opts), nil
+}
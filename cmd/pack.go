@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// packCmd represents the pack command
+var packCmd = &cobra.Command{
+	Use:   "pack",
+	Short: "Creates packs and a .gitdeps.xml manifest from a directory",
+	Long: `Creates gzip-compressed packs and a matching .gitdeps.xml manifest from a directory.
+
+This is the inverse of extract: the resulting packs can be uploaded to a CDN
+under <base-url>/<remote-path>/<hash>, and extracting them with the manifest
+recreates the input directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		inputDir, _ := cmd.Flags().GetString("input-dir")
+		packsDir, _ := cmd.Flags().GetString("packs-dir")
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		baseUrl, _ := cmd.Flags().GetString("base-url")
+		remotePath, _ := cmd.Flags().GetString("remote-path")
+		packSize, _ := cmd.Flags().GetInt("pack-size")
+		prefixes, _ := cmd.Flags().GetStringSlice("prefix")
+		excludes, _ := cmd.Flags().GetStringSlice("exclude")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		if verbose {
+			logrus.SetLevel(logrus.DebugLevel)
+		}
+
+		manifest, err := gitDeps.CreatePacks(inputDir, packsDir, gitDeps.CreatePackOptions{
+			BaseUrl:    baseUrl,
+			RemotePath: remotePath,
+			Prefixes:   prefixes,
+			Excludes:   excludes,
+			PackSize:   packSize,
+		})
+		if err != nil {
+			logrus.Errorf("failed to create packs: %s", err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		if err := gitDeps.WriteManifestFile(manifestPath, *manifest); err != nil {
+			logrus.Errorf("failed to write manifest: %s", err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		logrus.Infof("wrote %d packs to %s and manifest to %s", len(manifest.Packs), packsDir, manifestPath)
+	},
+}
+
+func init() {
+	gitDepsCmd.AddCommand(packCmd)
+
+	// Define flags
+	packCmd.Flags().String("input-dir", ".", "Directory containing the files to pack")
+	packCmd.Flags().String("packs-dir", "", "Directory to write <hash>.pack.gz files to")
+	packCmd.Flags().String("manifest", "", "Path of the .gitdeps.xml manifest to write")
+	packCmd.Flags().String("base-url", "", "BaseUrl written to the manifest")
+	packCmd.Flags().String("remote-path", "", "RemotePath of the created packs")
+	packCmd.Flags().Int("pack-size", gitDeps.DefaultPackSize, "Target uncompressed pack size in bytes")
+	packCmd.Flags().StringSlice("prefix", []string{}, "Only pack files with these path prefixes (repeatable)")
+	packCmd.Flags().StringSlice("exclude", []string{}, "Skip files with these path prefixes (repeatable)")
+	packCmd.Flags().Bool("verbose", false, "Enable verbose logging")
+
+	packCmd.MarkFlagRequired("packs-dir")
+	packCmd.MarkFlagRequired("manifest")
+}
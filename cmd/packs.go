@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// packLocatorFromFlags builds a PackLocator from the --packs-dir, --repository-cache and
+// --lockfile flags
+func packLocatorFromFlags(cmd *cobra.Command) (*gitDeps.PackLocator, error) {
+	packsDirs, _ := cmd.Flags().GetStringArray("packs-dir")
+	repositoryCache, _ := cmd.Flags().GetString("repository-cache")
+	lockfilePath, _ := cmd.Flags().GetString("lockfile")
+
+	if len(packsDirs) == 0 && repositoryCache == "" {
+		return nil, fmt.Errorf("at least one of --packs-dir or --repository-cache is required")
+	}
+	if repositoryCache != "" && lockfilePath == "" {
+		return nil, fmt.Errorf("--repository-cache requires --lockfile to map packs to sha256")
+	}
+
+	locator := &gitDeps.PackLocator{Dirs: packsDirs, RepositoryCache: repositoryCache}
+	if lockfilePath != "" {
+		lock, err := gitDeps.ReadPackLockfile(lockfilePath)
+		if err != nil {
+			return nil, err
+		}
+		locator.Lockfile = lock
+	}
+	return locator, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// paramsFileAnnotation marks a command that Bazel runs with its arguments in a params file
+// (ctx.actions.args().use_param_file). Its value is the format the rule declares with
+// set_param_file_format: "multiline" or "shell".
+const paramsFileAnnotation = "paramsFileFormat"
+
+// paramsFileArgs expands the params files in args if they run a command annotated with
+// paramsFileAnnotation. Arguments of other commands are returned as they are, so an argument
+// starting with @ is never read as a file by accident. The command path must come before the
+// params file, e.g. "uht manifest @path".
+func paramsFileArgs(root *cobra.Command, args []string) ([]string, error) {
+	cmd, _, err := root.Find(args)
+	if err != nil {
+		return args, nil // Let cobra report the unknown command
+	}
+	format, ok := cmd.Annotations[paramsFileAnnotation]
+	if !ok {
+		return args, nil
+	}
+	return expandParamsFiles(args, format)
+}
+
+// expandParamsFiles replaces every argument of the form @path with the arguments listed in the
+// file at path, one per line. In the "multiline" format lines are taken as they are; in the
+// "shell" format they are single-quoted where needed, and the quotes are removed.
+func expandParamsFiles(args []string, format string) ([]string, error) {
+	if format != "multiline" && format != "shell" {
+		return nil, fmt.Errorf("unknown params file format %q", format)
+	}
+
+	var expanded []string
+	for _, arg := range args {
+		path, ok := strings.CutPrefix(arg, "@")
+		if !ok || path == "" {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read params file: %w", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(data) == 0 {
+			lines = nil
+		}
+		for i, line := range lines {
+			line = strings.TrimSuffix(line, "\r")
+			if format == "shell" {
+				unquoted, err := unquoteShell(line)
+				if err != nil {
+					return nil, fmt.Errorf("params file %s, line %d: %w", path, i+1, err)
+				}
+				line = unquoted
+			}
+			expanded = append(expanded, line)
+		}
+	}
+	return expanded, nil
+}
+
+// unquoteShell removes the single quotes and backslash escapes Bazel's shell params file format
+// adds around arguments
+func unquoteShell(s string) (string, error) {
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case c == '\\' && !quoted && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if quoted {
+		return "", fmt.Errorf("unterminated quote in %s", s)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht"
+)
+
+func TestExpandParamsFiles(t *testing.T) {
+	params := filepath.Join(t.TempDir(), "params")
+	require.NoError(t, os.WriteFile(params, []byte("--header\n'Public/It'\\''s.h'\nPublic/A B.h\r\n"), 0644))
+
+	tests := map[string]struct {
+		format string
+		want   []string
+	}{
+		// Lines starting with a quote are arguments like any other in the multiline format
+		"multiline": {"multiline", []string{"--x", "--header", `'Public/It'\''s.h'`, "Public/A B.h"}},
+		"shell":     {"shell", []string{"--x", "--header", "Public/It's.h", "Public/A B.h"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			args, err := expandParamsFiles([]string{"--x", "@" + params}, tt.format)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, args)
+		})
+	}
+
+	_, err := expandParamsFiles([]string{"@" + params}, "windows")
+	assert.ErrorContains(t, err, "unknown params file format")
+}
+
+func TestParamsFileArgs(t *testing.T) {
+	// Only commands Bazel runs with a params file expand them
+	args := []string{"gitDeps", "printUrls", "--input", "@deps.xml"}
+	expanded, err := paramsFileArgs(rootCmd, args)
+	require.NoError(t, err)
+	assert.Equal(t, args, expanded)
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "Foo.uhtmanifest")
+	params := filepath.Join(dir, "params")
+	require.NoError(t, os.WriteFile(params, []byte("--config\nnone\n--module-name\nFoo\n--base-dir\n"+dir+
+		"\n--output-dir\n"+dir+"\n--output\n"+output+"\n"), 0644))
+	require.Equal(t, NormalExitCode, runCommand(t, "uht", "manifest", "@"+params))
+
+	manifest, err := uht.ReadManifest(output)
+	require.NoError(t, err)
+	assert.Equal(t, "Foo", manifest.Modules[0].Name)
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// printPlan writes an extraction plan to stdout. Valid formats are 'text' and 'json'.
+func printPlan(plan *gitDeps.Plan, format string) error {
+	switch format {
+	case "json":
+		return plan.WriteJSON(os.Stdout)
+	case "text":
+		return plan.WriteText(os.Stdout)
+	default:
+		return fmt.Errorf("unknown plan format %q (valid values are 'text' and 'json')", format)
+	}
+}
@@ -12,9 +12,11 @@ import (
 var printUrlsCmd = &cobra.Command{
 	Use:   "printUrls",
 	Short: "Prints the urls of the packs in the dependency file",
-	Long:  `Prints the urls of the packs in the dependency file`,
+	// Flags config files may set, see configKeys
+	Annotations: map[string]string{configKeysAnnotation: "base-url,prefix"},
+	Long:        `Prints the urls of the packs in the dependency file`,
 	Run: func(cmd *cobra.Command, args []string) {
-		input, err := cmd.Flags().GetString("input")
+		inputs, err := cmd.Flags().GetStringArray("input")
 		if err != nil {
 			logrus.Error(err)
 			logrus.Exit(UnknownExitCode)
@@ -32,11 +34,13 @@ var printUrlsCmd = &cobra.Command{
 			logrus.Exit(UnknownExitCode)
 		}
 
-		manifest, err := gitDeps.GetManifestFromInput(input)
+		manifest, err := manifestFromInputs(inputs)
 		if err != nil {
 			logrus.Errorf("error decoding dependency file: %s", err)
-			logrus.Exit(UnknownExitCode)
+			logrus.Exit(ManifestExitCode)
 		}
+		baseUrl, _ := cmd.Flags().GetString("base-url")
+		overrideBaseUrl(manifest, baseUrl)
 
 		// Get pack URLs, optionally filtered by file prefixes
 		urls := gitDeps.GetPackUrlsWithPrefixes(*manifest, prefixes)
@@ -80,7 +84,8 @@ func init() {
 	gitDepsCmd.AddCommand(printUrlsCmd)
 
 	// Define flags
-	printUrlsCmd.Flags().StringP("input", "i", ".", "Path to .ue4dependencies file or directory containing it")
+	printUrlsCmd.Flags().StringArrayP("input", "i", []string{"."}, "Path to .ue4dependencies file or directory containing it (repeatable, manifests are merged)")
 	printUrlsCmd.Flags().StringP("output", "o", "json", "How the urls should be printed. Valid values are 'json' and 'bazel'.")
+	printUrlsCmd.Flags().String("base-url", "", "Use this base URL instead of the one in the manifest")
 	printUrlsCmd.Flags().StringSlice("prefix", []string{}, "Only include packs containing files with these path prefixes (repeatable, e.g., --prefix=Engine/Binaries --prefix=Engine/Source/Programs)")
 }
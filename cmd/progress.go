@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+const (
+	progressRedrawInterval = 200 * time.Millisecond
+	progressLogInterval    = 10 * time.Second
+)
+
+// progressDisplay renders a gitDeps.Progress. On a terminal it redraws a block of lines in
+// place (summary plus one line per busy worker) and routes log output above the block so the
+// two don't garble each other. Otherwise, e.g. under Bazel, it logs a summary line periodically.
+type progressDisplay struct {
+	progress *gitDeps.Progress
+	out      io.Writer
+	tty      bool
+
+	mu     sync.Mutex
+	lines  int       // Number of lines currently drawn
+	logOut io.Writer // logrus output before the display took over
+
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// isTerminal reports whether f is a character device, i.e. an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress starts rendering progress until the returned stop function is called
+func startProgress(progress *gitDeps.Progress) (stop func()) {
+	d := &progressDisplay{
+		progress: progress,
+		out:      os.Stdout,
+		tty:      isTerminal(os.Stdout),
+		done:     make(chan struct{}),
+	}
+
+	interval := progressLogInterval
+	if d.tty {
+		interval = progressRedrawInterval
+		d.logOut = logrus.StandardLogger().Out
+		logrus.SetOutput(d)
+	}
+
+	d.stopped.Add(1)
+	go func() {
+		defer d.stopped.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.render()
+			case <-d.done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(d.done)
+		d.stopped.Wait()
+		if d.tty {
+			d.mu.Lock()
+			d.clear()
+			d.mu.Unlock()
+			logrus.SetOutput(d.logOut)
+		}
+	}
+}
+
+func (d *progressDisplay) render() {
+	snapshot := d.progress.Snapshot()
+	if !d.tty {
+		logrus.Info(snapshot.String())
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	d.draw(snapshot)
+}
+
+// Write lets the display act as logrus output: the progress block is cleared, the log line
+// printed, and the block drawn again below it.
+func (d *progressDisplay) Write(b []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.clear()
+	n, err := d.logOut.Write(b)
+	d.draw(d.progress.Snapshot())
+	return n, err
+}
+
+// clear erases the lines drawn last. Must be called with mu held.
+func (d *progressDisplay) clear() {
+	fmt.Fprint(d.out, strings.Repeat("\x1b[1A\x1b[2K", d.lines))
+	d.lines = 0
+}
+
+// draw prints the progress block. Must be called with mu held.
+func (d *progressDisplay) draw(snapshot gitDeps.ProgressSnapshot) {
+	var b strings.Builder
+	fmt.Fprintln(&b, snapshot.String())
+	for _, w := range snapshot.Workers {
+		fmt.Fprintf(&b, "  worker %2d: %s\n", w.ID, w.Pack)
+	}
+	fmt.Fprint(d.out, b.String())
+	d.lines = 1 + len(snapshot.Workers)
+}
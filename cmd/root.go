@@ -1,46 +1,66 @@
 /*
 Copyright © 2022 NAME HERE <EMAIL ADDRESS>
-
 */
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spf13/cobra"
 )
 
-
-
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "rules-unreal-engine",
 	Short: "A pure Go replacement for all supplementary programs provided by the Unreal Engine project.",
-	Long: `A Go replacement for all supplementary programs provided by the Unreal Engine project (usually location in the Engine/Source/Programs/ folder).`,
-	// Uncomment the following line if your bare application
-	// has an action associated with it:
-	// Run: func(cmd *cobra.Command, args []string) { },
+	Long: `A Go replacement for all supplementary programs provided by the Unreal Engine project (usually location in the Engine/Source/Programs/ folder).
+
+Flag defaults can be set in a config file, either given with --config or found
+as ` + ProjectConfigFile + ` in the working directory or its parents and as
+rules-unreal-engine/config.yaml in the user config directory. Named profiles in
+the config file are selected with --profile. Every flag of the gitDeps and
+extract commands can also be set with an environment variable named
+` + EnvPrefix + `<FLAG>, e.g. ` + EnvPrefix + `PACKS_DIR for --packs-dir. Flags given on the command
+line take precedence over environment variables, which take precedence over the
+profile and then the config defaults. --config ` + noConfig + ` disables both config files
+and environment variables.`,
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		return applyLogFormat(cmd)
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+//
+// The context passed to commands is cancelled on SIGINT or SIGTERM (e.g. Ctrl-C or a Bazel
+// repository rule timeout) so long-running downloads and extractions can stop cleanly.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	args, err := paramsFileArgs(rootCmd, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(UnknownExitCode)
+	}
+	rootCmd.SetArgs(args)
+
+	err = rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func init() {
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
-
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.rules-unreal-engine.yaml)")
-
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+	rootCmd.PersistentFlags().String("config", "", "Config file with flag defaults and profiles ('"+noConfig+"' disables config files)")
+	rootCmd.PersistentFlags().String("profile", "", "Named profile from the config file to apply")
+	rootCmd.PersistentFlags().String("log-format", "text", "Log format. Valid values are 'text' and 'json'.")
 }
-
-
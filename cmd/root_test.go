@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// runCLIEnv is set when the test binary is started by runCommand to run the CLI instead of tests
+const runCLIEnv = "RULES_UNREAL_ENGINE_TEST_RUN_CLI"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runCLIEnv) != "" {
+		Execute()
+		os.Exit(NormalExitCode)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the CLI with args in a new process, as commands exit with logrus.Exit, and
+// returns its exit code. The process inherits the environment of the test.
+func runCommand(t *testing.T, args ...string) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runCLIEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	t.Logf("%v:\n%s", args, out)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NormalExitCode
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"bytes"
+
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// writeStatsFile writes the stats report to path. It does nothing if path is empty.
+func writeStatsFile(path string, stats *gitDeps.SyncStats) error {
+	if path == "" {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := stats.Report(gitDeps.DefaultSlowestPacks).WriteJSON(&buf); err != nil {
+		return err
+	}
+	return gitDeps.WriteFileAtomic(path, buf.Bytes(), 0644)
+}
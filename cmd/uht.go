@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"path/filepath"
+	"strings"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"kreempuff.dev/rules-unreal-engine/pkg/uht"
-	"strings"
 )
 
 var uhtCmd = &cobra.Command{
@@ -18,10 +20,43 @@ This includes manifest generation and other UHT-related utilities.`,
 var uhtManifestCmd = &cobra.Command{
 	Use:   "manifest",
 	Short: "Generate UHT manifest file",
+	// The uht_codegen rule (bzl/uht.bzl) passes the arguments in a multiline params file
+	Annotations: map[string]string{paramsFileAnnotation: "multiline"},
 	Long: `Generate a .uhtmanifest JSON file for UnrealHeaderTool.
 
 This command creates the manifest that tells UHT which headers to process
-and where to write generated reflection code.`,
+and where to write generated reflection code.
+
+A single module is described with --module-name, --base-dir, --output-dir and
+--header (repeatable) or --headers. For a target-wide manifest with several modules, pass a JSON or YAML
+spec file with --spec, or repeat --module, e.g.:
+
+  --module name=Core,base-dir=Source/Runtime/Core,output-dir=out/Core,header=Public/A.h
+  --module name=CoreUObject,base-dir=Source/Runtime/CoreUObject,output-dir=out/CoreUObject,dep=Core
+
+Modules are ordered so that each comes after the modules it depends on.
+
+A module's include paths default to the Public and Private directories of its
+base directory. Include paths given with --include, include= or includePaths
+replace that default rather than adding to it, as in the manifests UBT writes,
+so list Public and Private too if UHT should still search them.
+
+Arguments after "uht manifest" can be read from a file with @path, one argument
+per line and taken as is, as Bazel writes them for
+ctx.actions.args().use_param_file with the "multiline" format. Use this and
+--header, or a spec file, for modules with too many headers for one command
+line, or with commas in header paths.
+
+With --classify-headers (or classify=true for a single --module), headers can be
+all headers of a module: they are sorted into the Classes, Public, Internal and
+Private lists by their directory under the base directory, and headers in
+Detail, Impl or platform directories, with a duplicate basename, or without
+UCLASS, USTRUCT, UENUM, UINTERFACE or UDELEGATE are left out.
+
+With --relocatable, paths under the working directory (the Bazel execroot) are
+written relative to ` + uht.ExecRootPlaceholder + `, so the manifest doesn't depend on
+where it was generated and the action can run sandboxed and be cached remotely.
+Run "uht manifest resolve" right before UHT to turn them back into absolute paths.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get flags
 		moduleName, _ := cmd.Flags().GetString("module-name")
@@ -29,28 +64,89 @@ and where to write generated reflection code.`,
 		baseDir, _ := cmd.Flags().GetString("base-dir")
 		outputDir, _ := cmd.Flags().GetString("output-dir")
 		headersStr, _ := cmd.Flags().GetString("headers")
+		headerArgs, _ := cmd.Flags().GetStringArray("header")
+		includePaths, _ := cmd.Flags().GetStringArray("include")
+		defines, _ := cmd.Flags().GetStringArray("define")
 		ueRoot, _ := cmd.Flags().GetString("ue-root")
 		targetName, _ := cmd.Flags().GetString("target-name")
 		output, _ := cmd.Flags().GetString("output")
+		specPath, _ := cmd.Flags().GetString("spec")
+		moduleArgs, _ := cmd.Flags().GetStringArray("module")
+		engineVersion, _ := cmd.Flags().GetString("engine-version")
+		gameModule, _ := cmd.Flags().GetBool("game-module")
+		targetType, _ := cmd.Flags().GetString("target-type")
+		classifyHeaders, _ := cmd.Flags().GetBool("classify-headers")
+		relocatable, _ := cmd.Flags().GetBool("relocatable")
+
+		if _, err := uht.ParseTargetType(targetType); err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		if specPath == "" && len(moduleArgs) == 0 {
+			if moduleType != "" {
+				if _, err := uht.ParseModuleType(moduleType, gameModule); err != nil {
+					logrus.Errorf("--module-type: %s", err)
+					logrus.Exit(UnknownExitCode)
+				}
+			}
 
-		// Parse comma-separated headers
-		var headers []string
-		if headersStr != "" {
-			headers = strings.Split(headersStr, ",")
+			// Parse comma-separated headers
+			var headers []string
+			if headersStr != "" {
+				headers = strings.Split(headersStr, ",")
+			}
+			headers = append(headers, headerArgs...)
+
+			// Generate manifest
+			opts := uht.GenerateManifestOptions{
+				ModuleName:   moduleName,
+				ModuleType:   moduleType,
+				BaseDir:      baseDir,
+				OutputDir:    outputDir,
+				Headers:      headers,
+				IncludePaths: includePaths,
+				UERoot:       ueRoot,
+				TargetName:   targetName,
+			}
+			opts.PublicDefines = defines
+			opts.EngineVersion = engineVersion
+			opts.Game = gameModule
+			opts.ClassifyHeaders = classifyHeaders
+			opts.Relocatable = relocatable
+			opts.TargetType = uht.TargetType(targetType)
+
+			if err := uht.WriteManifestFile(output, opts); err != nil {
+				logrus.Errorf("failed to generate manifest: %s", err)
+				logrus.Exit(UnknownExitCode)
+			}
+			logrus.Infof("generated manifest: %s", output)
+			return
 		}
 
-		// Generate manifest
-		opts := uht.GenerateManifestOptions{
-			ModuleName: moduleName,
-			ModuleType: moduleType,
-			BaseDir:    baseDir,
-			OutputDir:  outputDir,
-			Headers:    headers,
-			UERoot:     ueRoot,
-			TargetName: targetName,
+		spec := readTargetSpec(specPath, moduleArgs)
+		if classifyHeaders {
+			for i := range spec.Modules {
+				spec.Modules[i].ClassifyHeaders = true
+			}
+		}
+		if cmd.Flags().Changed("target-name") || spec.TargetName == "" {
+			spec.TargetName = targetName
+		}
+		if ueRoot != "" {
+			spec.UERoot = ueRoot
+		}
+		if cmd.Flags().Changed("engine-version") || spec.EngineVersion == "" {
+			spec.EngineVersion = engineVersion
+		}
+		if cmd.Flags().Changed("target-type") || spec.TargetType == "" {
+			spec.TargetType = uht.TargetType(targetType)
+		}
+		if relocatable {
+			spec.Relocatable = true
 		}
 
-		if err := uht.WriteManifestFile(output, opts); err != nil {
+		if err := uht.WriteTargetManifestFile(output, *spec); err != nil {
 			logrus.Errorf("failed to generate manifest: %s", err)
 			logrus.Exit(UnknownExitCode)
 		}
@@ -59,22 +155,137 @@ and where to write generated reflection code.`,
 	},
 }
 
+var uhtManifestValidateCmd = &cobra.Command{
+	Use:   "validate <manifest>",
+	Short: "Check a UHT manifest file",
+	Long: `Check a .uhtmanifest file before handing it to UnrealHeaderTool.
+
+Every path must be absolute and exist, headers must be under their module's
+BaseDirectory, module names must be unique, output directories must be
+writable, and no two headers may generate files of the same name. Each problem
+is reported with the module it belongs to.
+
+Paths of relocatable manifests are resolved against --execroot first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		execRoot, _ := cmd.Flags().GetString("execroot")
+
+		manifest := readResolvedManifest(args[0], execRoot)
+
+		problems := uht.ValidateManifest(manifest)
+		for _, problem := range problems {
+			logrus.Error(problem)
+		}
+		if len(problems) > 0 {
+			logrus.Errorf("%s: %d problems found", args[0], len(problems))
+			logrus.Exit(UnknownExitCode)
+		}
+		logrus.Infof("%s: %d modules ok", args[0], len(manifest.Modules))
+	},
+}
+
+var uhtManifestResolveCmd = &cobra.Command{
+	Use:   "resolve <manifest>",
+	Short: "Turn a relocatable UHT manifest into one UHT can read",
+	Long: `Replace ` + uht.ExecRootPlaceholder + ` in the paths of a manifest generated with
+"uht manifest --relocatable" with the execroot, giving the absolute paths UHT
+needs. Run it in the action that runs UHT.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		execRoot, _ := cmd.Flags().GetString("execroot")
+		output, _ := cmd.Flags().GetString("output")
+
+		if err := uht.ResolveManifestFile(args[0], output, absExecRoot(execRoot)); err != nil {
+			logrus.Errorf("failed to resolve manifest: %s", err)
+			logrus.Exit(UnknownExitCode)
+		}
+		logrus.Debugf("resolved manifest: %s", output)
+	},
+}
+
+// readTargetSpec reads the spec file at specPath, if any, and adds the modules given as
+// key=value pairs to it
+func readTargetSpec(specPath string, moduleArgs []string) *uht.TargetSpec {
+	spec := &uht.TargetSpec{}
+	if specPath != "" {
+		var err error
+		spec, err = uht.ReadTargetSpec(specPath)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+	}
+	for _, arg := range moduleArgs {
+		module, err := uht.ParseModuleSpec(arg)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+		spec.Modules = append(spec.Modules, module)
+	}
+	return spec
+}
+
+// readResolvedManifest reads the manifest at path, resolving it against execRoot if it is relocatable
+func readResolvedManifest(path, execRoot string) *uht.UHTManifest {
+	manifest, err := uht.ReadManifest(path)
+	if err != nil {
+		logrus.Error(err)
+		logrus.Exit(UnknownExitCode)
+	}
+	if uht.IsRelocatable(manifest) {
+		execRoot = absExecRoot(execRoot)
+		logrus.Debugf("resolving relocatable manifest against %s", execRoot)
+		if err := uht.ResolveManifest(manifest, execRoot); err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+	}
+	return manifest
+}
+
+// absExecRoot returns execRoot as an absolute path, defaulting to the working directory
+func absExecRoot(execRoot string) string {
+	abs, err := filepath.Abs(execRoot)
+	if err != nil {
+		logrus.Errorf("failed to resolve execroot: %s", err)
+		logrus.Exit(UnknownExitCode)
+	}
+	return abs
+}
+
 func init() {
 	rootCmd.AddCommand(uhtCmd)
 	uhtCmd.AddCommand(uhtManifestCmd)
+	uhtManifestCmd.AddCommand(uhtManifestValidateCmd)
+	uhtManifestCmd.AddCommand(uhtManifestResolveCmd)
 
 	// Define flags for manifest command
 	uhtManifestCmd.Flags().String("module-name", "", "Module name (e.g., 'Core', 'TestModule')")
-	uhtManifestCmd.Flags().String("module-type", "Runtime", "Module type (Runtime, Developer, Editor, Program)")
+	uhtManifestCmd.Flags().String("module-type", "", "Module type, e.g. EngineRuntime or GameEditor, or a module host type such as Runtime, Developer, Editor, UncookedOnly or Program (default: Program for Program targets, Runtime otherwise)")
+	uhtManifestCmd.Flags().Bool("game-module", false, "The module belongs to a project or project plugin, so host types give Game instead of Engine module types")
+	uhtManifestCmd.Flags().String("target-type", string(uht.TargetTypeGame), "Target type (Game, Editor, Client, Server or Program), which determines IsGameTarget")
 	uhtManifestCmd.Flags().String("base-dir", "", "Module base directory (absolute path)")
 	uhtManifestCmd.Flags().String("output-dir", "", "Output directory for generated files (absolute path)")
 	uhtManifestCmd.Flags().String("headers", "", "Comma-separated list of header files (absolute paths)")
+	uhtManifestCmd.Flags().StringArray("header", []string{}, "Header file (repeatable, added to --headers)")
+	uhtManifestCmd.Flags().StringArray("include", []string{}, "Include path (repeatable). Replaces the default, the Public and Private directories of --base-dir")
+	uhtManifestCmd.Flags().StringArray("define", []string{}, "Public define, e.g. WITH_FOO=1 (repeatable)")
+	uhtManifestCmd.Flags().Bool("classify-headers", false, "Sort headers into Classes, Public, Internal and Private headers and leave out those UHT should not process")
 	uhtManifestCmd.Flags().String("ue-root", "", "Unreal Engine root directory (defaults to base-dir)")
 	uhtManifestCmd.Flags().String("target-name", "BazelTarget", "Build target name")
 	uhtManifestCmd.Flags().StringP("output", "o", "", "Output manifest file path")
+	uhtManifestCmd.Flags().String("engine-version", uht.DefaultEngineVersion, "Engine version whose manifest schema to write (supported: "+strings.Join(uht.EngineVersions(), ", ")+")")
+	uhtManifestCmd.Flags().String("spec", "", "JSON or YAML file listing the modules of a target-wide manifest")
+	uhtManifestCmd.Flags().StringArray("module", []string{}, "Module of a target-wide manifest as key=value pairs (repeatable, keys: name, type, game, base-dir, output-dir, header, include, dep, classify; values cannot contain commas, use --spec for such paths)")
+
+	uhtManifestCmd.Flags().Bool("relocatable", false, "Write paths under the working directory relative to "+uht.ExecRootPlaceholder+" (see 'uht manifest resolve')")
 
-	uhtManifestCmd.MarkFlagRequired("module-name")
-	uhtManifestCmd.MarkFlagRequired("base-dir")
-	uhtManifestCmd.MarkFlagRequired("output-dir")
 	uhtManifestCmd.MarkFlagRequired("output")
+
+	uhtManifestValidateCmd.Flags().String("execroot", ".", "Directory "+uht.ExecRootPlaceholder+" stands for in relocatable manifests")
+
+	uhtManifestResolveCmd.Flags().String("execroot", ".", "Directory "+uht.ExecRootPlaceholder+" stands for")
+	uhtManifestResolveCmd.Flags().StringP("output", "o", "", "Output manifest file path")
+	uhtManifestResolveCmd.MarkFlagRequired("output")
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht/codegen"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht/scan"
+)
+
+var uhtGenerateCmd = &cobra.Command{
+	Use:   "generate <manifest>",
+	Short: "Generate UHT code in Go, without UnrealHeaderTool (experimental)",
+	// Hidden until its output has been compared against UnrealHeaderTool's, see
+	// docs/GO_UHT_NOTES.md
+	Hidden: true,
+	Long: `Experimental: generate the .generated.h and .gen.cpp files of every header
+of a manifest, in the module's OutputDirectory, modelled on UnrealHeaderTool of
+the given engine version. The output has not been verified against
+UnrealHeaderTool's yet and may differ, e.g. in the reload version hashes, so the
+command is hidden from the help until it has been. Headers may only declare
+UENUMs for now: anything else is an error, and UnrealHeaderTool is still needed
+for such modules and for .init.gen.cpp files.
+
+Paths of relocatable manifests are resolved against --execroot first.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		execRoot, _ := cmd.Flags().GetString("execroot")
+		moduleName, _ := cmd.Flags().GetString("module-name")
+		engineVersion, _ := cmd.Flags().GetString("engine-version")
+		logrus.Warn("uht generate is experimental: its output has not been verified against UnrealHeaderTool's")
+
+		manifest := readResolvedManifest(args[0], execRoot)
+		if moduleName != "" {
+			manifest.Modules = []uht.UHTModule{*manifestModule(manifest, moduleName)}
+		}
+		outputs, err := uht.PredictOutputs(manifest, engineVersion)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		written := 0
+		for i, module := range manifest.Modules {
+			opts := codegen.Options{
+				ModuleName:    module.Name,
+				BaseDir:       module.BaseDirectory,
+				RootDir:       manifest.RootLocalPath,
+				EngineVersion: engineVersion,
+			}
+			if err := os.MkdirAll(module.OutputDirectory, 0755); err != nil {
+				logrus.Error(err)
+				logrus.Exit(UnknownExitCode)
+			}
+			for _, out := range outputs.Modules[i].Headers {
+				h, err := scan.ScanFile(out.Header)
+				if err != nil {
+					logrus.Error(err)
+					logrus.Exit(UnknownExitCode)
+				}
+				files, err := codegen.Generate(h, opts)
+				if err != nil {
+					logrus.Error(err)
+					logrus.Exit(UnknownExitCode)
+				}
+				for _, f := range []struct {
+					path     string
+					contents []byte
+				}{{out.GeneratedH, files.GeneratedH}, {out.GenCpp, files.GenCpp}} {
+					if err := os.WriteFile(f.path, f.contents, 0644); err != nil {
+						logrus.Error(err)
+						logrus.Exit(UnknownExitCode)
+					}
+					logrus.Debugf("wrote %s", f.path)
+					written++
+				}
+			}
+		}
+		logrus.Infof("%s: %d files written", args[0], written)
+	},
+}
+
+func init() {
+	uhtCmd.AddCommand(uhtGenerateCmd)
+
+	uhtGenerateCmd.Flags().String("execroot", ".", "Directory a relocatable manifest is resolved against")
+	uhtGenerateCmd.Flags().String("module-name", "", "Only generate code for this module of the manifest")
+	uhtGenerateCmd.Flags().String("engine-version", uht.DefaultEngineVersion, "Engine version whose UHT output to match")
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht"
+)
+
+var uhtOutputsCmd = &cobra.Command{
+	Use:   "outputs",
+	Short: "List the files UHT will generate",
+	Long: `Print, as JSON, the .generated.h, .gen.cpp and .init.gen.cpp files
+UnrealHeaderTool of the given engine version generates for a manifest.
+
+The manifest is read with --manifest, or built like "uht manifest" does from a
+spec file (--spec) or modules (--module), including header classification.
+With --files only the list of generated files is printed, in a stable order.
+With --format text that list is printed one file per line, for scripts like the
+uht_codegen Bazel rule.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		specPath, _ := cmd.Flags().GetString("spec")
+		moduleArgs, _ := cmd.Flags().GetStringArray("module")
+		engineVersion, _ := cmd.Flags().GetString("engine-version")
+		filesOnly, _ := cmd.Flags().GetBool("files")
+		format, _ := cmd.Flags().GetString("format")
+
+		if format != "json" && format != "text" {
+			logrus.Errorf("invalid --format %q, must be 'json' or 'text'", format)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		var manifest *uht.UHTManifest
+		var err error
+		switch {
+		case manifestPath != "" && (specPath != "" || len(moduleArgs) > 0):
+			logrus.Error("--manifest cannot be combined with --spec or --module")
+			logrus.Exit(UnknownExitCode)
+		case manifestPath != "":
+			manifest, err = uht.ReadManifest(manifestPath)
+		case specPath != "" || len(moduleArgs) > 0:
+			spec := readTargetSpec(specPath, moduleArgs)
+			if cmd.Flags().Changed("engine-version") || spec.EngineVersion == "" {
+				spec.EngineVersion = engineVersion
+			}
+			engineVersion = spec.EngineVersion
+			manifest, err = uht.NewTargetManifest(*spec)
+		default:
+			logrus.Error("one of --manifest, --spec or --module is required")
+			logrus.Exit(UnknownExitCode)
+		}
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		outputs, err := uht.PredictOutputs(manifest, engineVersion)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		switch {
+		case format == "text":
+			for _, file := range outputs.Files() {
+				if _, err = fmt.Fprintln(os.Stdout, file); err != nil {
+					break
+				}
+			}
+		case filesOnly:
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(outputs.Files())
+		default:
+			err = outputs.WriteJSON(os.Stdout)
+		}
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+	},
+}
+
+func init() {
+	uhtCmd.AddCommand(uhtOutputsCmd)
+
+	uhtOutputsCmd.Flags().String("manifest", "", "UHT manifest file")
+	uhtOutputsCmd.Flags().String("spec", "", "JSON or YAML file listing the modules of a target-wide manifest")
+	uhtOutputsCmd.Flags().StringArray("module", []string{}, "Module as key=value pairs, as for 'uht manifest' (repeatable)")
+	uhtOutputsCmd.Flags().String("engine-version", uht.DefaultEngineVersion, "Engine version whose UHT output names to use")
+	uhtOutputsCmd.Flags().Bool("files", false, "Only print the list of generated files")
+	uhtOutputsCmd.Flags().String("format", "json", "Output format. Valid values are 'json' and 'text' (the generated files, one per line).")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht/scan"
+)
+
+var uhtScanCmd = &cobra.Command{
+	Use:   "scan [headers...]",
+	Short: "Print the reflected types of a module's headers",
+	Long: `Scan the headers of a module with the Go header scanner and print, as JSON,
+every UCLASS, USTRUCT, UENUM and UINTERFACE with its UPROPERTY and UFUNCTION
+members, specifiers, metadata, line numbers and preprocessor conditions.
+
+The headers are given as arguments, or taken from a module of a manifest
+(--manifest). --module-name names the module, and picks the module of a
+manifest that has more than one. Relocatable manifests are resolved against
+--execroot first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		moduleName, _ := cmd.Flags().GetString("module-name")
+		execRoot, _ := cmd.Flags().GetString("execroot")
+
+		headers := args
+		switch {
+		case manifestPath != "" && len(args) > 0:
+			logrus.Error("--manifest cannot be combined with header arguments")
+			logrus.Exit(UnknownExitCode)
+		case manifestPath != "":
+			module := manifestModule(readResolvedManifest(manifestPath, execRoot), moduleName)
+			moduleName = module.Name
+			headers = moduleHeaders(module)
+		case len(args) == 0:
+			logrus.Error("headers or --manifest are required")
+			logrus.Exit(UnknownExitCode)
+		}
+
+		module, err := scan.ScanModule(moduleName, headers)
+		if err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+		if err := module.WriteJSON(os.Stdout); err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+	},
+}
+
+// moduleHeaders returns every header of a manifest module
+func moduleHeaders(module *uht.UHTModule) []string {
+	var headers []string
+	for _, list := range [][]string{module.ClassesHeaders, module.PublicHeaders, module.InternalHeaders, module.PrivateHeaders} {
+		headers = append(headers, list...)
+	}
+	return headers
+}
+
+// manifestModule returns the module of manifest called name, or its only module if name is empty
+func manifestModule(manifest *uht.UHTManifest, name string) *uht.UHTModule {
+	if name == "" {
+		if len(manifest.Modules) != 1 {
+			logrus.Errorf("the manifest has %d modules, pick one with --module-name", len(manifest.Modules))
+			logrus.Exit(UnknownExitCode)
+		}
+		return &manifest.Modules[0]
+	}
+	for i := range manifest.Modules {
+		if manifest.Modules[i].Name == name {
+			return &manifest.Modules[i]
+		}
+	}
+	logrus.Errorf("module %s is not in the manifest", name)
+	logrus.Exit(UnknownExitCode)
+	return nil
+}
+
+func init() {
+	uhtCmd.AddCommand(uhtScanCmd)
+
+	uhtScanCmd.Flags().String("manifest", "", "UHT manifest file whose module headers to scan")
+	uhtScanCmd.Flags().String("module-name", "", "Module name, and the module to scan from --manifest")
+	uhtScanCmd.Flags().String("execroot", ".", "Directory a relocatable manifest is resolved against")
+}
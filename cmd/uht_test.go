@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht"
+)
+
+func TestUHTManifestModuleTypeDefault(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"game target":           {nil, "EngineRuntime"},
+		"program target":        {[]string{"--target-type", "Program"}, "Program"},
+		"explicit type":         {[]string{"--target-type", "Program", "--module-type", "Editor"}, "EngineEditor"},
+		"game module host type": {[]string{"--module-type", "Editor", "--game-module"}, "GameEditor"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(dir, name+".uhtmanifest")
+			args := append([]string{"uht", "manifest", "--config", "none", "--module-name", "Foo",
+				"--base-dir", dir, "--output-dir", dir, "--output", output}, tt.args...)
+			require.Equal(t, NormalExitCode, runCommand(t, args...))
+
+			manifest, err := uht.ReadManifest(output)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, manifest.Modules[0].ModuleType)
+		})
+	}
+}
+
+func TestUHTOutputsFormat(t *testing.T) {
+	dir := t.TempDir()
+	module := "name=Foo,base-dir=" + dir + ",output-dir=" + dir
+	assert.Equal(t, NormalExitCode, runCommand(t, "uht", "outputs", "--config", "none", "--module", module, "--format", "text"))
+	assert.Equal(t, UnknownExitCode, runCommand(t, "uht", "outputs", "--config", "none", "--module", module, "--format", "yaml"))
+}
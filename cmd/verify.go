@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kreempuff.dev/rules-unreal-engine/pkg/gitDeps"
+)
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks an extracted directory against a .gitdeps.xml manifest",
+	Long: `Checks every file of a .gitdeps.xml manifest in an extracted directory.
+
+Files are checked for presence, SHA1 and executable bit, and files in the
+directory that the manifest doesn't list are reported as extra. The report is
+written to stdout as JSON. The command exits with a non-zero code when a file
+of the manifest is missing or does not match, which makes it usable in CI to
+catch cache poisoning and accidental local edits. Extra files only fail the
+command with --strict, with their own exit code.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifestPath, _ := cmd.Flags().GetString("manifest")
+		dir, _ := cmd.Flags().GetString("dir")
+		prefixes, _ := cmd.Flags().GetStringSlice("prefix")
+		workers, _ := cmd.Flags().GetInt("workers")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		strict, _ := cmd.Flags().GetBool("strict")
+
+		if verbose {
+			logrus.SetLevel(logrus.DebugLevel)
+		}
+
+		manifest, err := gitDeps.GetManifestFromInput(manifestPath)
+		if err != nil {
+			logrus.Errorf("failed to parse manifest: %s", err)
+			logrus.Exit(ManifestExitCode)
+		}
+
+		report, err := gitDeps.VerifyTree(cmd.Context(), *manifest, dir, gitDeps.VerifyOptions{
+			Prefixes: prefixes,
+			Workers:  workers,
+			Ignore:   []string{workingManifestName},
+		})
+		if err != nil {
+			logrus.Errorf("failed to verify %s: %s", dir, err)
+			logrus.Exit(exitCodeForError(err))
+		}
+
+		if err := report.WriteJSON(os.Stdout); err != nil {
+			logrus.Error(err)
+			logrus.Exit(UnknownExitCode)
+		}
+
+		if !report.OK() {
+			logrus.Errorf("%s does not match the manifest: %d missing, %d modified, %d with wrong mode, %d extra",
+				dir, len(report.Missing), len(report.Modified), len(report.ModeMismatch), len(report.Extra))
+			logrus.Exit(IntegrityExitCode)
+		}
+		if len(report.Extra) > 0 {
+			if strict {
+				logrus.Errorf("%s has %d files the manifest doesn't list", dir, len(report.Extra))
+				logrus.Exit(ExtraFilesExitCode)
+			}
+			logrus.Warnf("%s has %d files the manifest doesn't list", dir, len(report.Extra))
+		}
+		logrus.Infof("verified %d files in %s", report.Checked, dir)
+	},
+}
+
+func init() {
+	gitDepsCmd.AddCommand(verifyCmd)
+
+	// Define flags
+	verifyCmd.Flags().String("manifest", "", "Path to .gitdeps.xml manifest file")
+	verifyCmd.Flags().String("dir", ".", "Directory the manifest was extracted to")
+	verifyCmd.Flags().StringSlice("prefix", []string{}, "Only verify files with these path prefixes (repeatable)")
+	verifyCmd.Flags().Int("workers", 0, "Number of files to hash in parallel (default: number of CPUs)")
+	verifyCmd.Flags().Bool("verbose", false, "Enable verbose logging")
+	verifyCmd.Flags().Bool("strict", false, "Also fail when the directory has files the manifest doesn't list")
+
+	verifyCmd.MarkFlagRequired("manifest")
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyExtraFiles(t *testing.T) {
+	packsDir, dir := t.TempDir(), t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "Commit.gitdeps.xml")
+	packTree(t, map[string]string{"Engine/a.txt": "a"}, packsDir, manifestPath)
+	assert.Equal(t, NormalExitCode, runCommand(t, "extract", "--config", "none",
+		"--manifest", manifestPath, "--packs-dir", packsDir, "--output-dir", dir))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Engine/extra.txt"), []byte("extra"), 0644))
+
+	// Extra files are reported, and only fail the command with --strict
+	assert.Equal(t, NormalExitCode, runCommand(t, "gitDeps", "verify", "--config", "none",
+		"--manifest", manifestPath, "--dir", dir))
+	assert.Equal(t, ExtraFilesExitCode, runCommand(t, "gitDeps", "verify", "--config", "none",
+		"--manifest", manifestPath, "--dir", dir, "--strict"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "Engine/a.txt"), []byte("modified"), 0644))
+	assert.Equal(t, IntegrityExitCode, runCommand(t, "gitDeps", "verify", "--config", "none",
+		"--manifest", manifestPath, "--dir", dir))
+}
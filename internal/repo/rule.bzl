@@ -1,3 +1,19 @@
+# Exit codes of the gitDeps tool, keep in sync with cmd/exit.go
+_GITDEPS_EXIT_CODES = {
+    2: "interrupted",
+    3: "the manifest could not be read or parsed",
+    4: "a pack could not be downloaded (CDN unavailable or pack missing)",
+    5: "pack data does not match the manifest (corrupt download or cache)",
+    6: "the manifest contains a path outside the output directory",
+    7: "a locally modified file blocked extraction",
+    8: "some requested files were not produced (see gitdeps_report.json)",
+    9: "the output directory has files the manifest doesn't list",
+}
+
+def _describe_exit_code(code):
+    """Returns a human readable reason for a gitDeps exit code."""
+    return _GITDEPS_EXIT_CODES.get(code, "unknown error (exit code {})".format(code))
+
 def _build_gitdeps(repo_ctx):
     """Build gitDeps tool using downloaded Go SDK (hermetic, during loading phase).
 
@@ -85,6 +101,8 @@ def _parse_pack_urls(repo_ctx, gitdeps_binary, manifest_path, prefixes):
         "printUrls",
         "--input", manifest_path,
         "--output", "json",
+        # Ignore config files and GITDEPS_* environment variables so results only depend on the rule's attributes
+        "--config", "none",
     ]
 
     # Add all prefixes (gitDeps now supports multiple --prefix flags)
@@ -94,7 +112,7 @@ def _parse_pack_urls(repo_ctx, gitdeps_binary, manifest_path, prefixes):
     result = repo_ctx.execute(args)
 
     if result.return_code != 0:
-        fail("Failed to parse manifest: " + result.stderr)
+        fail("Failed to parse manifest ({}): {}".format(_describe_exit_code(result.return_code), result.stderr))
 
     # Parse JSON output: ["url1", "url2", ...]
     urls = json.decode(result.stdout)
@@ -176,6 +194,9 @@ def _unreal_engine_impl(repo_ctx):
             "--packs-dir", "packs",
             "--manifest", manifest_path,
             "--output-dir", "UnrealEngine",
+            "--strict",
+            "--report", "gitdeps_report.json",
+            "--config", "none",
         ]
         for prefix in prefixes:
             extract_args.extend(["--prefix", prefix])
@@ -187,7 +208,7 @@ def _unreal_engine_impl(repo_ctx):
         )
 
         if exec_result.return_code != 0:
-            fail("Failed to extract packs: {}{}".format(exec_result.stdout, exec_result.stderr))
+            fail("Failed to extract packs ({}): {}{}".format(_describe_exit_code(exec_result.return_code), exec_result.stdout, exec_result.stderr))
     else:
         # Fallback: Use gitDeps directly (downloads + extracts in one go)
         print("Downloading dependencies using gitDeps (no Bazel cache)...")
@@ -202,13 +223,14 @@ def _unreal_engine_impl(repo_ctx):
                 "--input", manifest_path,
                 "--output-dir", "UnrealEngine",
                 "--verify=false",
+                "--config", "none",
             ],
             quiet = False,
             timeout = 3600,  # 1 hour timeout
         )
 
         if exec_result.return_code != 0:
-            fail("Failed to download dependencies: " + exec_result.stdout + exec_result.stderr)
+            fail("Failed to download dependencies ({}): {}{}".format(_describe_exit_code(exec_result.return_code), exec_result.stdout, exec_result.stderr))
 
     print("Unreal Engine dependencies ready")
 
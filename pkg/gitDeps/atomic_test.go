@@ -0,0 +1,42 @@
+package gitDeps
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lib.so")
+
+	assert.NoError(t, WriteFileAtomic(path, []byte("first"), 0755))
+	assert.NoError(t, WriteFileAtomic(path, []byte("second"), 0755))
+
+	got, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", string(got))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files should be left behind")
+}
+
+func TestWriteFileAtomicFailureCleansUp(t *testing.T) {
+	dir := t.TempDir()
+	// Renaming a file over a non-empty directory fails
+	target := filepath.Join(dir, "target")
+	writeTree(t, dir, map[string]string{"target/keep": "keep"})
+
+	assert.Error(t, WriteFileAtomic(target, []byte("data"), 0644))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary file should be removed")
+}
@@ -0,0 +1,238 @@
+package gitDeps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// ReadBundle calls fn for every entry of a tar, gzip-compressed tar or zip archive read from r,
+// in the order the entries are stored. name is the entry's path inside the archive and data
+// its content, which is only valid until fn returns. The format is detected from the content,
+// so r can be a stream such as stdin; zip archives are spooled to a temporary file first
+// because their index is at the end.
+func ReadBundle(ctx context.Context, r io.Reader, fn func(name string, data io.Reader) error) error {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, zipMagic):
+		return readZipBundle(ctx, br, fn)
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("failed to decompress bundle: %w", err)
+		}
+		defer gzr.Close()
+		return readTarBundle(ctx, gzr, fn)
+	default:
+		return readTarBundle(ctx, br, fn)
+	}
+}
+
+func readTarBundle(ctx context.Context, r io.Reader, fn func(name string, data io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func readZipBundle(ctx context.Context, r io.Reader, fn func(name string, data io.Reader) error) error {
+	tmp, err := os.CreateTemp("", "gitdeps-bundle-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return fmt.Errorf("failed to spool bundle: %w", err)
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open %s in bundle: %w", f.Name, err)
+		}
+		err = fn(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// packHashFromEntry returns the pack hash for an archive entry named <hash>.pack.gz or <hash>,
+// optionally inside directories such as <RemotePath>/
+func packHashFromEntry(name string) string {
+	return strings.TrimSuffix(path.Base(name), ".pack.gz")
+}
+
+// ExtractBundle extracts files from the packs stored in a bundle archive (see ReadBundle).
+// Packs are extracted in whatever order they appear, in parallel, and entries that are not
+// packs of manifest or hold none of the requested files are ignored. Packs holding requested
+// files that are not in the bundle are returned as skipped.
+func ExtractBundle(ctx context.Context, r io.Reader, manifest WorkingManifest, files []File, targetDir string, opts ExtractOptions) (*ExtractResult, []SkippedPack, error) {
+	// Index the requested files by the pack they are stored in
+	packs := map[string]*Pack{}
+	for i := range manifest.Packs {
+		packs[manifest.Packs[i].Hash] = &manifest.Packs[i]
+	}
+	blobsByPack := map[string][]Blob{}
+	packByBlob := map[string]string{}
+	for _, blob := range manifest.Blobs {
+		blobsByPack[blob.PackHash] = append(blobsByPack[blob.PackHash], blob)
+		packByBlob[blob.Hash] = blob.PackHash
+	}
+	filesByPack := map[string][]File{}
+	for _, file := range files {
+		if packHash, ok := packByBlob[file.Hash]; ok {
+			filesByPack[packHash] = append(filesByPack[packHash], file)
+		}
+	}
+
+	type packJob struct {
+		hash string
+		data []byte
+		size int64 // Compressed size
+	}
+	numWorkers := opts.Workers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	jobs := make(chan packJob, numWorkers)
+	result := &ExtractResult{}
+	var errMu sync.Mutex
+	var firstErr error
+	setErr := func(err error) {
+		errMu.Lock()
+		defer errMu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for job := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				opts.Progress.StartPack(worker, job.hash)
+				packStart := time.Now()
+				packResult, err := ExtractUEPackWithOptions(ctx, job.data, blobsByPack[job.hash], filesByPack[job.hash], targetDir, opts)
+				opts.Stats.AddPack(job.hash, time.Since(packStart), job.size)
+				opts.Progress.FinishPack(worker)
+				result.Merge(packResult)
+				if err != nil {
+					setErr(fmt.Errorf("failed to extract pack %s: %w", job.hash, err))
+				}
+			}
+		}(w)
+	}
+
+	seen := map[string]bool{}
+	readErr := ReadBundle(ctx, r, func(name string, data io.Reader) error {
+		hash := packHashFromEntry(name)
+		if len(filesByPack[hash]) == 0 || seen[hash] {
+			logrus.Debugf("ignoring bundle entry %s", name)
+			return nil
+		}
+		seen[hash] = true
+		opts.Stats.CacheHit()
+
+		readStart := time.Now()
+		compressed, err := io.ReadAll(data)
+		if err != nil {
+			return fmt.Errorf("failed to read pack %s: %w", name, err)
+		}
+		opts.Stats.AddPhase(PhaseRead, time.Since(readStart), int64(len(compressed)))
+
+		pack, ok := packs[hash]
+		if !ok {
+			return fmt.Errorf("pack %s holds files but is not in the manifest", hash)
+		}
+		packData, err := decompressPack(pack, compressed, opts.Stats)
+		if err != nil {
+			return fmt.Errorf("failed to decompress pack %s: %w", name, err)
+		}
+
+		select {
+		case jobs <- packJob{hash: hash, data: packData, size: int64(len(compressed))}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(jobs)
+	wg.Wait()
+
+	if readErr != nil {
+		return result, nil, readErr
+	}
+	if firstErr != nil {
+		return result, nil, firstErr
+	}
+
+	var skipped []SkippedPack
+	for hash := range filesByPack {
+		if !seen[hash] {
+			skipped = append(skipped, SkippedPack{Hash: hash, Reason: "pack not found in bundle"})
+			opts.Stats.CacheMiss()
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Hash < skipped[j].Hash })
+	return result, skipped, nil
+}
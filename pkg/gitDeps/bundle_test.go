@@ -0,0 +1,152 @@
+package gitDeps
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bundleEntries reads every pack of manifest from packsDir, keyed by archive entry name, plus a non-pack entry
+func bundleEntries(t *testing.T, manifest WorkingManifest, packsDir string) map[string][]byte {
+	t.Helper()
+	entries := map[string][]byte{}
+	for _, pack := range manifest.Packs {
+		data, err := os.ReadFile(filepath.Join(packsDir, pack.Hash+".pack.gz"))
+		assert.NoError(t, err)
+		entries["packs/"+pack.Hash+".pack.gz"] = data
+	}
+	entries["README.txt"] = []byte("not a pack")
+	return entries
+}
+
+func tarBundle(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range entries {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func zipBundle(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range entries {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = w.Write(data)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	_, err := gzw.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, gzw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractBundle(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"Engine/Source/a.cpp": "int a;",
+		"Engine/Source/b.cpp": "int b;",
+		"Engine/Content/c":    "content",
+	}
+	writeTree(t, src, files)
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(src, packsDir, CreatePackOptions{PackSize: 1})
+	assert.NoError(t, err)
+	entries := bundleEntries(t, *manifest, packsDir)
+
+	tests := []struct {
+		name   string
+		bundle []byte
+	}{
+		{"tar", tarBundle(t, entries)},
+		{"tar.gz", gzipBytes(t, tarBundle(t, entries))},
+		{"zip", zipBundle(t, entries)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetDir := t.TempDir()
+			// A plain io.Reader, as stdin would be
+			r := io.MultiReader(bytes.NewReader(tt.bundle))
+
+			result, skipped, err := ExtractBundle(context.Background(), r, *manifest, manifest.Files, targetDir, ExtractOptions{})
+			assert.NoError(t, err)
+			assert.Empty(t, skipped)
+			assert.Len(t, result.Written, len(files))
+			for name, content := range files {
+				data, err := os.ReadFile(filepath.Join(targetDir, name))
+				assert.NoError(t, err)
+				assert.Equal(t, content, string(data))
+			}
+		})
+	}
+
+	t.Run("missing packs are skipped", func(t *testing.T) {
+		// Only bundle the pack holding a.cpp
+		partial := map[string][]byte{}
+		for _, blob := range manifest.Blobs {
+			if blob.Hash == sha1Hex([]byte("int a;")) {
+				name := blob.PackHash + ".pack.gz"
+				partial[name] = entries["packs/"+name]
+			}
+		}
+
+		var requested []File
+		for _, f := range manifest.Files {
+			if f.Name != "Engine/Content/c" {
+				requested = append(requested, f)
+			}
+		}
+
+		result, skipped, err := ExtractBundle(context.Background(), bytes.NewReader(tarBundle(t, partial)), *manifest, requested, t.TempDir(), ExtractOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Engine/Source/a.cpp"}, result.Written)
+		assert.Len(t, skipped, 1)
+
+		report := NewCompletenessReport(*manifest, requested, result, skipped)
+		if assert.Len(t, report.Missing, 1) {
+			assert.Equal(t, "Engine/Source/b.cpp", report.Missing[0].Name)
+			assert.Equal(t, "pack skipped: pack not found in bundle", report.Missing[0].Reason)
+		}
+	})
+
+	t.Run("corrupt pack", func(t *testing.T) {
+		corrupt := map[string][]byte{manifest.Packs[0].Hash: []byte("this is not gzip data")}
+		_, _, err := ExtractBundle(context.Background(), bytes.NewReader(tarBundle(t, corrupt)), *manifest, manifest.Files, t.TempDir(), ExtractOptions{})
+		assert.ErrorIs(t, err, gzip.ErrHeader)
+	})
+
+	t.Run("pack of the wrong size", func(t *testing.T) {
+		// The blobs are intact, but the pack inflates to more than the manifest records
+		pack := manifest.Packs[0]
+		compressed := entries["packs/"+pack.Hash+".pack.gz"]
+		data, err := DecompressPack(compressed, nil)
+		assert.NoError(t, err)
+		padded := map[string][]byte{pack.Hash: gzipBytes(t, append(data, "trailing"...))}
+		_, _, err = ExtractBundle(context.Background(), bytes.NewReader(tarBundle(t, padded)), *manifest, manifest.Files, t.TempDir(), ExtractOptions{})
+		var integrityErr *IntegrityError
+		assert.ErrorAs(t, err, &integrityErr)
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd || dragonfly)
+
+package gitDeps
+
+import "errors"
+
+// diskFree is not implemented on this platform (e.g. Windows, NetBSD, OpenBSD, Solaris); plans report the free space as unknown
+func diskFree(path string) (int64, error) {
+	return 0, errors.New("disk free space is not supported on this platform")
+}
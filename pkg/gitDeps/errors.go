@@ -0,0 +1,96 @@
+package gitDeps
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ParseError is returned by ParseFile when a manifest cannot be decoded.
+// It matches XmlDecodeError for malformed XML and UnknownError otherwise.
+type ParseError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at line %d, column %d: %s", XmlDecodeError, e.Line, e.Column, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+func (e *ParseError) Is(target error) bool {
+	var syntaxErr *xml.SyntaxError
+	var unmarshalErr xml.UnmarshalError
+	var numErr *strconv.NumError // Numeric attributes that don't parse
+	isXml := errors.As(e.Err, &syntaxErr) || errors.As(e.Err, &unmarshalErr) || errors.As(e.Err, &numErr)
+	return (target == XmlDecodeError && isXml) || (target == UnknownError && !isXml)
+}
+
+// HTTPError is returned when a pack cannot be fetched, either because the request failed
+// (StatusCode is 0 and Err is set) or because the server answered with an unexpected status.
+type HTTPError struct {
+	URL        string
+	PackHash   string
+	StatusCode int
+	Err        error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("request for pack %s (%s) failed: %s", e.PackHash, e.URL, e.Err)
+	}
+	return fmt.Sprintf("unexpected status code %d for pack %s (%s)", e.StatusCode, e.PackHash, e.URL)
+}
+
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// IntegrityError is returned when data does not match what the manifest says it should be
+type IntegrityError struct {
+	Name     string // What was checked, e.g. a file name or "blob <hash>"
+	Expected string
+	Actual   string
+	Err      error // Why the data could not be read, e.g. a truncated gzip stream, if that was the problem
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s: expected %s, got %s", e.Name, e.Expected, e.Actual)
+}
+
+func (e *IntegrityError) Unwrap() error {
+	return e.Err
+}
+
+// PathSafetyError is returned when a manifest entry would be written outside the output directory
+type PathSafetyError struct {
+	Name   string
+	Reason string
+}
+
+func (e *PathSafetyError) Error() string {
+	return fmt.Sprintf("illegal file path (%s): %s", e.Reason, e.Name)
+}
+
+// IncompleteError is returned in strict mode when some requested files were not produced
+type IncompleteError struct {
+	Missing      int
+	SkippedPacks int
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("extraction incomplete: %d requested files missing, %d packs skipped", e.Missing, e.SkippedPacks)
+}
+
+// ManifestConflictError is returned by MergeManifests when manifests disagree about a file
+type ManifestConflictError struct {
+	Conflicts []PathConflict
+}
+
+func (e *ManifestConflictError) Error() string {
+	if len(e.Conflicts) == 1 {
+		return fmt.Sprintf("conflicting manifests: %s %s", e.Conflicts[0].Name, e.Conflicts[0].Reason)
+	}
+	return fmt.Sprintf("conflicting manifests: %d files, first is %s %s", len(e.Conflicts), e.Conflicts[0].Name, e.Conflicts[0].Reason)
+}
@@ -0,0 +1,105 @@
+package gitDeps
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFileErrors(t *testing.T) {
+	t.Run("syntax error has position", func(t *testing.T) {
+		_, err := ParseFile(strings.NewReader("<WorkingManifest>\n  <Files>\n    <File Name=\"a\"\n</WorkingManifest>"))
+
+		var parseErr *ParseError
+		assert.True(t, errors.As(err, &parseErr))
+		assert.Equal(t, 4, parseErr.Line)
+		assert.True(t, errors.Is(err, XmlDecodeError))
+		assert.False(t, errors.Is(err, UnknownError))
+		assert.Contains(t, err.Error(), "line 4")
+	})
+
+	t.Run("unmarshal error", func(t *testing.T) {
+		_, err := ParseFile(strings.NewReader(`<WorkingManifest><Blobs><Blob Size="big"/></Blobs></WorkingManifest>`))
+		assert.True(t, errors.Is(err, XmlDecodeError))
+	})
+
+	t.Run("read error keeps the cause", func(t *testing.T) {
+		cause := errors.New("disk on fire")
+		_, err := ParseFile(io.MultiReader(strings.NewReader("<Working"), &failingReader{cause}))
+		assert.True(t, errors.Is(err, cause))
+		assert.True(t, errors.Is(err, UnknownError))
+	})
+}
+
+type failingReader struct{ err error }
+
+func (r *failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestHTTPError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	manifest := WorkingManifest{BaseUrl: ts.URL, Packs: []Pack{{Hash: "abc", RemotePath: "Remote"}}}
+	err := DownloadAndExtractPack(context.Background(), *ts.Client(), &manifest.Packs[0], manifest, t.TempDir(), false)
+
+	var httpErr *HTTPError
+	assert.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+	assert.Equal(t, ts.URL+"/Remote/abc", httpErr.URL)
+	assert.Equal(t, "abc", httpErr.PackHash)
+
+	// Transport failures keep the underlying error
+	ts.Close()
+	err = DownloadPack(context.Background(), io.Discard, *ts.Client(), &manifest.Packs[0], manifest)
+	assert.True(t, errors.As(err, &httpErr))
+	assert.Zero(t, httpErr.StatusCode)
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestIntegrityAndPathErrors(t *testing.T) {
+	content := []byte("content")
+	packData := append([]byte(PackHeader), content...)
+
+	t.Run("hash mismatch with verification", func(t *testing.T) {
+		blobs := []Blob{{Hash: "h", PackOffset: len(PackHeader), Size: len(content)}}
+		files := []File{{Name: "a.txt", Hash: "h", ExpectedHash: "0000"}}
+
+		_, err := ExtractUEPackWithOptions(context.Background(), packData, blobs, files, t.TempDir(), ExtractOptions{VerifyHashes: true})
+		var integrityErr *IntegrityError
+		assert.True(t, errors.As(err, &integrityErr))
+		assert.Equal(t, "a.txt", integrityErr.Name)
+		assert.Equal(t, "0000", integrityErr.Expected)
+		assert.Equal(t, sha1Hex(content), integrityErr.Actual)
+
+		// Without verification it is only a warning
+		_, err = ExtractUEPackWithOptions(context.Background(), packData, blobs, files, t.TempDir(), ExtractOptions{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("blob beyond pack data", func(t *testing.T) {
+		blobs := []Blob{{Hash: "h", PackHash: "p", PackOffset: len(PackHeader), Size: 100}}
+		files := []File{{Name: "a.txt", Hash: "h"}}
+
+		err := ExtractUEPack(context.Background(), packData, blobs, files, t.TempDir())
+		var integrityErr *IntegrityError
+		assert.True(t, errors.As(err, &integrityErr))
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		blobs := []Blob{{Hash: "h", PackOffset: len(PackHeader), Size: len(content)}}
+		files := []File{{Name: "../../etc/passwd", Hash: "h"}}
+
+		err := ExtractUEPack(context.Background(), packData, blobs, files, t.TempDir())
+		var pathErr *PathSafetyError
+		assert.True(t, errors.As(err, &pathErr))
+		assert.Equal(t, "../../etc/passwd", pathErr.Name)
+	})
+}
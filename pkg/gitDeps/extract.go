@@ -0,0 +1,223 @@
+package gitDeps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExtractAllOptions configures ExtractAll
+type ExtractAllOptions struct {
+	ExtractOptions
+
+	// Prefixes limits extraction to files with these path prefixes (empty means all files)
+	Prefixes []string
+
+	// Packs finds packs on local disk. Packs it cannot find are skipped. If nil, packs are
+	// downloaded with HTTPClient instead, see ExtractOptions.Mirrors and ExtractOptions.CacheDir.
+	Packs *PackLocator
+
+	// HTTPClient downloads packs when Packs is nil. http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+
+	// OnPack, if set, is called from the worker goroutine after each pack holding requested
+	// files was processed, whether it succeeded, failed or was skipped
+	OnPack func(PackOutcome)
+}
+
+// PackOutcome describes how processing a single pack went
+type PackOutcome struct {
+	Hash    string
+	Files   int   // Number of requested files stored in the pack
+	Skipped bool  // The pack could not be found by ExtractAllOptions.Packs
+	Err     error // Why the pack failed, if it did
+}
+
+// ExtractSummary is the outcome of ExtractAll
+type ExtractSummary struct {
+	Requested    []File         // Files selected by ExtractAllOptions.Prefixes
+	Packs        int            // Number of packs holding requested files
+	Result       *ExtractResult // Files written, unchanged or locally modified
+	SkippedPacks []SkippedPack  // Packs that could not be found, sorted by hash
+	Errors       []error        // One error per failed pack
+}
+
+// Report returns the completeness report of the extraction
+func (s *ExtractSummary) Report(manifest WorkingManifest) *CompletenessReport {
+	return NewCompletenessReport(manifest, s.Requested, s.Result, s.SkippedPacks)
+}
+
+// FilterFiles returns the files whose names start with one of prefixes, or all files if
+// prefixes is empty
+func FilterFiles(files []File, prefixes []string) []File {
+	if len(prefixes) == 0 {
+		return files
+	}
+	var filtered []File
+	for _, f := range files {
+		if matchesPrefixes(f.Name, prefixes) {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// PacksWithFiles returns the hashes of the packs of manifest that store at least one of files
+func PacksWithFiles(manifest WorkingManifest, files []File) map[string]bool {
+	packByBlob := make(map[string]string, len(manifest.Blobs))
+	for _, blob := range manifest.Blobs {
+		packByBlob[blob.Hash] = blob.PackHash
+	}
+	packs := map[string]bool{}
+	for _, file := range files {
+		if packHash, ok := packByBlob[file.Hash]; ok {
+			packs[packHash] = true
+		}
+	}
+	return packs
+}
+
+// ExtractAll extracts the files of manifest selected by opts.Prefixes into targetDir, processing
+// packs in parallel with opts.Workers workers. Packs holding none of the selected files are not
+// touched. A pack failing does not stop the others: every failure is collected in the summary's
+// Errors, and the returned error joins them. If ctx is cancelled, workers stop after their
+// current pack and ctx.Err() is returned. The summary is never nil.
+func ExtractAll(ctx context.Context, manifest WorkingManifest, targetDir string, opts ExtractAllOptions) (*ExtractSummary, error) {
+	summary := &ExtractSummary{
+		Requested: FilterFiles(manifest.Files, opts.Prefixes),
+		Result:    &ExtractResult{},
+	}
+
+	// Index the requested files by the pack they are stored in
+	blobsByPack := map[string][]Blob{}
+	packByBlob := map[string]string{}
+	for _, blob := range manifest.Blobs {
+		blobsByPack[blob.PackHash] = append(blobsByPack[blob.PackHash], blob)
+		packByBlob[blob.Hash] = blob.PackHash
+	}
+	filesByPack := map[string][]File{}
+	for _, file := range summary.Requested {
+		if packHash, ok := packByBlob[file.Hash]; ok {
+			filesByPack[packHash] = append(filesByPack[packHash], file)
+		}
+	}
+	summary.Packs = len(filesByPack)
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	numWorkers := opts.Workers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	logrus.Infof("extracting %d packs using %d workers", summary.Packs, numWorkers)
+
+	var mu sync.Mutex // Guards SkippedPacks and Errors
+	packs := make(chan Pack, numWorkers*2)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for pack := range packs {
+				if ctx.Err() != nil {
+					continue // Drain the queue without doing more work
+				}
+
+				outcome := PackOutcome{Hash: pack.Hash, Files: len(filesByPack[pack.Hash])}
+				opts.Progress.StartPack(worker, pack.Hash)
+				packResult, found, err := extractPack(ctx, *httpClient, pack, manifest, blobsByPack[pack.Hash], filesByPack[pack.Hash], targetDir, opts)
+				opts.Progress.FinishPack(worker)
+				summary.Result.Merge(packResult)
+
+				mu.Lock()
+				switch {
+				case !found:
+					outcome.Skipped = true
+					logrus.Warnf("pack file not found: %s", pack.Hash)
+					summary.SkippedPacks = append(summary.SkippedPacks, SkippedPack{Hash: pack.Hash, Reason: "pack file not found in packs dir"})
+				case err != nil && ctx.Err() == nil:
+					outcome.Err = err
+					summary.Errors = append(summary.Errors, err)
+				}
+				mu.Unlock()
+
+				if opts.OnPack != nil {
+					opts.OnPack(outcome)
+				}
+			}
+		}(w)
+	}
+
+	// Send the packs holding requested files to the workers, stopping early if cancelled
+dispatch:
+	for _, pack := range manifest.Packs {
+		if len(filesByPack[pack.Hash]) == 0 {
+			continue
+		}
+		select {
+		case packs <- pack:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(packs)
+	wg.Wait()
+
+	sort.Slice(summary.SkippedPacks, func(i, j int) bool { return summary.SkippedPacks[i].Hash < summary.SkippedPacks[j].Hash })
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+	return summary, errors.Join(summary.Errors...)
+}
+
+// extractPack reads a pack from opts.Packs, or downloads it if opts.Packs is nil, and extracts
+// files from it. found is false if opts.Packs could not find the pack.
+func extractPack(ctx context.Context, httpClient http.Client, pack Pack, manifest WorkingManifest, blobs []Blob, files []File, targetDir string, opts ExtractAllOptions) (result *ExtractResult, found bool, err error) {
+	packStart := time.Now()
+
+	var packData []byte
+	var compressedSize int
+	if opts.Packs != nil {
+		packFile, err := opts.Packs.Find(pack)
+		if errors.Is(err, os.ErrNotExist) {
+			opts.Stats.CacheMiss()
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to find pack %s: %w", pack.Hash, err)
+		}
+		opts.Stats.CacheHit()
+
+		compressed, err := os.ReadFile(packFile)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to read pack %s: %w", packFile, err)
+		}
+		opts.Stats.AddPhase(PhaseRead, time.Since(packStart), int64(len(compressed)))
+		compressedSize = len(compressed)
+		if packData, err = decompressPack(&pack, compressed, opts.Stats); err != nil {
+			return nil, true, err
+		}
+	} else {
+		packData, compressedSize, err = fetchPack(ctx, httpClient, &pack, manifest, opts.ExtractOptions)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to download pack %s: %w", pack.Hash, err)
+		}
+	}
+
+	result, err = ExtractUEPackWithOptions(ctx, packData, blobs, files, targetDir, opts.ExtractOptions)
+	opts.Stats.AddPack(pack.Hash, time.Since(packStart), int64(compressedSize))
+	if err != nil {
+		return result, true, fmt.Errorf("failed to extract pack %s: %w", pack.Hash, err)
+	}
+	return result, true, nil
+}
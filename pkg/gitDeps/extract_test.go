@@ -0,0 +1,193 @@
+package gitDeps
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// packedTree packs files into a temporary packs dir with one file per pack
+func packedTree(t *testing.T, files map[string]string) (*WorkingManifest, string) {
+	src := t.TempDir()
+	writeTree(t, src, files)
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(src, packsDir, CreatePackOptions{PackSize: 1})
+	assert.NoError(t, err)
+	return manifest, packsDir
+}
+
+// packOf returns the hash of the pack storing the named file
+func packOf(t *testing.T, manifest WorkingManifest, name string) string {
+	pack := GetPackfromFileName(name, manifest)
+	if !assert.NotNil(t, pack, name) {
+		return ""
+	}
+	return pack.Hash
+}
+
+func TestFilterFiles(t *testing.T) {
+	files := []File{{Name: "Engine/Binaries/a"}, {Name: "Engine/Source/b"}, {Name: "Samples/c"}}
+
+	assert.Equal(t, files, FilterFiles(files, nil))
+	assert.Equal(t, []File{{Name: "Engine/Binaries/a"}, {Name: "Samples/c"}}, FilterFiles(files, []string{"Engine/Binaries", "Samples"}))
+	assert.Empty(t, FilterFiles(files, []string{"Docs"}))
+}
+
+func TestExtractAll(t *testing.T) {
+	t.Run("extracts files matching prefixes", func(t *testing.T) {
+		manifest, packsDir := packedTree(t, map[string]string{
+			"Engine/Binaries/a.txt": "a",
+			"Engine/Source/b.txt":   "b",
+			"Samples/c.txt":         "c",
+		})
+		outputDir := t.TempDir()
+
+		var mu sync.Mutex
+		var outcomes []PackOutcome
+		summary, err := ExtractAll(context.Background(), *manifest, outputDir, ExtractAllOptions{
+			Prefixes: []string{"Engine/"},
+			Packs:    &PackLocator{Dirs: []string{packsDir}},
+			OnPack: func(o PackOutcome) {
+				mu.Lock()
+				defer mu.Unlock()
+				outcomes = append(outcomes, o)
+			},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, summary.Requested, 2)
+		assert.Equal(t, 2, summary.Packs)
+		assert.Len(t, outcomes, 2)
+		summary.Result.Sort()
+		assert.Equal(t, []string{"Engine/Binaries/a.txt", "Engine/Source/b.txt"}, summary.Result.Written)
+		assert.NoFileExists(t, filepath.Join(outputDir, "Samples", "c.txt"))
+		assert.True(t, summary.Report(*manifest).Complete())
+	})
+
+	t.Run("extracts many packs concurrently", func(t *testing.T) {
+		files := map[string]string{}
+		for i := 0; i < 50; i++ {
+			files[fmt.Sprintf("dir%d/file%d.txt", i%5, i)] = fmt.Sprintf("content %d", i)
+		}
+		manifest, packsDir := packedTree(t, files)
+		outputDir := t.TempDir()
+
+		progress := NewProgress(len(manifest.Packs), 0)
+		summary, err := ExtractAll(context.Background(), *manifest, outputDir, ExtractAllOptions{
+			ExtractOptions: ExtractOptions{Workers: 8, Progress: progress},
+			Packs:          &PackLocator{Dirs: []string{packsDir}},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, summary.Result.Written, len(files))
+		assert.Equal(t, int64(len(files)), progress.Snapshot().PacksDone)
+		for name, content := range files {
+			data, err := os.ReadFile(filepath.Join(outputDir, name))
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		}
+	})
+
+	t.Run("collects errors from every failed pack", func(t *testing.T) {
+		manifest, packsDir := packedTree(t, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+		for _, name := range []string{"a.txt", "b.txt"} {
+			corrupt := filepath.Join(packsDir, packOf(t, *manifest, name)+".pack.gz")
+			assert.NoError(t, os.WriteFile(corrupt, []byte("not a gzip stream at all"), 0644))
+		}
+		outputDir := t.TempDir()
+
+		summary, err := ExtractAll(context.Background(), *manifest, outputDir, ExtractAllOptions{
+			ExtractOptions: ExtractOptions{Workers: 2},
+			Packs:          &PackLocator{Dirs: []string{packsDir}},
+		})
+		assert.ErrorIs(t, err, gzip.ErrHeader)
+		assert.Len(t, summary.Errors, 2)
+		assert.Equal(t, []string{"c.txt"}, summary.Result.Written)
+		assert.FileExists(t, filepath.Join(outputDir, "c.txt"))
+	})
+
+	t.Run("skips packs that are not found", func(t *testing.T) {
+		manifest, packsDir := packedTree(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+		missing := packOf(t, *manifest, "b.txt")
+		assert.NoError(t, os.Remove(filepath.Join(packsDir, missing+".pack.gz")))
+
+		var skipped []string
+		summary, err := ExtractAll(context.Background(), *manifest, t.TempDir(), ExtractAllOptions{
+			ExtractOptions: ExtractOptions{Workers: 1},
+			Packs:          &PackLocator{Dirs: []string{packsDir}},
+			OnPack: func(o PackOutcome) {
+				if o.Skipped {
+					skipped = append(skipped, o.Hash)
+				}
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{missing}, skipped)
+		assert.Equal(t, []SkippedPack{{Hash: missing, Reason: "pack file not found in packs dir"}}, summary.SkippedPacks)
+
+		report := summary.Report(*manifest)
+		assert.False(t, report.Complete())
+		assert.Equal(t, "b.txt", report.Missing[0].Name)
+	})
+
+	t.Run("downloads packs without a locator", func(t *testing.T) {
+		manifest, packsDir := packedTree(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, filepath.Join(packsDir, path.Base(r.URL.Path)+".pack.gz"))
+		}))
+		defer ts.Close()
+		manifest.BaseUrl = ts.URL
+
+		summary, err := ExtractAll(context.Background(), *manifest, t.TempDir(), ExtractAllOptions{HTTPClient: ts.Client()})
+		assert.NoError(t, err)
+		assert.Len(t, summary.Result.Written, 2)
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		manifest, packsDir := packedTree(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		summary, err := ExtractAll(ctx, *manifest, t.TempDir(), ExtractAllOptions{
+			Packs: &PackLocator{Dirs: []string{packsDir}},
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, summary.Result.Written)
+		assert.Empty(t, summary.Errors)
+	})
+}
+
+func TestExtractCancelled(t *testing.T) {
+	content := []byte("content")
+	packData := append([]byte(PackHeader), content...)
+	blobs := []Blob{{Hash: sha1Hex(content), PackHash: "pack", PackOffset: len(PackHeader), Size: len(content)}}
+	files := []File{{Name: "Engine/a.txt", Hash: sha1Hex(content)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	targetDir := t.TempDir()
+	_, err := ExtractUEPackWithOptions(ctx, packData, blobs, files, targetDir, ExtractOptions{})
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, err = os.Stat(filepath.Join(targetDir, "Engine", "a.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer ts.Close()
+
+	manifest := WorkingManifest{BaseUrl: ts.URL, Packs: []Pack{{Hash: "pack"}}, Blobs: blobs, Files: files}
+	err = DownloadAllPacks(ctx, *ts.Client(), manifest, targetDir, false)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Zero(t, requests)
+}
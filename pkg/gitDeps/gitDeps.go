@@ -2,7 +2,7 @@ package gitDeps
 
 import (
 	"bytes"
-	"compress/gzip"
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/xml"
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -61,15 +62,14 @@ var (
 )
 
 // ParseFile takes an XML file that represents Unreal Engine dependencies and returns a
-// data structure representing the file for further processing
+// data structure representing the file for further processing.
+// Decoding failures are returned as a *ParseError carrying the position of the problem.
 func ParseFile(data io.Reader) (*WorkingManifest, error) {
 	w := WorkingManifest{}
-	err := xml.NewDecoder(data).Decode(&w)
-	if err != nil {
-		if _, ok := err.(xml.UnmarshalError); ok {
-			return nil, XmlDecodeError
-		}
-		return nil, UnknownError
+	dec := xml.NewDecoder(data)
+	if err := dec.Decode(&w); err != nil {
+		line, column := dec.InputPos()
+		return nil, &ParseError{Line: line, Column: column, Err: err}
 	}
 	return &w, nil
 }
@@ -98,21 +98,30 @@ func GetFileFromManifest(filename string, w WorkingManifest) (file *File) {
 //
 //	Pack.Url = String.Format("{0}/{1}/{2}", RequiredPack.Manifest.BaseUrl, RequiredPack.Pack.RemotePath, RequiredPack.Pack.Hash);
 //	# https://github.com/kreempuff/UnrealEngine/blob/bd73ff2e35f9e0900035c8ad0080bb8fecefac24/Engine/Source/Programs/GitDependencies/Program.cs#L1033
-func DownloadPack(w io.Writer, httpClient http.Client, pack *Pack, manifest WorkingManifest) error {
-	url := fmt.Sprintf("%s/%s/%s", manifest.BaseUrl, pack.RemotePath, pack.Hash)
+func DownloadPack(ctx context.Context, w io.Writer, httpClient http.Client, pack *Pack, manifest WorkingManifest) error {
+	url := PackUrl(manifest, *pack)
 	l := logrus.WithFields(logrus.Fields{
 		"packUrl": url,
 	})
 
-	res, err := httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return err
 	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return &HTTPError{URL: url, PackHash: pack.Hash, Err: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return &HTTPError{URL: url, PackHash: pack.Hash, StatusCode: res.StatusCode}
+	}
 
 	l.Debugf("downloading pack")
 	_, err = io.Copy(w, res.Body)
 	if err != nil {
-		return err
+		return &HTTPError{URL: url, PackHash: pack.Hash, StatusCode: res.StatusCode, Err: err}
 	}
 	l.Debug("downloaded pack")
 	return nil
@@ -144,6 +153,15 @@ func GetPackfromFileName(filename string, w WorkingManifest) *Pack {
 	return pack
 }
 
+// PackUrl returns the CDN url of a pack: <BaseUrl>/<RemotePath>/<Hash>
+func PackUrl(w WorkingManifest, p Pack) string {
+	baseUrl := w.BaseUrl
+	if p.BaseUrl != "" {
+		baseUrl = p.BaseUrl
+	}
+	return fmt.Sprintf("%s/%s/%s", baseUrl, p.RemotePath, p.Hash)
+}
+
 // GetPackUrls returns a list of urls for all the packs in a manifest file
 func GetPackUrls(w WorkingManifest) []string {
 	return GetPackUrlsWithPrefix(w, "")
@@ -158,7 +176,7 @@ func GetPackUrlsWithPrefixes(w WorkingManifest, prefixes []string) []string {
 	if len(prefixes) == 0 || (len(prefixes) == 1 && prefixes[0] == "") {
 		var urls []string
 		for _, p := range w.Packs {
-			urls = append(urls, fmt.Sprintf("%s/%s/%s", w.BaseUrl, p.RemotePath, p.Hash))
+			urls = append(urls, PackUrl(w, p))
 		}
 		return urls
 	}
@@ -185,7 +203,7 @@ func GetPackUrlsWithPrefixes(w WorkingManifest, prefixes []string) []string {
 	var urls []string
 	for _, p := range w.Packs {
 		if neededPacks[p.Hash] {
-			urls = append(urls, fmt.Sprintf("%s/%s/%s", w.BaseUrl, p.RemotePath, p.Hash))
+			urls = append(urls, PackUrl(w, p))
 		}
 	}
 	return urls
@@ -237,11 +255,26 @@ func GetManifestFromInput(input string) (*WorkingManifest, error) {
 // ExtractUEPack extracts files from an Unreal Engine pack (gzip-compressed binary container)
 // Epic's format: Each pack is gzip-compressed and contains multiple files concatenated together.
 // The manifest specifies PackOffset and Size to extract individual files.
-func ExtractUEPack(packData []byte, blobs []Blob, files []File, targetDir string, manifest WorkingManifest) error {
+func ExtractUEPack(ctx context.Context, packData []byte, blobs []Blob, files []File, targetDir string) error {
+	_, err := ExtractUEPackWithOptions(ctx, packData, blobs, files, targetDir, ExtractOptions{})
+	return err
+}
+
+// ExtractUEPackWithOptions extracts files from an Unreal Engine pack like ExtractUEPack.
+// Files whose content already matches the manifest are left untouched. Files that were
+// modified locally are handled according to opts.ModifiedPolicy and listed in the result.
+// Every file is written atomically, and cancelling ctx stops extraction between files.
+func ExtractUEPackWithOptions(ctx context.Context, packData []byte, blobs []Blob, files []File, targetDir string, opts ExtractOptions) (*ExtractResult, error) {
 	l := logrus.WithField("targetDir", targetDir)
 	l.Debugf("extracting %d files from pack", len(files))
 
+	result := &ExtractResult{}
+
 	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
 		// Find the blob for this file
 		var blob *Blob
 		for i := range blobs {
@@ -258,28 +291,32 @@ func ExtractUEPack(packData []byte, blobs []Blob, files []File, targetDir string
 
 		// Extract file data from pack using offset and size
 		if blob.PackOffset+blob.Size > len(packData) {
-			return fmt.Errorf("blob %s extends beyond pack data (offset=%d, size=%d, packlen=%d)",
-				blob.Hash, blob.PackOffset, blob.Size, len(packData))
+			return result, &IntegrityError{
+				Name:     fmt.Sprintf("blob %s in pack %s (offset=%d, size=%d)", blob.Hash, blob.PackHash, blob.PackOffset, blob.Size),
+				Expected: fmt.Sprintf("at least %d bytes of pack data", blob.PackOffset+blob.Size),
+				Actual:   fmt.Sprintf("%d bytes", len(packData)),
+			}
 		}
 
 		fileData := packData[blob.PackOffset : blob.PackOffset+blob.Size]
 
+		// Verify file hash if specified, before anything is written
+		if file.ExpectedHash != "" {
+			valid, actualHash := VerifyHash(fileData, file.ExpectedHash)
+			if !valid {
+				if opts.VerifyHashes {
+					return result, &IntegrityError{Name: file.Name, Expected: file.ExpectedHash, Actual: actualHash}
+				}
+				l.Warnf("file %s hash mismatch: expected %s, got %s", file.Name, file.ExpectedHash, actualHash)
+			}
+		}
+
 		// Construct target path
 		targetPath := filepath.Join(targetDir, file.Name)
 
 		// Prevent path traversal attacks using filepath.Rel
-		cleanTargetDir := filepath.Clean(targetDir)
-		cleanTargetPath := filepath.Clean(targetPath)
-		relPath, err := filepath.Rel(cleanTargetDir, cleanTargetPath)
-		if err != nil || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) || strings.HasPrefix(relPath, "..") && len(relPath) == 2 {
-			return fmt.Errorf("illegal file path (path traversal detected): %s", file.Name)
-		}
-
-		l.Debugf("extracting: %s (%d bytes)", file.Name, blob.Size)
-
-		// Create parent directories
-		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", file.Name, err)
+		if err := checkTargetPath(targetDir, file.Name); err != nil {
+			return result, err
 		}
 
 		// Write file with appropriate permissions
@@ -287,21 +324,64 @@ func ExtractUEPack(packData []byte, blobs []Blob, files []File, targetDir string
 		if file.IsExecutable {
 			fileMode = 0755
 		}
-		if err := os.WriteFile(targetPath, fileData, fileMode); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", file.Name, err)
-		}
 
-		// Verify file hash if specified
-		if file.ExpectedHash != "" {
-			valid, actualHash := VerifyHash(fileData, file.ExpectedHash)
-			if !valid {
-				l.Warnf("file %s hash mismatch: expected %s, got %s", file.Name, file.ExpectedHash, actualHash)
+		// Check what is currently on disk before replacing it
+		existing, err := os.ReadFile(targetPath)
+		if err == nil {
+			existingHash := sha1Hex(existing)
+			if existingHash == file.Hash {
+				l.Debugf("unchanged: %s", file.Name)
+				if err := os.Chmod(targetPath, fileMode); err != nil {
+					return result, fmt.Errorf("failed to set mode of %s: %w", file.Name, err)
+				}
+				result.Unchanged = append(result.Unchanged, file.Name)
+				continue
 			}
+
+			previous, tracked := opts.PreviousFiles[file.Name]
+			if !tracked || previous.Hash != existingHash {
+				modified := ModifiedFile{Name: file.Name, Action: opts.ModifiedPolicy}
+				switch opts.ModifiedPolicy {
+				case ModifiedFileSkip:
+					l.Warnf("skipping locally modified file: %s", file.Name)
+					result.Modified = append(result.Modified, modified)
+					continue
+				case ModifiedFileFail:
+					result.Modified = append(result.Modified, modified)
+					return result, fmt.Errorf("%w: %s", LocallyModifiedError, file.Name)
+				case ModifiedFileBackup:
+					backup, err := backupFile(targetPath)
+					if err != nil {
+						return result, fmt.Errorf("failed to back up %s: %w", file.Name, err)
+					}
+					l.Warnf("backed up locally modified file %s to %s", file.Name, backup)
+					modified.BackupPath = backup
+				default:
+					modified.Action = ModifiedFileOverwrite
+					l.Warnf("overwriting locally modified file: %s", file.Name)
+				}
+				result.Modified = append(result.Modified, modified)
+			}
+		}
+
+		l.Debugf("extracting: %s (%d bytes)", file.Name, blob.Size)
+
+		// Create parent directories
+		writeStart := time.Now()
+		if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+			return result, fmt.Errorf("failed to create directory for %s: %w", file.Name, err)
 		}
+
+		if err := WriteFileAtomic(targetPath, fileData, fileMode); err != nil {
+			return result, fmt.Errorf("failed to write file %s: %w", file.Name, err)
+		}
+		opts.Stats.AddPhase(PhaseWrite, time.Since(writeStart), int64(len(fileData)))
+		result.Written = append(result.Written, file.Name)
+		opts.Progress.AddExtracted(int64(len(fileData)))
 	}
 
-	l.Debugf("extracted %d files successfully", len(files))
-	return nil
+	l.Debugf("extracted %d files successfully", len(result.Written))
+	return result, nil
 }
 
 // VerifyHash computes the SHA1 hash of data and compares it with the expected hash
@@ -312,38 +392,23 @@ func VerifyHash(data []byte, expectedHash string) (bool, string) {
 	return actualHash == expectedHash, actualHash
 }
 
-// DownloadAndExtractPack downloads a pack, decompresses it, and extracts files to the target directory
-func DownloadAndExtractPack(httpClient http.Client, pack *Pack, manifest WorkingManifest, targetDir string, verifyChecksum bool) error {
-	url := fmt.Sprintf("%s/%s/%s", manifest.BaseUrl, pack.RemotePath, pack.Hash)
+// DownloadAndExtractPack downloads a pack, decompresses it, and extracts files to the target
+// directory. With verifyChecksum, a file not matching its ExpectedHash fails with an *IntegrityError.
+func DownloadAndExtractPack(ctx context.Context, httpClient http.Client, pack *Pack, manifest WorkingManifest, targetDir string, verifyChecksum bool) error {
+	_, err := DownloadAndExtractPackWithOptions(ctx, httpClient, pack, manifest, targetDir, ExtractOptions{VerifyHashes: verifyChecksum})
+	return err
+}
+
+// DownloadAndExtractPackWithOptions is DownloadAndExtractPack with control over locally modified files
+func DownloadAndExtractPackWithOptions(ctx context.Context, httpClient http.Client, pack *Pack, manifest WorkingManifest, targetDir string, opts ExtractOptions) (*ExtractResult, error) {
 	l := logrus.WithFields(logrus.Fields{
-		"packUrl":   url,
 		"targetDir": targetDir,
 		"packHash":  pack.Hash,
 	})
 
-	l.Debug("downloading pack")
-	res, err := httpClient.Get(url)
+	packData, _, err := fetchPack(ctx, httpClient, pack, manifest, opts)
 	if err != nil {
-		return fmt.Errorf("failed to download pack: %w", err)
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
-	}
-
-	// Decompress gzip stream
-	l.Debug("decompressing pack")
-	gzr, err := gzip.NewReader(res.Body)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
-	}
-	defer gzr.Close()
-
-	// Read decompressed pack data
-	packData, err := io.ReadAll(gzr)
-	if err != nil {
-		return fmt.Errorf("failed to read decompressed pack: %w", err)
+		return nil, err
 	}
 
 	// Find all blobs that belong to this pack
@@ -368,30 +433,141 @@ func DownloadAndExtractPack(httpClient http.Client, pack *Pack, manifest Working
 	l.Debugf("extracting %d files from pack", len(packFiles))
 
 	// Extract files from pack
-	if err := ExtractUEPack(packData, packBlobs, packFiles, targetDir, manifest); err != nil {
-		return fmt.Errorf("failed to extract pack: %w", err)
+	result, err := ExtractUEPackWithOptions(ctx, packData, packBlobs, packFiles, targetDir, opts)
+	if err != nil {
+		return result, fmt.Errorf("failed to extract pack: %w", err)
 	}
 
 	l.Debug("pack downloaded and extracted successfully")
-	return nil
+	return result, nil
 }
 
-// DownloadAllPacks downloads and extracts all packs from a manifest
-func DownloadAllPacks(httpClient http.Client, manifest WorkingManifest, targetDir string, verifyChecksum bool) error {
-	l := logrus.WithFields(logrus.Fields{
-		"packCount": len(manifest.Packs),
-		"targetDir": targetDir,
-	})
+// fetchPack returns the decompressed data and the compressed size of a pack from opts.CacheDir,
+// or downloads it from the pack's url and then from each of opts.Mirrors until one succeeds.
+// Packs are checked with decompressPack before they are used or cached; a cached pack that fails
+// the check is evicted and downloaded again.
+func fetchPack(ctx context.Context, httpClient http.Client, pack *Pack, manifest WorkingManifest, opts ExtractOptions) ([]byte, int, error) {
+	var cachePath string
+	if opts.CacheDir != "" {
+		cachePath = filepath.Join(opts.CacheDir, pack.Hash+".pack.gz")
+		readStart := time.Now()
+		if compressed, err := os.ReadFile(cachePath); err == nil {
+			opts.Stats.AddPhase(PhaseRead, time.Since(readStart), int64(len(compressed)))
+			packData, err := decompressPack(pack, compressed, opts.Stats)
+			if err == nil {
+				opts.Stats.CacheHit()
+				opts.Progress.AddDownloaded(int64(len(compressed)))
+				return packData, len(compressed), nil
+			}
+			logrus.Warnf("evicting corrupt pack %s from the cache: %s", cachePath, err)
+			if err := os.Remove(cachePath); err != nil {
+				logrus.Warnf("failed to evict %s: %s", cachePath, err)
+			}
+		}
+		opts.Stats.CacheMiss()
+	}
 
-	l.Info("downloading all packs")
+	urls := []string{PackUrl(manifest, *pack)}
+	for _, mirror := range opts.Mirrors {
+		mirrored := *pack
+		mirrored.BaseUrl = mirror
+		urls = append(urls, PackUrl(manifest, mirrored))
+	}
 
-	for i, pack := range manifest.Packs {
-		l.Infof("downloading pack %d/%d", i+1, len(manifest.Packs))
-		if err := DownloadAndExtractPack(httpClient, &pack, manifest, targetDir, verifyChecksum); err != nil {
-			return fmt.Errorf("failed to download pack %s: %w", pack.Hash, err)
+	var compressed, packData []byte
+	var err error
+	for i, url := range urls {
+		compressed, err = downloadPackData(ctx, httpClient, url, pack.Hash, opts)
+		var httpErr *HTTPError
+		if err == nil {
+			// A corrupt response is retried from the next mirror like a failed request
+			if packData, err = decompressPack(pack, compressed, opts.Stats); err == nil {
+				break
+			}
+		} else if !errors.As(err, &httpErr) {
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		logrus.WithField("packUrl", url).Warnf("failed to download pack: %s", err)
+		if i < len(urls)-1 {
+			opts.Stats.Retry()
 		}
 	}
+	if err != nil {
+		return nil, 0, err
+	}
 
-	l.Info("all packs downloaded successfully")
-	return nil
+	if cachePath != "" {
+		if err := os.MkdirAll(opts.CacheDir, 0755); err != nil {
+			logrus.Warnf("failed to create pack cache: %s", err)
+		} else if err := WriteFileAtomic(cachePath, compressed, 0644); err != nil {
+			logrus.Warnf("failed to cache pack %s: %s", pack.Hash, err)
+		}
+	}
+	return packData, len(compressed), nil
+}
+
+// decompressPack inflates the compressed data of a pack and checks its size against the
+// manifest, so a truncated or corrupt pack is neither extracted nor cached
+func decompressPack(pack *Pack, compressed []byte, stats *SyncStats) ([]byte, error) {
+	packData, err := DecompressPack(compressed, stats)
+	if err != nil {
+		// Corrupt or truncated data (gzip.ErrHeader, io.ErrUnexpectedEOF, ...) is an integrity failure
+		return nil, &IntegrityError{Name: "pack " + pack.Hash, Expected: "gzip data", Actual: err.Error(), Err: err}
+	}
+	if pack.Size > 0 && len(packData) != pack.Size {
+		return nil, &IntegrityError{
+			Name:     "pack " + pack.Hash,
+			Expected: fmt.Sprintf("%d bytes", pack.Size),
+			Actual:   fmt.Sprintf("%d bytes", len(packData)),
+		}
+	}
+	return packData, nil
+}
+
+// downloadPackData downloads the compressed data of a pack from url
+func downloadPackData(ctx context.Context, httpClient http.Client, url string, hash string, opts ExtractOptions) ([]byte, error) {
+	logrus.WithField("packUrl", url).Debug("downloading pack")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &HTTPError{URL: url, PackHash: hash, Err: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &HTTPError{URL: url, PackHash: hash, StatusCode: res.StatusCode}
+	}
+
+	// Read the compressed pack, then decompress it, so network and CPU time can be told apart
+	networkStart := time.Now()
+	compressed, err := io.ReadAll(&countingReader{r: res.Body, progress: opts.Progress})
+	if err != nil {
+		return nil, &HTTPError{URL: url, PackHash: hash, StatusCode: res.StatusCode, Err: err}
+	}
+	opts.Stats.AddPhase(PhaseNetwork, time.Since(networkStart), int64(len(compressed)))
+	return compressed, nil
+}
+
+// DownloadAllPacks downloads and extracts all packs from a manifest. With verifyChecksum, files
+// not matching their ExpectedHash fail with an *IntegrityError.
+func DownloadAllPacks(ctx context.Context, httpClient http.Client, manifest WorkingManifest, targetDir string, verifyChecksum bool) error {
+	_, err := DownloadAllPacksWithOptions(ctx, httpClient, manifest, targetDir, ExtractOptions{VerifyHashes: verifyChecksum})
+	return err
+}
+
+// DownloadAllPacksWithOptions is DownloadAllPacks with control over locally modified files. It
+// is ExtractAll of every file, downloading with httpClient; see ExtractAll for how failures and
+// cancellation are reported. The returned result covers every pack processed.
+func DownloadAllPacksWithOptions(ctx context.Context, httpClient http.Client, manifest WorkingManifest, targetDir string, opts ExtractOptions) (*ExtractResult, error) {
+	summary, err := ExtractAll(ctx, manifest, targetDir, ExtractAllOptions{
+		ExtractOptions: opts,
+		HTTPClient:     &httpClient,
+	})
+	return summary.Result, err
 }
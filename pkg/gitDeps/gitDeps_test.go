@@ -3,7 +3,9 @@ package gitDeps
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"net/http"
@@ -11,6 +13,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
 )
@@ -59,7 +62,7 @@ func TestDownloadPack(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &bytes.Buffer{}
-			err := DownloadPack(w, tt.args.httpClient, tt.args.pack, tt.args.manifest)
+			err := DownloadPack(context.Background(), w, tt.args.httpClient, tt.args.pack, tt.args.manifest)
 			if !tt.wantErr(t, err, fmt.Sprintf("DownloadPack(%v, %v, %v, %v)", w, tt.args.httpClient, tt.args.pack, tt.args.manifest)) {
 				return
 			}
@@ -228,8 +231,7 @@ func TestExtractUEPack(t *testing.T) {
 			defer os.RemoveAll(targetDir)
 
 			// Extract pack
-			manifest := WorkingManifest{}
-			err = ExtractUEPack(tt.packData, tt.blobs, tt.files, targetDir, manifest)
+			err = ExtractUEPack(context.Background(), tt.packData, tt.blobs, tt.files, targetDir)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -315,7 +317,7 @@ func TestDownloadAndExtractPack(t *testing.T) {
 			assert.NoError(t, err)
 			defer os.RemoveAll(targetDir)
 
-			err = DownloadAndExtractPack(*ts.Client(), &manifest.Packs[0], manifest, targetDir, false)
+			err = DownloadAndExtractPack(context.Background(), *ts.Client(), &manifest.Packs[0], manifest, targetDir, false)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -331,6 +333,129 @@ func TestDownloadAndExtractPack(t *testing.T) {
 			assert.Equal(t, "test dependency file", string(content))
 		})
 	}
+
+	t.Run("verifies checksums when asked to", func(t *testing.T) {
+		mismatched := manifest
+		mismatched.Files = []File{{Name: "Engine/Binaries/test.bin", Hash: "test-blob-hash", ExpectedHash: "0000"}}
+
+		err := DownloadAndExtractPack(context.Background(), *ts.Client(), &mismatched.Packs[0], mismatched, t.TempDir(), true)
+		var integrityErr *IntegrityError
+		assert.True(t, errors.As(err, &integrityErr))
+
+		err = DownloadAllPacks(context.Background(), *ts.Client(), mismatched, t.TempDir(), true)
+		assert.True(t, errors.As(err, &integrityErr))
+
+		// Without verification a mismatch is only a warning
+		assert.NoError(t, DownloadAndExtractPack(context.Background(), *ts.Client(), &mismatched.Packs[0], mismatched, t.TempDir(), false))
+	})
+}
+
+func TestDownloadMirrorsAndCache(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "hello", "b.txt": "world"})
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(src, packsDir, CreatePackOptions{PackSize: 1})
+	assert.NoError(t, err)
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	var mirrorRequests atomic.Int32
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorRequests.Add(1)
+		http.ServeFile(w, r, filepath.Join(packsDir, filepath.Base(r.URL.Path)+".pack.gz"))
+	}))
+	defer mirror.Close()
+	manifest.BaseUrl = broken.URL
+
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	opts := ExtractOptions{Mirrors: []string{mirror.URL}, CacheDir: cacheDir, Stats: NewSyncStats(1)}
+	_, err = DownloadAllPacksWithOptions(context.Background(), *broken.Client(), *manifest, t.TempDir(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), mirrorRequests.Load())
+	assert.Equal(t, 2, opts.Stats.Report(0).CacheMisses)
+	// Each pack failed once on the primary URL and was retried from the mirror
+	assert.Equal(t, 2, opts.Stats.Report(0).Retries)
+	for _, pack := range manifest.Packs {
+		assert.FileExists(t, filepath.Join(cacheDir, pack.Hash+".pack.gz"))
+	}
+
+	// The second run is served from the cache
+	opts.Stats = NewSyncStats(1)
+	targetDir := t.TempDir()
+	_, err = DownloadAllPacksWithOptions(context.Background(), *broken.Client(), *manifest, targetDir, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), mirrorRequests.Load())
+	assert.Equal(t, 2, opts.Stats.Report(0).CacheHits)
+	assert.Zero(t, opts.Stats.Report(0).Retries)
+	content, err := os.ReadFile(filepath.Join(targetDir, "a.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	// Without a working mirror the download fails
+	_, err = DownloadAllPacksWithOptions(context.Background(), *broken.Client(), *manifest, t.TempDir(), ExtractOptions{})
+	var httpErr *HTTPError
+	assert.ErrorAs(t, err, &httpErr)
+}
+
+func TestDownloadCorruptPackNotCached(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "hello"})
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(src, packsDir, CreatePackOptions{})
+	assert.NoError(t, err)
+	cachePath := func(dir string) string { return filepath.Join(dir, manifest.Packs[0].Hash+".pack.gz") }
+
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Write([]byte("this is not gzip"))
+			return
+		}
+		http.ServeFile(w, r, filepath.Join(packsDir, filepath.Base(r.URL.Path)+".pack.gz"))
+	}))
+	defer ts.Close()
+	manifest.BaseUrl = ts.URL
+
+	// The corrupt first response fails the download and is not cached
+	cacheDir := t.TempDir()
+	opts := ExtractOptions{CacheDir: cacheDir}
+	_, err = DownloadAllPacksWithOptions(context.Background(), *ts.Client(), *manifest, t.TempDir(), opts)
+	assert.ErrorIs(t, err, gzip.ErrHeader)
+	assert.NoFileExists(t, cachePath(cacheDir))
+
+	// The next run downloads the pack again
+	targetDir := t.TempDir()
+	_, err = DownloadAllPacksWithOptions(context.Background(), *ts.Client(), *manifest, targetDir, opts)
+	assert.NoError(t, err)
+	assert.FileExists(t, cachePath(cacheDir))
+	assert.Equal(t, int32(2), requests.Load())
+
+	// A corrupt cached pack is evicted and downloaded again
+	assert.NoError(t, os.WriteFile(cachePath(cacheDir), []byte("this is not gzip"), 0644))
+	opts.Stats = NewSyncStats(1)
+	_, err = DownloadAllPacksWithOptions(context.Background(), *ts.Client(), *manifest, t.TempDir(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, 1, opts.Stats.Report(0).CacheMisses)
+	cached, err := os.ReadFile(cachePath(cacheDir))
+	assert.NoError(t, err)
+	packData, err := DecompressPack(cached, nil)
+	assert.NoError(t, err)
+	assert.Len(t, packData, manifest.Packs[0].Size)
+
+	// A corrupt response is retried from the mirrors
+	requests.Store(0)
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(packsDir, filepath.Base(r.URL.Path)+".pack.gz"))
+	}))
+	defer mirror.Close()
+	opts = ExtractOptions{Mirrors: []string{mirror.URL}, CacheDir: t.TempDir(), Stats: NewSyncStats(1)}
+	_, err = DownloadAllPacksWithOptions(context.Background(), *ts.Client(), *manifest, t.TempDir(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, opts.Stats.Report(0).Retries)
+	assert.FileExists(t, cachePath(opts.CacheDir))
 }
 
 func TestVerifyHash(t *testing.T) {
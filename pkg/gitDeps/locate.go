@@ -0,0 +1,132 @@
+package gitDeps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PackLockfileVersion is the current version of the pack lockfile format
+const PackLockfileVersion = 1
+
+// PackLockfile maps pack hashes to the SHA256 of the compressed pack as served by the CDN.
+// Pack hashes are SHA1 of the uncompressed data, so they can't be used to look packs up in
+// content addressable stores such as the Bazel repository cache; the lockfile bridges the two.
+type PackLockfile struct {
+	Version int               `json:"version"`
+	Packs   map[string]string `json:"packs"` // Pack hash -> sha256 of the .pack.gz content
+}
+
+// ReadPackLockfile reads a lockfile written by WritePackLockfile
+func ReadPackLockfile(path string) (*PackLockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lock := &PackLockfile{}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("invalid pack lockfile %s: %w", path, err)
+	}
+	if lock.Version != PackLockfileVersion {
+		return nil, fmt.Errorf("unsupported pack lockfile version %d in %s (expected %d)", lock.Version, path, PackLockfileVersion)
+	}
+	return lock, nil
+}
+
+// WritePackLockfile writes lock as indented JSON to path
+func WritePackLockfile(path string, lock *PackLockfile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, append(data, '\n'), 0644)
+}
+
+// PackLocator finds the compressed data of a pack in one or more local directories.
+// For every directory, these layouts are tried in order:
+//
+//	<dir>/<hash>.pack.gz        written by `extract`'s Bazel flow and `gitDeps pack`
+//	<dir>/<hash>                raw CDN downloads
+//	<dir>/<RemotePath>/<hash>   a mirror of the CDN tree
+//
+// If RepositoryCache and Lockfile are set, the Bazel repository cache
+// (<RepositoryCache>/content_addressable/sha256/<sha256>/file) is searched last.
+type PackLocator struct {
+	Dirs            []string
+	RepositoryCache string
+	Lockfile        *PackLockfile
+}
+
+// Candidates returns every path Find looks at for pack, in order
+func (l *PackLocator) Candidates(pack Pack) []string {
+	var paths []string
+	for _, dir := range l.Dirs {
+		paths = append(paths,
+			filepath.Join(dir, pack.Hash+".pack.gz"),
+			filepath.Join(dir, pack.Hash),
+		)
+		if pack.RemotePath != "" {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(pack.RemotePath), pack.Hash))
+		}
+	}
+	if l.RepositoryCache != "" && l.Lockfile != nil {
+		if sum, ok := l.Lockfile.Packs[pack.Hash]; ok {
+			paths = append(paths, filepath.Join(l.RepositoryCache, "content_addressable", "sha256", sum, "file"))
+		}
+	}
+	return paths
+}
+
+// Find returns the path of the first candidate that exists. The error wraps os.ErrNotExist
+// if the pack is not found anywhere.
+func (l *PackLocator) Find(pack Pack) (string, error) {
+	for _, path := range l.Candidates(pack) {
+		info, err := os.Stat(path)
+		if err == nil && !info.IsDir() {
+			return path, nil
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("pack %s: %w", pack.Hash, os.ErrNotExist)
+}
+
+// NewPackLockfile computes the sha256 of every pack of manifest that locator can find.
+// Packs that are not found are left out.
+func NewPackLockfile(manifest WorkingManifest, locator *PackLocator) (*PackLockfile, error) {
+	lock := &PackLockfile{Version: PackLockfileVersion, Packs: map[string]string{}}
+	for _, pack := range manifest.Packs {
+		path, err := locator.Find(pack)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sum, err := sha256File(path)
+		if err != nil {
+			return nil, err
+		}
+		lock.Packs[pack.Hash] = sum
+	}
+	return lock, nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+package gitDeps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackLocator(t *testing.T) {
+	pack := Pack{Hash: "abc", RemotePath: "Remote/Path"}
+
+	t.Run("layouts", func(t *testing.T) {
+		tests := []struct {
+			name string
+			file string
+		}{
+			{"pack.gz", "abc.pack.gz"},
+			{"raw hash", "abc"},
+			{"cdn tree", "Remote/Path/abc"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				dir := t.TempDir()
+				writeTree(t, dir, map[string]string{tt.file: "data"})
+
+				path, err := (&PackLocator{Dirs: []string{dir}}).Find(pack)
+				assert.NoError(t, err)
+				assert.Equal(t, filepath.Join(dir, filepath.FromSlash(tt.file)), path)
+			})
+		}
+	})
+
+	t.Run("search order", func(t *testing.T) {
+		first, second := t.TempDir(), t.TempDir()
+		writeTree(t, first, map[string]string{"abc": "raw"})
+		writeTree(t, second, map[string]string{"abc.pack.gz": "gz"})
+
+		path, err := (&PackLocator{Dirs: []string{first, second}}).Find(pack)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(first, "abc"), path)
+	})
+
+	t.Run("repository cache", func(t *testing.T) {
+		cache := t.TempDir()
+		writeTree(t, cache, map[string]string{"content_addressable/sha256/0123/file": "data"})
+		lock := &PackLockfile{Version: PackLockfileVersion, Packs: map[string]string{"abc": "0123"}}
+
+		path, err := (&PackLocator{RepositoryCache: cache, Lockfile: lock}).Find(pack)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(cache, "content_addressable", "sha256", "0123", "file"), path)
+
+		// Without a lockfile entry the cache can't be searched
+		_, err = (&PackLocator{RepositoryCache: cache, Lockfile: lock}).Find(Pack{Hash: "def"})
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := (&PackLocator{Dirs: []string{t.TempDir()}}).Find(pack)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
+
+func TestPackLockfile(t *testing.T) {
+	packsDir := t.TempDir()
+	writeTree(t, packsDir, map[string]string{"abc.pack.gz": "data"})
+	manifest := WorkingManifest{Packs: []Pack{{Hash: "abc"}, {Hash: "missing"}}}
+
+	lock, err := NewPackLockfile(manifest, &PackLocator{Dirs: []string{packsDir}})
+	assert.NoError(t, err)
+	// sha256("data")
+	assert.Equal(t, map[string]string{"abc": "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"}, lock.Packs)
+
+	path := filepath.Join(t.TempDir(), "gitdeps.lock.json")
+	assert.NoError(t, WritePackLockfile(path, lock))
+	read, err := ReadPackLockfile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, lock, read)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "packs": {}}`), 0644))
+	_, err = ReadPackLockfile(path)
+	assert.ErrorContains(t, err, "unsupported pack lockfile version")
+}
@@ -0,0 +1,56 @@
+package gitDeps
+
+// MergeManifests combines several manifests, e.g. the engine's and a plugin's, into one so they
+// can be extracted or downloaded in a single run. Packs and blobs shared between manifests are
+// kept once. Every pack remembers the BaseUrl of the manifest it came from, so PackUrl keeps
+// resolving to the right CDN. A file listed by several manifests with different hashes is a
+// conflict and fails the merge with a *ManifestConflictError.
+func MergeManifests(manifests ...WorkingManifest) (*WorkingManifest, error) {
+	merged := &WorkingManifest{}
+	if len(manifests) > 0 {
+		merged.XMLName = manifests[0].XMLName
+		merged.BaseUrl = manifests[0].BaseUrl
+	}
+
+	packs := map[string]bool{}
+	blobs := map[string]bool{}
+	files := map[string]int{} // Name -> index in merged.Files
+	var conflicts []PathConflict
+
+	for _, m := range manifests {
+		for _, pack := range m.Packs {
+			if packs[pack.Hash] {
+				continue
+			}
+			packs[pack.Hash] = true
+			if pack.BaseUrl == "" {
+				pack.BaseUrl = m.BaseUrl
+			}
+			merged.Packs = append(merged.Packs, pack)
+		}
+
+		for _, blob := range m.Blobs {
+			if blobs[blob.Hash] {
+				continue
+			}
+			blobs[blob.Hash] = true
+			merged.Blobs = append(merged.Blobs, blob)
+		}
+
+		for _, file := range m.Files {
+			if i, ok := files[file.Name]; ok {
+				if merged.Files[i].Hash != file.Hash {
+					conflicts = append(conflicts, PathConflict{Name: file.Name, Reason: "listed by several manifests with different hashes"})
+				}
+				continue
+			}
+			files[file.Name] = len(merged.Files)
+			merged.Files = append(merged.Files, file)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, &ManifestConflictError{Conflicts: conflicts}
+	}
+	return merged, nil
+}
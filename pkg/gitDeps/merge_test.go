@@ -0,0 +1,58 @@
+package gitDeps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeManifests(t *testing.T) {
+	engine := WorkingManifest{
+		BaseUrl: "https://cdn.example.com/engine",
+		Files: []File{
+			{Name: "Engine/a", Hash: "h1"},
+			{Name: "Engine/shared", Hash: "h2"},
+		},
+		Blobs: []Blob{{Hash: "h1", PackHash: "p1"}, {Hash: "h2", PackHash: "p1"}},
+		Packs: []Pack{{Hash: "p1", RemotePath: "Engine"}},
+	}
+	plugin := WorkingManifest{
+		BaseUrl: "https://cdn.example.com/plugin",
+		Files: []File{
+			{Name: "Plugin/b", Hash: "h3"},
+			{Name: "Engine/shared", Hash: "h2"},
+		},
+		Blobs: []Blob{{Hash: "h2", PackHash: "p1"}, {Hash: "h3", PackHash: "p2"}},
+		Packs: []Pack{{Hash: "p1", RemotePath: "Engine"}, {Hash: "p2", RemotePath: "Plugin"}},
+	}
+
+	t.Run("dedupes and keeps base urls", func(t *testing.T) {
+		merged, err := MergeManifests(engine, plugin)
+		assert.NoError(t, err)
+		assert.Len(t, merged.Files, 3)
+		assert.Len(t, merged.Blobs, 3)
+		assert.Len(t, merged.Packs, 2)
+		assert.Equal(t, []string{
+			"https://cdn.example.com/engine/Engine/p1",
+			"https://cdn.example.com/plugin/Plugin/p2",
+		}, GetPackUrls(*merged))
+		assert.Equal(t, []string{"https://cdn.example.com/plugin/Plugin/p2"}, GetPackUrlsWithPrefix(*merged, "Plugin/"))
+	})
+
+	t.Run("single manifest is unchanged", func(t *testing.T) {
+		merged, err := MergeManifests(engine)
+		assert.NoError(t, err)
+		assert.Equal(t, engine.Files, merged.Files)
+		assert.Equal(t, GetPackUrls(engine), GetPackUrls(*merged))
+	})
+
+	t.Run("conflicting files", func(t *testing.T) {
+		conflicting := WorkingManifest{Files: []File{{Name: "Engine/a", Hash: "other"}}}
+		_, err := MergeManifests(engine, conflicting)
+
+		var conflictErr *ManifestConflictError
+		assert.True(t, errors.As(err, &conflictErr))
+		assert.Equal(t, []PathConflict{{Name: "Engine/a", Reason: "listed by several manifests with different hashes"}}, conflictErr.Conflicts)
+	})
+}
@@ -0,0 +1,125 @@
+package gitDeps
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// ModifiedFilePolicy decides what happens to a file whose on-disk content matches neither the
+// previous nor the new manifest hash, i.e. a file that was changed locally.
+type ModifiedFilePolicy string
+
+const (
+	ModifiedFileSkip      ModifiedFilePolicy = "skip"      // Leave the local file alone
+	ModifiedFileOverwrite ModifiedFilePolicy = "overwrite" // Replace the local file
+	ModifiedFileFail      ModifiedFilePolicy = "fail"      // Stop extraction with LocallyModifiedError
+	ModifiedFileBackup    ModifiedFilePolicy = "backup"    // Rename the local file to <name>.bak, then replace it
+)
+
+var LocallyModifiedError = errors.New("file was modified locally")
+
+// ParseModifiedFilePolicy validates a policy name
+func ParseModifiedFilePolicy(s string) (ModifiedFilePolicy, error) {
+	switch p := ModifiedFilePolicy(s); p {
+	case ModifiedFileSkip, ModifiedFileOverwrite, ModifiedFileFail, ModifiedFileBackup:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown modified file policy %q (valid values are skip, overwrite, fail, backup)", s)
+}
+
+// ExtractOptions configures ExtractUEPackWithOptions
+type ExtractOptions struct {
+	// Policy for locally modified files. The zero value behaves like ModifiedFileOverwrite.
+	ModifiedPolicy ModifiedFilePolicy
+
+	// VerifyHashes makes a File.ExpectedHash mismatch fail with an *IntegrityError, before the
+	// file is written, instead of logging a warning.
+	VerifyHashes bool
+
+	// PreviousFiles are the files of the manifest the target directory was last synced with,
+	// keyed by name (see FilesByName). A file on disk matching its previous hash is not
+	// considered modified. Without an entry, any content that differs from the new hash is.
+	PreviousFiles map[string]File
+
+	// Progress, if set, is updated with the number of bytes written
+	Progress *Progress
+
+	// Stats, if set, collects the time spent writing files
+	Stats *SyncStats
+
+	// Workers is the number of packs extracted in parallel where supported, 0 for the number
+	// of CPUs
+	Workers int
+
+	// Mirrors are base URLs tried in order when downloading a pack from its BaseUrl fails
+	Mirrors []string
+
+	// CacheDir, if set, is searched for <hash>.pack.gz before downloading a pack, and every
+	// downloaded pack is stored there once it decompressed to the size in the manifest
+	CacheDir string
+}
+
+// ModifiedFile is a locally modified file found during extraction
+type ModifiedFile struct {
+	Name       string             `json:"name"`
+	Action     ModifiedFilePolicy `json:"action"`
+	BackupPath string             `json:"backupPath,omitempty"`
+}
+
+// ExtractResult summarizes what an extraction did. It is safe to Merge results from several
+// goroutines into one shared result.
+type ExtractResult struct {
+	mu sync.Mutex
+
+	Written   []string       `json:"written"`
+	Unchanged []string       `json:"unchanged"`
+	Modified  []ModifiedFile `json:"modified"`
+}
+
+// Merge adds the contents of other to r
+func (r *ExtractResult) Merge(other *ExtractResult) {
+	if other == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Written = append(r.Written, other.Written...)
+	r.Unchanged = append(r.Unchanged, other.Unchanged...)
+	r.Modified = append(r.Modified, other.Modified...)
+}
+
+// Sort orders the result lists by file name so summaries are stable across runs
+func (r *ExtractResult) Sort() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sort.Strings(r.Written)
+	sort.Strings(r.Unchanged)
+	sort.Slice(r.Modified, func(i, j int) bool { return r.Modified[i].Name < r.Modified[j].Name })
+}
+
+// FilesByName indexes the files of a manifest by name, for use as ExtractOptions.PreviousFiles
+func FilesByName(manifest WorkingManifest) map[string]File {
+	files := make(map[string]File, len(manifest.Files))
+	for _, f := range manifest.Files {
+		files[f.Name] = f
+	}
+	return files
+}
+
+// backupFile renames path to the first free name of path.bak, path.bak.1, path.bak.2, ...
+func backupFile(path string) (string, error) {
+	backup := path + ".bak"
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.bak.%d", path, i)
+	}
+	if err := os.Rename(path, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
@@ -0,0 +1,146 @@
+package gitDeps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractUEPackWithOptions(t *testing.T) {
+	content := []byte("new content")
+	packData := append([]byte(PackHeader), content...)
+	blobs := []Blob{{Hash: sha1Hex(content), PackOffset: len(PackHeader), Size: len(content)}}
+	files := []File{{Name: "Engine/Binaries/lib.so", Hash: sha1Hex(content)}}
+
+	previous := []byte("old content")
+	previousFiles := map[string]File{"Engine/Binaries/lib.so": {Name: "Engine/Binaries/lib.so", Hash: sha1Hex(previous)}}
+
+	tests := []struct {
+		name          string
+		onDisk        string // empty means the file does not exist
+		opts          ExtractOptions
+		wantErr       error
+		wantContent   string
+		wantWritten   int
+		wantUnchanged int
+		wantModified  []ModifiedFilePolicy
+		wantBackup    string
+	}{
+		{
+			name:        "creates missing file",
+			opts:        ExtractOptions{ModifiedPolicy: ModifiedFileSkip},
+			wantContent: "new content",
+			wantWritten: 1,
+		},
+		{
+			name:          "leaves up-to-date file alone",
+			onDisk:        "new content",
+			opts:          ExtractOptions{ModifiedPolicy: ModifiedFileFail},
+			wantContent:   "new content",
+			wantUnchanged: 1,
+		},
+		{
+			name:        "updates file matching the previous manifest",
+			onDisk:      "old content",
+			opts:        ExtractOptions{ModifiedPolicy: ModifiedFileFail, PreviousFiles: previousFiles},
+			wantContent: "new content",
+			wantWritten: 1,
+		},
+		{
+			name:         "skips modified file",
+			onDisk:       "local patch",
+			opts:         ExtractOptions{ModifiedPolicy: ModifiedFileSkip, PreviousFiles: previousFiles},
+			wantContent:  "local patch",
+			wantModified: []ModifiedFilePolicy{ModifiedFileSkip},
+		},
+		{
+			name:         "fails on modified file",
+			onDisk:       "local patch",
+			opts:         ExtractOptions{ModifiedPolicy: ModifiedFileFail, PreviousFiles: previousFiles},
+			wantErr:      LocallyModifiedError,
+			wantContent:  "local patch",
+			wantModified: []ModifiedFilePolicy{ModifiedFileFail},
+		},
+		{
+			name:         "backs up modified file",
+			onDisk:       "local patch",
+			opts:         ExtractOptions{ModifiedPolicy: ModifiedFileBackup},
+			wantContent:  "new content",
+			wantWritten:  1,
+			wantModified: []ModifiedFilePolicy{ModifiedFileBackup},
+			wantBackup:   "local patch",
+		},
+		{
+			name:         "zero value overwrites modified file",
+			onDisk:       "local patch",
+			opts:         ExtractOptions{},
+			wantContent:  "new content",
+			wantWritten:  1,
+			wantModified: []ModifiedFilePolicy{ModifiedFileOverwrite},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetDir := t.TempDir()
+			target := filepath.Join(targetDir, "Engine", "Binaries", "lib.so")
+			if tt.onDisk != "" {
+				writeTree(t, targetDir, map[string]string{"Engine/Binaries/lib.so": tt.onDisk})
+			}
+
+			result, err := ExtractUEPackWithOptions(context.Background(), packData, blobs, files, targetDir, tt.opts)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			got, err := os.ReadFile(target)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(got))
+
+			assert.Len(t, result.Written, tt.wantWritten)
+			assert.Len(t, result.Unchanged, tt.wantUnchanged)
+			var actions []ModifiedFilePolicy
+			for _, m := range result.Modified {
+				actions = append(actions, m.Action)
+			}
+			assert.Equal(t, tt.wantModified, actions)
+
+			if tt.wantBackup != "" {
+				assert.Equal(t, target+".bak", result.Modified[0].BackupPath)
+				backup, err := os.ReadFile(result.Modified[0].BackupPath)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantBackup, string(backup))
+			}
+		})
+	}
+}
+
+func TestBackupFileDoesNotClobber(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	writeTree(t, dir, map[string]string{"file": "second", "file.bak": "first"})
+
+	backup, err := backupFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, path+".bak.1", backup)
+
+	first, _ := os.ReadFile(path + ".bak")
+	assert.Equal(t, "first", string(first))
+	second, _ := os.ReadFile(backup)
+	assert.Equal(t, "second", string(second))
+}
+
+func TestParseModifiedFilePolicy(t *testing.T) {
+	p, err := ParseModifiedFilePolicy("backup")
+	assert.NoError(t, err)
+	assert.Equal(t, ModifiedFileBackup, p)
+
+	_, err = ParseModifiedFilePolicy("prompt")
+	assert.Error(t, err)
+}
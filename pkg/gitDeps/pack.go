@@ -0,0 +1,255 @@
+package gitDeps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PackHeader is written at the start of every pack. Blob offsets in Epic's
+	// manifests start at 8, and the extraction code makes the same assumption.
+	PackHeader = "UEPACK00"
+
+	// DefaultPackSize is the target uncompressed size of a pack created by CreatePacks
+	DefaultPackSize = 1024 * 1024
+
+	// ticksAtUnixEpoch is the number of .NET ticks (100ns intervals since 0001-01-01)
+	// at 1970-01-01. Epic's manifests store File.Timestamp as DateTime.Ticks.
+	ticksAtUnixEpoch = 621355968000000000
+)
+
+// CreatePackOptions configures how CreatePacks builds packs and the resulting manifest
+type CreatePackOptions struct {
+	BaseUrl    string   // BaseUrl written to the manifest
+	RemotePath string   // RemotePath of every created pack
+	Prefixes   []string // Only pack files with these path prefixes (empty means all files)
+	Excludes   []string // Skip files with these path prefixes
+	PackSize   int      // Target uncompressed pack size in bytes (default: DefaultPackSize)
+}
+
+// TicksFromTime converts a time to .NET DateTime ticks, the unit used by File.Timestamp
+func TicksFromTime(t time.Time) int {
+	return int(t.UTC().UnixNano()/100 + ticksAtUnixEpoch)
+}
+
+// TimeFromTicks converts a .NET DateTime tick count from File.Timestamp back to a time
+func TimeFromTicks(ticks int) time.Time {
+	return time.Unix(0, int64(ticks-ticksAtUnixEpoch)*100).UTC()
+}
+
+// CreatePacks is the inverse of ExtractUEPack. It walks srcDir, groups the selected files into
+// gzip-compressed packs of roughly opts.PackSize bytes, writes each pack to packsDir as
+// <hash>.pack.gz and returns a manifest describing the files, blobs and packs.
+//
+// Files with identical content share a single blob. A packsDir inside srcDir is not packed.
+// The returned manifest can be written with WriteManifest and read back with ParseFile.
+func CreatePacks(srcDir string, packsDir string, opts CreatePackOptions) (*WorkingManifest, error) {
+	if opts.PackSize <= 0 {
+		opts.PackSize = DefaultPackSize
+	}
+
+	l := logrus.WithFields(logrus.Fields{
+		"srcDir":   srcDir,
+		"packsDir": packsDir,
+	})
+
+	if err := os.MkdirAll(packsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create packs directory: %w", err)
+	}
+	absPacksDir, err := filepath.Abs(packsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &WorkingManifest{BaseUrl: opts.BaseUrl}
+	blobs := make(map[string]bool)
+
+	var packBuf bytes.Buffer
+	var packBlobs []Blob
+
+	flush := func() error {
+		if len(packBlobs) == 0 {
+			return nil
+		}
+		pack, err := writePack(packBuf.Bytes(), packBlobs, packsDir, opts.RemotePath)
+		if err != nil {
+			return err
+		}
+		for i := range packBlobs {
+			packBlobs[i].PackHash = pack.Hash
+		}
+		manifest.Blobs = append(manifest.Blobs, packBlobs...)
+		manifest.Packs = append(manifest.Packs, *pack)
+		l.Debugf("created pack %s with %d blobs", pack.Hash, len(packBlobs))
+
+		packBuf.Reset()
+		packBlobs = nil
+		return nil
+	}
+
+	err = filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Packs written so far must not end up in later packs
+			if abs, err := filepath.Abs(path); err == nil && abs == absPacksDir {
+				l.Debugf("skipping packs directory: %s", path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !matchesPrefixes(name, opts.Prefixes) || hasAnyPrefix(name, opts.Excludes) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			l.Debugf("skipping non-regular file: %s", name)
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		hash := sha1Hex(data)
+
+		manifest.Files = append(manifest.Files, File{
+			Name:         name,
+			Hash:         hash,
+			ExpectedHash: hash,
+			Timestamp:    TicksFromTime(info.ModTime()),
+			IsExecutable: info.Mode()&0111 != 0,
+		})
+
+		// Identical content is stored once
+		if blobs[hash] {
+			return nil
+		}
+		blobs[hash] = true
+
+		if packBuf.Len() == 0 {
+			packBuf.WriteString(PackHeader)
+		}
+		packBlobs = append(packBlobs, Blob{
+			Hash:       hash,
+			Size:       len(data),
+			PackOffset: packBuf.Len(),
+		})
+		packBuf.Write(data)
+
+		if packBuf.Len() >= opts.PackSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	l.Infof("packed %d files into %d blobs and %d packs", len(manifest.Files), len(manifest.Blobs), len(manifest.Packs))
+	return manifest, nil
+}
+
+// writePack compresses the pack data and writes it to packsDir/<hash>.pack.gz.
+// The pack hash is the SHA1 of the uncompressed pack data.
+func writePack(data []byte, blobs []Blob, packsDir string, remotePath string) (*Pack, error) {
+	var compressed bytes.Buffer
+	gzw := gzip.NewWriter(&compressed)
+	if _, err := gzw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress pack: %w", err)
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress pack: %w", err)
+	}
+
+	pack := &Pack{
+		Hash:           sha1Hex(data),
+		Size:           len(data),
+		CompressedSize: compressed.Len(),
+		RemotePath:     remotePath,
+	}
+
+	packFile := filepath.Join(packsDir, fmt.Sprintf("%s.pack.gz", pack.Hash))
+	if err := WriteFileAtomic(packFile, compressed.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write pack %s: %w", packFile, err)
+	}
+
+	return pack, nil
+}
+
+// manifestElement is the root element of Epic's dependency manifests
+const manifestElement = "DependencyManifest"
+
+// WriteManifest writes a manifest as .gitdeps.xml in the schema read by ParseFile, with Epic's
+// DependencyManifest root element
+func WriteManifest(w io.Writer, manifest WorkingManifest) error {
+	manifest.XMLName = xml.Name{Local: manifestElement}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteManifestFile atomically writes a manifest to path
+func WriteManifestFile(path string, manifest WorkingManifest) error {
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, manifest); err != nil {
+		return err
+	}
+	return WriteFileAtomic(path, buf.Bytes(), 0644)
+}
+
+// matchesPrefixes reports whether name matches one of the prefixes. An empty list matches everything.
+func matchesPrefixes(name string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	return hasAnyPrefix(name, prefixes)
+}
+
+func hasAnyPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sha1Hex(data []byte) string {
+	h := sha1.Sum(data)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,169 @@
+package gitDeps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree creates the given files (relative path -> content) under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// extractAll extracts every pack of a manifest from packsDir into targetDir
+func extractAll(t *testing.T, manifest WorkingManifest, packsDir string, targetDir string) {
+	t.Helper()
+	for _, pack := range manifest.Packs {
+		f, err := os.Open(filepath.Join(packsDir, fmt.Sprintf("%s.pack.gz", pack.Hash)))
+		assert.NoError(t, err)
+		gzr, err := gzip.NewReader(f)
+		assert.NoError(t, err)
+		packData, err := io.ReadAll(gzr)
+		assert.NoError(t, err)
+		gzr.Close()
+		f.Close()
+
+		var packBlobs []Blob
+		for _, blob := range manifest.Blobs {
+			if blob.PackHash == pack.Hash {
+				packBlobs = append(packBlobs, blob)
+			}
+		}
+		var packFiles []File
+		for _, file := range manifest.Files {
+			for _, blob := range packBlobs {
+				if file.Hash == blob.Hash {
+					packFiles = append(packFiles, file)
+					break
+				}
+			}
+		}
+		assert.NoError(t, ExtractUEPack(context.Background(), packData, packBlobs, packFiles, targetDir))
+	}
+}
+
+func TestCreatePacksRoundTrip(t *testing.T) {
+	files := map[string]string{
+		"Engine/Binaries/ThirdParty/Foo/libfoo.so": "shared object contents",
+		"Engine/Binaries/ThirdParty/Foo/foo.txt":   "readme",
+		"Engine/Source/ThirdParty/Bar/bar.h":       "#pragma once\n",
+		"Engine/Source/ThirdParty/Bar/copy.h":      "#pragma once\n",
+		"Engine/Content/Big.uasset":                string(bytes.Repeat([]byte("x"), 4096)),
+	}
+
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, files)
+	assert.NoError(t, os.Chmod(filepath.Join(srcDir, "Engine/Binaries/ThirdParty/Foo/libfoo.so"), 0755))
+
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(srcDir, packsDir, CreatePackOptions{
+		BaseUrl:    "https://example.com/deps",
+		RemotePath: "Internal-1",
+		PackSize:   1024,
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, manifest.Files, len(files))
+	// The two identical headers share a blob
+	assert.Len(t, manifest.Blobs, len(files)-1)
+	// The 4KB asset overflows the target size so there is more than one pack
+	assert.Greater(t, len(manifest.Packs), 1)
+
+	for _, blob := range manifest.Blobs {
+		assert.GreaterOrEqual(t, blob.PackOffset, len(PackHeader))
+	}
+	for _, pack := range manifest.Packs {
+		assert.Equal(t, "Internal-1", pack.RemotePath)
+		info, err := os.Stat(filepath.Join(packsDir, fmt.Sprintf("%s.pack.gz", pack.Hash)))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(pack.CompressedSize), info.Size())
+	}
+
+	// Write and parse the manifest to make sure it survives serialization
+	var buf bytes.Buffer
+	assert.NoError(t, WriteManifest(&buf, *manifest))
+	assert.Contains(t, buf.String(), `<DependencyManifest BaseUrl="https://example.com/deps">`)
+	parsed, err := ParseFile(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/deps", parsed.BaseUrl)
+	assert.Len(t, parsed.Files, len(manifest.Files))
+	assert.Len(t, parsed.Blobs, len(manifest.Blobs))
+	assert.Len(t, parsed.Packs, len(manifest.Packs))
+
+	targetDir := t.TempDir()
+	extractAll(t, *parsed, packsDir, targetDir)
+
+	for name, content := range files {
+		got, err := os.ReadFile(filepath.Join(targetDir, filepath.FromSlash(name)))
+		assert.NoError(t, err, name)
+		assert.Equal(t, content, string(got), name)
+	}
+
+	info, err := os.Stat(filepath.Join(targetDir, "Engine/Binaries/ThirdParty/Foo/libfoo.so"))
+	assert.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "executable bit should survive the round trip")
+
+	exe := GetFileFromManifest("Engine/Binaries/ThirdParty/Foo/libfoo.so", *parsed)
+	assert.True(t, exe.IsExecutable)
+	txt := GetFileFromManifest("Engine/Binaries/ThirdParty/Foo/foo.txt", *parsed)
+	assert.False(t, txt.IsExecutable)
+}
+
+func TestCreatePacksFilters(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, map[string]string{
+		"Engine/Binaries/a.dll":       "a",
+		"Engine/Binaries/Debug/a.pdb": "pdb",
+		"Engine/Source/b.h":           "b",
+	})
+
+	manifest, err := CreatePacks(srcDir, t.TempDir(), CreatePackOptions{
+		Prefixes: []string{"Engine/Binaries"},
+		Excludes: []string{"Engine/Binaries/Debug"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Files, 1)
+	assert.Equal(t, "Engine/Binaries/a.dll", manifest.Files[0].Name)
+	assert.Equal(t, sha1Hex([]byte("a")), manifest.Files[0].Hash)
+}
+
+func TestCreatePacksSkipsPacksDir(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, map[string]string{
+		"Engine/a.txt":          "a",
+		"Packs/stale.pack.gz":   "stale",
+		"Packs/Nested/file.txt": "nested",
+	})
+
+	// Packing twice must not pick up the packs of the first run either
+	for i := 0; i < 2; i++ {
+		manifest, err := CreatePacks(srcDir, filepath.Join(srcDir, "Packs"), CreatePackOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, manifest.Files, 1)
+		assert.Equal(t, "Engine/a.txt", manifest.Files[0].Name)
+	}
+}
+
+func TestTicksRoundTrip(t *testing.T) {
+	// Timestamp taken from working-manifest-test.xml
+	ts := TimeFromTicks(637988041677261645)
+	assert.Equal(t, 2022, ts.Year())
+	assert.Equal(t, 637988041677261645, TicksFromTime(ts))
+
+	now := time.Now().Truncate(100 * time.Nanosecond)
+	assert.True(t, now.Equal(TimeFromTicks(TicksFromTime(now))))
+}
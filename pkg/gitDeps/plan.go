@@ -0,0 +1,383 @@
+package gitDeps
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// FileAction describes what an extraction would do with a single file
+type FileAction string
+
+const (
+	FileActionCreate    FileAction = "create"
+	FileActionOverwrite FileAction = "overwrite"
+	FileActionUnchanged FileAction = "unchanged"
+)
+
+// PlanOptions configures PlanExtraction
+type PlanOptions struct {
+	Prefixes []string     // Only plan files with these path prefixes (empty means all files)
+	Packs    *PackLocator // If set, check that every pack to fetch can be found locally
+
+	// ModifiedPolicy is the policy for locally modified files. With ModifiedFileBackup any
+	// overwritten file may be kept as a .bak copy, so the space of its old content is not freed.
+	ModifiedPolicy ModifiedFilePolicy
+
+	// Workers is the number of packs extracted in parallel, each of which may have a temporary
+	// copy of the file it is overwriting next to it. 0 means the number of CPUs.
+	Workers int
+}
+
+// Plan describes what an extraction would do without touching the output directory
+type Plan struct {
+	OutputDir string         `json:"outputDir"`
+	Packs     []PlannedPack  `json:"packs"`
+	Files     []PlannedFile  `json:"files"`
+	Conflicts []PathConflict `json:"conflicts"`
+
+	DownloadBytes int64 `json:"downloadBytes"` // Compressed size of all packs to fetch
+	WriteBytes    int64 `json:"writeBytes"`    // Bytes written for created and overwritten files
+	DiskRequired  int64 `json:"diskRequired"`  // Additional disk space needed on the output filesystem at most
+	DiskFree      int64 `json:"diskFree"`      // Free space on the output filesystem, -1 if unknown
+}
+
+// PlannedPack is a pack that has to be fetched because it contains files to create or overwrite
+type PlannedPack struct {
+	Hash           string `json:"hash"`
+	Url            string `json:"url"`
+	Size           int    `json:"size"`
+	CompressedSize int    `json:"compressedSize"`
+	Missing        bool   `json:"missing,omitempty"` // Not found by PlanOptions.Packs
+
+	// Populated by CheckRemotePacks
+	RemoteStatus int   `json:"remoteStatus,omitempty"`
+	RemoteSize   int64 `json:"remoteSize,omitempty"`
+}
+
+// PlannedFile is a file selected by the plan
+type PlannedFile struct {
+	Name     string     `json:"name"`
+	Action   FileAction `json:"action"`
+	Size     int        `json:"size"`
+	PackHash string     `json:"packHash"`
+}
+
+// PathConflict is a file that cannot be written as-is
+type PathConflict struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Count returns the number of planned files with the given action
+func (p *Plan) Count(action FileAction) int {
+	n := 0
+	for _, f := range p.Files {
+		if f.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// HasEnoughSpace reports whether the output filesystem has room for the plan.
+// It returns true when the free space is unknown.
+func (p *Plan) HasEnoughSpace() bool {
+	return p.DiskFree < 0 || p.DiskRequired <= p.DiskFree
+}
+
+// PlanExtraction computes which packs an extraction of manifest into targetDir needs, which files
+// it would create, overwrite or leave unchanged, and whether the output filesystem has enough space.
+// The space required covers the temporary files overwritten files are written to before they
+// replace the old ones, and with ModifiedFileBackup the .bak copies of every overwritten file, so
+// it is an upper bound. It only reads the output directory and never touches the network.
+func PlanExtraction(manifest WorkingManifest, targetDir string, opts PlanOptions) (*Plan, error) {
+	plan := &Plan{OutputDir: targetDir, DiskFree: -1}
+
+	blobsByHash := make(map[string]Blob, len(manifest.Blobs))
+	for _, blob := range manifest.Blobs {
+		blobsByHash[blob.Hash] = blob
+	}
+
+	var selected []File
+	for _, file := range manifest.Files {
+		if matchesPrefixes(file.Name, opts.Prefixes) {
+			selected = append(selected, file)
+		}
+	}
+
+	plan.Conflicts = findManifestConflicts(selected)
+	conflicting := make(map[string]bool, len(plan.Conflicts))
+	for _, c := range plan.Conflicts {
+		conflicting[c.Name] = true
+	}
+
+	neededPacks := make(map[string]bool)
+	var tempSizes []int64 // Sizes of the temporary files written to overwrite files
+	for _, file := range selected {
+		if conflicting[file.Name] {
+			continue
+		}
+
+		blob, ok := blobsByHash[file.Hash]
+		if !ok {
+			plan.Conflicts = append(plan.Conflicts, PathConflict{Name: file.Name, Reason: "no blob for file hash " + file.Hash})
+			continue
+		}
+
+		action, existingSize, err := planFile(file, blob, targetDir)
+		if err != nil {
+			plan.Conflicts = append(plan.Conflicts, PathConflict{Name: file.Name, Reason: err.Error()})
+			continue
+		}
+
+		plan.Files = append(plan.Files, PlannedFile{
+			Name:     file.Name,
+			Action:   action,
+			Size:     blob.Size,
+			PackHash: blob.PackHash,
+		})
+
+		if action == FileActionUnchanged {
+			continue
+		}
+		neededPacks[blob.PackHash] = true
+		plan.WriteBytes += int64(blob.Size)
+		switch {
+		case action == FileActionOverwrite && opts.ModifiedPolicy == ModifiedFileBackup:
+			plan.DiskRequired += int64(blob.Size) // The old file may be kept as a backup
+		case action == FileActionOverwrite:
+			tempSizes = append(tempSizes, int64(blob.Size))
+			fallthrough
+		default:
+			if growth := int64(blob.Size) - existingSize; growth > 0 {
+				plan.DiskRequired += growth
+			}
+		}
+	}
+
+	// Each worker has at most one temporary file next to the file it overwrites at a time
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	sort.Slice(tempSizes, func(i, j int) bool { return tempSizes[i] > tempSizes[j] })
+	for i := 0; i < len(tempSizes) && i < workers; i++ {
+		plan.DiskRequired += tempSizes[i]
+	}
+
+	for _, pack := range manifest.Packs {
+		if !neededPacks[pack.Hash] {
+			continue
+		}
+		planned := PlannedPack{
+			Hash:           pack.Hash,
+			Url:            PackUrl(manifest, pack),
+			Size:           pack.Size,
+			CompressedSize: pack.CompressedSize,
+		}
+		if opts.Packs != nil {
+			if _, err := opts.Packs.Find(pack); err != nil {
+				planned.Missing = true
+			}
+		}
+		plan.Packs = append(plan.Packs, planned)
+		plan.DownloadBytes += int64(pack.CompressedSize)
+	}
+
+	if free, err := diskFree(existingParent(targetDir)); err == nil {
+		plan.DiskFree = free
+	}
+
+	return plan, nil
+}
+
+// planFile decides what extracting a single file would do. It returns the size of the existing file
+// (0 if it does not exist) or an error describing why the file cannot be written.
+func planFile(file File, blob Blob, targetDir string) (FileAction, int64, error) {
+	if err := checkTargetPath(targetDir, file.Name); err != nil {
+		return "", 0, err
+	}
+
+	targetPath := filepath.Join(targetDir, file.Name)
+	info, err := os.Lstat(targetPath)
+	if os.IsNotExist(err) {
+		return FileActionCreate, 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot stat target: %w", err)
+	}
+	if info.IsDir() {
+		return "", 0, fmt.Errorf("a directory exists at the target path")
+	}
+
+	if info.Size() != int64(blob.Size) {
+		return FileActionOverwrite, info.Size(), nil
+	}
+	data, err := os.ReadFile(targetPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot read existing file: %w", err)
+	}
+	if sha1Hex(data) == file.Hash {
+		return FileActionUnchanged, info.Size(), nil
+	}
+	return FileActionOverwrite, info.Size(), nil
+}
+
+// checkTargetPath returns an error if name would be written outside targetDir
+func checkTargetPath(targetDir string, name string) error {
+	cleanTargetDir := filepath.Clean(targetDir)
+	cleanTargetPath := filepath.Clean(filepath.Join(targetDir, name))
+	relPath, err := filepath.Rel(cleanTargetDir, cleanTargetPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(os.PathSeparator)) {
+		return &PathSafetyError{Name: name, Reason: "path traversal detected"}
+	}
+	return nil
+}
+
+// findManifestConflicts reports files that cannot coexist: duplicate names with different content,
+// names that are also used as a directory by another file, and names that only differ in case
+// (which collide on case-insensitive filesystems such as the macOS default).
+func findManifestConflicts(files []File) []PathConflict {
+	var conflicts []PathConflict
+
+	byName := make(map[string]File, len(files))
+	byFolded := make(map[string]string, len(files))
+	for _, file := range files {
+		if existing, ok := byName[file.Name]; ok {
+			if existing.Hash != file.Hash {
+				conflicts = append(conflicts, PathConflict{Name: file.Name, Reason: "listed more than once with different hashes"})
+			}
+			continue
+		}
+		byName[file.Name] = file
+
+		folded := strings.ToLower(file.Name)
+		if other, ok := byFolded[folded]; ok {
+			conflicts = append(conflicts, PathConflict{Name: file.Name, Reason: "differs only in case from " + other})
+			continue
+		}
+		byFolded[folded] = file.Name
+	}
+
+	for name := range byName {
+		for dir := filepath.ToSlash(filepath.Dir(name)); dir != "." && dir != "/"; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			if _, ok := byName[dir]; ok {
+				conflicts = append(conflicts, PathConflict{Name: dir, Reason: "is a file but is also the parent directory of " + name})
+				break
+			}
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Name < conflicts[j].Name })
+	return conflicts
+}
+
+// existingParent returns the closest existing ancestor of path (or path itself)
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// CheckRemotePacks sends a HEAD request for every pack in the plan and records the response
+// status and size. This is the only part of planning that touches the network.
+func CheckRemotePacks(ctx context.Context, httpClient http.Client, plan *Plan) error {
+	for i := range plan.Packs {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, plan.Packs[i].Url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := httpClient.Do(req)
+		if err != nil {
+			return &HTTPError{URL: plan.Packs[i].Url, PackHash: plan.Packs[i].Hash, Err: err}
+		}
+		res.Body.Close()
+		plan.Packs[i].RemoteStatus = res.StatusCode
+		plan.Packs[i].RemoteSize = res.ContentLength
+	}
+	return nil
+}
+
+// WriteJSON writes the plan as indented JSON
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// WriteText writes a human readable summary of the plan
+func (p *Plan) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Packs to fetch: %d (%s compressed)\n", len(p.Packs), formatBytes(p.DownloadBytes))
+	for _, pack := range p.Packs {
+		note := ""
+		if pack.Missing {
+			note = "missing from packs dir"
+		}
+		if pack.RemoteStatus != 0 {
+			note = strings.TrimSpace(fmt.Sprintf("%s HTTP %d", note, pack.RemoteStatus))
+		}
+		if note != "" {
+			note = "\t" + note
+		}
+		fmt.Fprintf(tw, "  %s\t%s%s\n", pack.Hash, formatBytes(int64(pack.CompressedSize)), note)
+	}
+
+	fmt.Fprintf(tw, "\nFiles: %d to create, %d to overwrite, %d unchanged\n",
+		p.Count(FileActionCreate), p.Count(FileActionOverwrite), p.Count(FileActionUnchanged))
+	for _, file := range p.Files {
+		if file.Action == FileActionUnchanged {
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", file.Action, file.Name, formatBytes(int64(file.Size)))
+	}
+
+	if len(p.Conflicts) > 0 {
+		fmt.Fprintf(tw, "\nConflicts: %d\n", len(p.Conflicts))
+		for _, c := range p.Conflicts {
+			fmt.Fprintf(tw, "  %s\t%s\n", c.Name, c.Reason)
+		}
+	}
+
+	free := "unknown"
+	if p.DiskFree >= 0 {
+		free = formatBytes(p.DiskFree)
+	}
+	fmt.Fprintf(tw, "\nDisk: %s to write, %s required, %s free on %s\n", formatBytes(p.WriteBytes), formatBytes(p.DiskRequired), free, p.OutputDir)
+	if !p.HasEnoughSpace() {
+		fmt.Fprintln(tw, "WARNING: not enough free disk space")
+	}
+
+	return tw.Flush()
+}
+
+// formatBytes formats a byte count using binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
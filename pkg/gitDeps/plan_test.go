@@ -0,0 +1,156 @@
+package gitDeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanExtraction(t *testing.T) {
+	srcDir := t.TempDir()
+	writeTree(t, srcDir, map[string]string{
+		"Engine/Binaries/new.dll":     "new",
+		"Engine/Binaries/changed.dll": "changed",
+		"Engine/Binaries/same.dll":    "same",
+		"Engine/Source/skipped.h":     "skipped",
+	})
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(srcDir, packsDir, CreatePackOptions{BaseUrl: "https://cdn", RemotePath: "Path", PackSize: 1})
+	assert.NoError(t, err)
+
+	targetDir := t.TempDir()
+	writeTree(t, targetDir, map[string]string{
+		"Engine/Binaries/changed.dll": "locally different",
+		"Engine/Binaries/same.dll":    "same",
+	})
+
+	plan, err := PlanExtraction(*manifest, targetDir, PlanOptions{
+		Prefixes: []string{"Engine/Binaries"},
+		Packs:    &PackLocator{Dirs: []string{packsDir}},
+	})
+	assert.NoError(t, err)
+
+	actions := make(map[string]FileAction)
+	for _, f := range plan.Files {
+		actions[f.Name] = f.Action
+	}
+	assert.Equal(t, map[string]FileAction{
+		"Engine/Binaries/new.dll":     FileActionCreate,
+		"Engine/Binaries/changed.dll": FileActionOverwrite,
+		"Engine/Binaries/same.dll":    FileActionUnchanged,
+	}, actions)
+
+	// One pack per file because of PackSize=1; the unchanged file's pack is not needed
+	assert.Len(t, plan.Packs, 2)
+	for _, p := range plan.Packs {
+		assert.False(t, p.Missing)
+		assert.Equal(t, "https://cdn/Path/"+p.Hash, p.Url)
+	}
+	assert.Equal(t, int64(len("new")+len("changed")), plan.WriteBytes)
+	// changed.dll shrinks, but is written to a temporary file before the old one is replaced
+	assert.Equal(t, int64(len("new")+len("changed")), plan.DiskRequired)
+	assert.Empty(t, plan.Conflicts)
+	assert.True(t, plan.HasEnoughSpace())
+
+	// Nothing was written
+	_, err = os.Stat(filepath.Join(targetDir, "Engine/Binaries/new.dll"))
+	assert.True(t, os.IsNotExist(err))
+
+	// With backups the old changed.dll may be kept next to the new one
+	plan, err = PlanExtraction(*manifest, targetDir, PlanOptions{Prefixes: []string{"Engine/Binaries"}, ModifiedPolicy: ModifiedFileBackup})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("new")+len("changed")), plan.DiskRequired)
+
+	// Missing packs are flagged
+	assert.NoError(t, os.RemoveAll(packsDir))
+	plan, err = PlanExtraction(*manifest, targetDir, PlanOptions{Packs: &PackLocator{Dirs: []string{packsDir}}})
+	assert.NoError(t, err)
+	for _, p := range plan.Packs {
+		assert.True(t, p.Missing)
+	}
+}
+
+func TestPlanExtractionConflicts(t *testing.T) {
+	manifest := WorkingManifest{
+		Files: []File{
+			{Name: "Engine/Foo", Hash: "a"},
+			{Name: "Engine/Foo/Bar.h", Hash: "a"},
+			{Name: "Engine/Case.h", Hash: "a"},
+			{Name: "Engine/CASE.h", Hash: "a"},
+			{Name: "../escape.txt", Hash: "a"},
+			{Name: "Engine/Dir", Hash: "a"},
+			{Name: "Engine/NoBlob", Hash: "b"},
+		},
+		Blobs: []Blob{{Hash: "a", Size: 1, PackHash: "p"}},
+		Packs: []Pack{{Hash: "p"}},
+	}
+
+	targetDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(targetDir, "Engine", "Dir"), 0755))
+
+	plan, err := PlanExtraction(manifest, targetDir, PlanOptions{})
+	assert.NoError(t, err)
+
+	reasons := make(map[string]string)
+	for _, c := range plan.Conflicts {
+		reasons[c.Name] = c.Reason
+	}
+	assert.Contains(t, reasons["Engine/Foo"], "parent directory")
+	assert.Contains(t, reasons["Engine/CASE.h"], "differs only in case")
+	assert.Contains(t, reasons["../escape.txt"], "path traversal")
+	assert.Contains(t, reasons["Engine/Dir"], "directory exists")
+	assert.Contains(t, reasons["Engine/NoBlob"], "no blob")
+	assert.Len(t, plan.Files, 2)
+}
+
+func TestPlanOutput(t *testing.T) {
+	plan := &Plan{
+		OutputDir:     "/out",
+		Packs:         []PlannedPack{{Hash: "p", CompressedSize: 2048}},
+		Files:         []PlannedFile{{Name: "a", Action: FileActionCreate, Size: 10}},
+		DownloadBytes: 2048,
+		DiskRequired:  10,
+		DiskFree:      5,
+	}
+
+	var text bytes.Buffer
+	assert.NoError(t, plan.WriteText(&text))
+	assert.Contains(t, text.String(), "Packs to fetch: 1 (2.0 KiB compressed)")
+	assert.Contains(t, text.String(), "1 to create, 0 to overwrite, 0 unchanged")
+	assert.Contains(t, text.String(), "not enough free disk space")
+
+	var js bytes.Buffer
+	assert.NoError(t, plan.WriteJSON(&js))
+	var decoded Plan
+	assert.NoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assert.Equal(t, *plan, decoded)
+}
+
+func TestCheckRemotePacks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path == "/Path/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", "42")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	plan := &Plan{Packs: []PlannedPack{
+		{Hash: "found", Url: ts.URL + "/Path/found"},
+		{Hash: "missing", Url: ts.URL + "/Path/missing"},
+	}}
+	assert.NoError(t, CheckRemotePacks(context.Background(), *ts.Client(), plan))
+	assert.Equal(t, http.StatusOK, plan.Packs[0].RemoteStatus)
+	assert.Equal(t, int64(42), plan.Packs[0].RemoteSize)
+	assert.Equal(t, http.StatusNotFound, plan.Packs[1].RemoteStatus)
+}
@@ -0,0 +1,158 @@
+package gitDeps
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress tracks a download or extraction run for progress displays. All methods are safe for
+// concurrent use, and a nil *Progress ignores every update so callers don't need to check.
+type Progress struct {
+	totalPacks int64
+	totalBytes int64 // Compressed bytes to download, 0 if nothing is downloaded
+
+	packsDone  atomic.Int64
+	downloaded atomic.Int64
+	extracted  atomic.Int64
+
+	mu      sync.Mutex
+	workers map[int]string // Worker id -> hash of the pack it is working on
+
+	start time.Time
+	now   func() time.Time
+}
+
+// NewProgress creates a Progress for totalPacks packs holding totalBytes compressed bytes to
+// download. Pass 0 for totalBytes when packs are read from disk.
+func NewProgress(totalPacks int, totalBytes int64) *Progress {
+	return &Progress{
+		totalPacks: int64(totalPacks),
+		totalBytes: totalBytes,
+		workers:    map[int]string{},
+		start:      time.Now(),
+		now:        time.Now,
+	}
+}
+
+// AddDownloaded records n compressed bytes received from the network
+func (p *Progress) AddDownloaded(n int64) {
+	if p != nil {
+		p.downloaded.Add(n)
+	}
+}
+
+// AddExtracted records n bytes written to the output directory
+func (p *Progress) AddExtracted(n int64) {
+	if p != nil {
+		p.extracted.Add(n)
+	}
+}
+
+// StartPack records that worker started processing the pack with the given hash
+func (p *Progress) StartPack(worker int, hash string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.workers[worker] = hash
+}
+
+// FinishPack records that worker is done with its current pack
+func (p *Progress) FinishPack(worker int) {
+	if p == nil {
+		return
+	}
+	p.packsDone.Add(1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.workers, worker)
+}
+
+// WorkerState is the pack a worker is currently processing
+type WorkerState struct {
+	ID   int
+	Pack string
+}
+
+// ProgressSnapshot is a consistent view of a Progress at one point in time
+type ProgressSnapshot struct {
+	PacksDone      int64
+	TotalPacks     int64
+	Downloaded     int64
+	TotalBytes     int64
+	Extracted      int64
+	Elapsed        time.Duration
+	BytesPerSecond float64       // Download throughput, or extraction throughput if nothing is downloaded
+	ETA            time.Duration // 0 if unknown
+	Workers        []WorkerState
+}
+
+// Snapshot returns the current state
+func (p *Progress) Snapshot() ProgressSnapshot {
+	s := ProgressSnapshot{
+		PacksDone:  p.packsDone.Load(),
+		TotalPacks: p.totalPacks,
+		Downloaded: p.downloaded.Load(),
+		TotalBytes: p.totalBytes,
+		Extracted:  p.extracted.Load(),
+		Elapsed:    p.now().Sub(p.start),
+	}
+
+	p.mu.Lock()
+	for id, pack := range p.workers {
+		s.Workers = append(s.Workers, WorkerState{ID: id, Pack: pack})
+	}
+	p.mu.Unlock()
+	sort.Slice(s.Workers, func(i, j int) bool { return s.Workers[i].ID < s.Workers[j].ID })
+
+	seconds := s.Elapsed.Seconds()
+	if s.TotalBytes > 0 {
+		if seconds > 0 {
+			s.BytesPerSecond = float64(s.Downloaded) / seconds
+		}
+		if s.BytesPerSecond > 0 && s.Downloaded < s.TotalBytes {
+			s.ETA = time.Duration(float64(s.TotalBytes-s.Downloaded) / s.BytesPerSecond * float64(time.Second))
+		}
+	} else {
+		if seconds > 0 {
+			s.BytesPerSecond = float64(s.Extracted) / seconds
+		}
+		if s.PacksDone > 0 && s.PacksDone < s.TotalPacks {
+			s.ETA = time.Duration(float64(s.Elapsed) * float64(s.TotalPacks-s.PacksDone) / float64(s.PacksDone))
+		}
+	}
+	return s
+}
+
+// String formats the snapshot as a single summary line, e.g.
+// "packs 12/40, downloaded 1.2 MiB/4.0 MiB, extracted 3.1 MiB, 512.0 KiB/s, ETA 6s"
+func (s ProgressSnapshot) String() string {
+	parts := []string{fmt.Sprintf("packs %d/%d", s.PacksDone, s.TotalPacks)}
+	if s.TotalBytes > 0 {
+		parts = append(parts, fmt.Sprintf("downloaded %s/%s", formatBytes(s.Downloaded), formatBytes(s.TotalBytes)))
+	}
+	parts = append(parts, fmt.Sprintf("extracted %s", formatBytes(s.Extracted)))
+	parts = append(parts, fmt.Sprintf("%s/s", formatBytes(int64(s.BytesPerSecond))))
+	if s.ETA > 0 {
+		parts = append(parts, fmt.Sprintf("ETA %s", s.ETA.Round(time.Second)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countingReader reports every read to a Progress as downloaded bytes
+type countingReader struct {
+	r        io.Reader
+	progress *Progress
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.progress.AddDownloaded(int64(n))
+	return n, err
+}
@@ -0,0 +1,89 @@
+package gitDeps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressSnapshot(t *testing.T) {
+	t.Run("download", func(t *testing.T) {
+		p := NewProgress(4, 4096)
+		clock := p.start
+		p.now = func() time.Time { return clock }
+
+		p.StartPack(0, "aaa")
+		p.StartPack(1, "bbb")
+		p.AddDownloaded(1024)
+		p.AddExtracted(3000)
+		p.FinishPack(0)
+		clock = clock.Add(2 * time.Second)
+
+		s := p.Snapshot()
+		assert.Equal(t, int64(1), s.PacksDone)
+		assert.Equal(t, []WorkerState{{ID: 1, Pack: "bbb"}}, s.Workers)
+		assert.Equal(t, 512.0, s.BytesPerSecond)
+		assert.Equal(t, 6*time.Second, s.ETA)
+		assert.Equal(t, "packs 1/4, downloaded 1.0 KiB/4.0 KiB, extracted 2.9 KiB, 512 B/s, ETA 6s", s.String())
+	})
+
+	t.Run("extract only", func(t *testing.T) {
+		p := NewProgress(10, 0)
+		clock := p.start
+		p.now = func() time.Time { return clock }
+
+		for i := 0; i < 5; i++ {
+			p.StartPack(i, "pack")
+			p.FinishPack(i)
+		}
+		p.AddExtracted(2048)
+		clock = clock.Add(4 * time.Second)
+
+		s := p.Snapshot()
+		assert.Equal(t, 512.0, s.BytesPerSecond)
+		assert.Equal(t, 4*time.Second, s.ETA)
+		assert.Equal(t, "packs 5/10, extracted 2.0 KiB, 512 B/s, ETA 4s", s.String())
+	})
+
+	t.Run("nil progress ignores updates", func(t *testing.T) {
+		var p *Progress
+		p.AddDownloaded(1)
+		p.AddExtracted(1)
+		p.StartPack(0, "x")
+		p.FinishPack(0)
+	})
+}
+
+func TestDownloadProgress(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "hello", "b.txt": "world"})
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(src, packsDir, CreatePackOptions{PackSize: 1})
+	assert.NoError(t, err)
+
+	var total int64
+	for _, pack := range manifest.Packs {
+		total += int64(pack.CompressedSize)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join(packsDir, path.Base(r.URL.Path)+".pack.gz"))
+	}))
+	defer ts.Close()
+	manifest.BaseUrl = ts.URL
+
+	p := NewProgress(len(manifest.Packs), total)
+	_, err = DownloadAllPacksWithOptions(context.Background(), *ts.Client(), *manifest, t.TempDir(), ExtractOptions{Progress: p})
+	assert.NoError(t, err)
+
+	s := p.Snapshot()
+	assert.Equal(t, int64(2), s.PacksDone)
+	assert.Equal(t, total, s.Downloaded)
+	assert.Equal(t, int64(len("hello")+len("world")), s.Extracted)
+	assert.Empty(t, s.Workers)
+}
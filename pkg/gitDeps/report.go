@@ -0,0 +1,117 @@
+package gitDeps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SkippedPack is a pack holding requested files that could not be used
+type SkippedPack struct {
+	Hash   string `json:"hash"`
+	Reason string `json:"reason"`
+}
+
+// MissingFile is a requested file that extraction did not produce
+type MissingFile struct {
+	Name     string `json:"name"`
+	Hash     string `json:"hash"`
+	PackHash string `json:"packHash,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// CompletenessReport compares the files requested from a manifest with what an extraction
+// actually produced, so a partial extraction doesn't go unnoticed.
+type CompletenessReport struct {
+	Requested    int           `json:"requested"`
+	Produced     []string      `json:"produced"`
+	Kept         []string      `json:"kept"` // Locally modified files left in place (--modified=skip)
+	Missing      []MissingFile `json:"missing"`
+	SkippedPacks []SkippedPack `json:"skippedPacks"`
+}
+
+// NewCompletenessReport builds a report for the requested files of manifest from the result of
+// extracting them. skipped lists the packs that were not extracted and why.
+func NewCompletenessReport(manifest WorkingManifest, requested []File, result *ExtractResult, skipped []SkippedPack) *CompletenessReport {
+	report := &CompletenessReport{
+		Requested:    len(requested),
+		Produced:     []string{},
+		Kept:         []string{},
+		Missing:      []MissingFile{},
+		SkippedPacks: append([]SkippedPack{}, skipped...),
+	}
+	sort.Slice(report.SkippedPacks, func(i, j int) bool { return report.SkippedPacks[i].Hash < report.SkippedPacks[j].Hash })
+
+	done := map[string]bool{}
+	kept := map[string]bool{}
+	if result != nil {
+		for _, name := range result.Written {
+			done[name] = true
+		}
+		for _, name := range result.Unchanged {
+			done[name] = true
+		}
+		for _, m := range result.Modified {
+			if m.Action == ModifiedFileSkip {
+				kept[m.Name] = true
+			}
+		}
+	}
+
+	skippedReasons := map[string]string{}
+	for _, p := range skipped {
+		skippedReasons[p.Hash] = p.Reason
+	}
+	blobs := map[string]Blob{}
+	for _, b := range manifest.Blobs {
+		blobs[b.Hash] = b
+	}
+
+	for _, f := range requested {
+		if kept[f.Name] {
+			report.Kept = append(report.Kept, f.Name)
+			continue
+		}
+		if done[f.Name] {
+			report.Produced = append(report.Produced, f.Name)
+			continue
+		}
+
+		missing := MissingFile{Name: f.Name, Hash: f.Hash, Reason: "not extracted"}
+		if blob, ok := blobs[f.Hash]; !ok {
+			missing.Reason = "no blob in manifest"
+		} else {
+			missing.PackHash = blob.PackHash
+			if reason, ok := skippedReasons[blob.PackHash]; ok {
+				missing.Reason = fmt.Sprintf("pack skipped: %s", reason)
+			}
+		}
+		report.Missing = append(report.Missing, missing)
+	}
+
+	sort.Strings(report.Produced)
+	sort.Strings(report.Kept)
+	sort.Slice(report.Missing, func(i, j int) bool { return report.Missing[i].Name < report.Missing[j].Name })
+	return report
+}
+
+// Complete reports whether every requested file was produced and no pack was skipped
+func (r *CompletenessReport) Complete() bool {
+	return len(r.Missing) == 0 && len(r.SkippedPacks) == 0
+}
+
+// Err returns an *IncompleteError when the report is not complete, nil otherwise
+func (r *CompletenessReport) Err() error {
+	if r.Complete() {
+		return nil
+	}
+	return &IncompleteError{Missing: len(r.Missing), SkippedPacks: len(r.SkippedPacks)}
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *CompletenessReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,74 @@
+package gitDeps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletenessReport(t *testing.T) {
+	manifest := WorkingManifest{
+		Blobs: []Blob{
+			{Hash: "h1", PackHash: "p1"},
+			{Hash: "h2", PackHash: "p1"},
+			{Hash: "h3", PackHash: "p2"},
+			{Hash: "h4", PackHash: "p1"},
+		},
+	}
+	requested := []File{
+		{Name: "b.txt", Hash: "h2"},
+		{Name: "a.txt", Hash: "h1"},
+		{Name: "c.txt", Hash: "h3"},
+		{Name: "d.txt", Hash: "nope"},
+		{Name: "e.txt", Hash: "h4"},
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		result := &ExtractResult{Written: []string{"a.txt", "b.txt", "c.txt", "d.txt"}, Unchanged: []string{"e.txt"}}
+		report := NewCompletenessReport(manifest, requested, result, nil)
+
+		assert.True(t, report.Complete())
+		assert.NoError(t, report.Err())
+		assert.Equal(t, []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"}, report.Produced)
+	})
+
+	t.Run("missing files and skipped packs", func(t *testing.T) {
+		result := &ExtractResult{
+			Written:  []string{"a.txt"},
+			Modified: []ModifiedFile{{Name: "b.txt", Action: ModifiedFileSkip}},
+		}
+		skipped := []SkippedPack{{Hash: "p2", Reason: "pack file not found in packs dir"}}
+		report := NewCompletenessReport(manifest, requested, result, skipped)
+
+		assert.False(t, report.Complete())
+		assert.Equal(t, 5, report.Requested)
+		assert.Equal(t, []string{"a.txt"}, report.Produced)
+		assert.Equal(t, []string{"b.txt"}, report.Kept)
+		assert.Equal(t, []MissingFile{
+			{Name: "c.txt", Hash: "h3", PackHash: "p2", Reason: "pack skipped: pack file not found in packs dir"},
+			{Name: "d.txt", Hash: "nope", Reason: "no blob in manifest"},
+			{Name: "e.txt", Hash: "h4", PackHash: "p1", Reason: "not extracted"},
+		}, report.Missing)
+
+		var incompleteErr *IncompleteError
+		assert.True(t, errors.As(report.Err(), &incompleteErr))
+		assert.Equal(t, 3, incompleteErr.Missing)
+		assert.Equal(t, 1, incompleteErr.SkippedPacks)
+
+		var buf bytes.Buffer
+		assert.NoError(t, report.WriteJSON(&buf))
+		var decoded CompletenessReport
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, report.Missing, decoded.Missing)
+		assert.Equal(t, report.SkippedPacks, decoded.SkippedPacks)
+	})
+
+	t.Run("empty lists encode as arrays", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, NewCompletenessReport(manifest, nil, nil, nil).WriteJSON(&buf))
+		assert.Contains(t, buf.String(), `"missing": []`)
+	})
+}
@@ -0,0 +1,192 @@
+package gitDeps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phase is a stage of processing a pack that SyncStats keeps timings for
+type Phase string
+
+const (
+	PhaseNetwork    Phase = "network"    // Downloading compressed packs
+	PhaseRead       Phase = "read"       // Reading compressed packs from local disk
+	PhaseDecompress Phase = "decompress" // Inflating pack data
+	PhaseWrite      Phase = "write"      // Writing extracted files
+)
+
+// DefaultSlowestPacks is the number of slowest packs listed in a StatsReport
+const DefaultSlowestPacks = 10
+
+// SyncStats collects timings of a download or extraction run. All methods are safe for
+// concurrent use, and a nil *SyncStats ignores every update.
+type SyncStats struct {
+	mu          sync.Mutex
+	workers     int
+	start       time.Time
+	phases      map[Phase]*PhaseStats
+	packs       []PackStats
+	cacheHits   int
+	cacheMisses int
+	retries     int
+
+	now func() time.Time
+}
+
+// PhaseStats is the total time and bytes spent in a phase, summed over all workers
+type PhaseStats struct {
+	Seconds float64 `json:"seconds"`
+	Bytes   int64   `json:"bytes"`
+}
+
+// PackStats is the time one pack took from start to finish
+type PackStats struct {
+	Hash    string  `json:"hash"`
+	Seconds float64 `json:"seconds"`
+	Bytes   int64   `json:"bytes"` // Compressed size
+}
+
+// NewSyncStats starts collecting stats for a run using the given number of workers
+func NewSyncStats(workers int) *SyncStats {
+	return &SyncStats{
+		workers: workers,
+		start:   time.Now(),
+		phases:  map[Phase]*PhaseStats{},
+		now:     time.Now,
+	}
+}
+
+// AddPhase records d spent in phase processing n bytes
+func (s *SyncStats) AddPhase(phase Phase, d time.Duration, n int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ps, ok := s.phases[phase]
+	if !ok {
+		ps = &PhaseStats{}
+		s.phases[phase] = ps
+	}
+	ps.Seconds += d.Seconds()
+	ps.Bytes += n
+}
+
+// AddPack records that the pack with the given hash and compressed size took d
+func (s *SyncStats) AddPack(hash string, d time.Duration, n int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.packs = append(s.packs, PackStats{Hash: hash, Seconds: d.Seconds(), Bytes: n})
+}
+
+// CacheHit records a pack that was found locally instead of being downloaded
+func (s *SyncStats) CacheHit() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cacheHits++
+}
+
+// CacheMiss records a pack that was not found locally
+func (s *SyncStats) CacheMiss() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cacheMisses++
+}
+
+// Retry records a failed download that is retried from the next mirror
+func (s *SyncStats) Retry() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+}
+
+// StatsReport is the JSON summary of a run written by --stats-file
+type StatsReport struct {
+	Seconds float64 `json:"seconds"`
+	Workers int     `json:"workers"`
+	// Share of the available worker time spent processing packs, between 0 and 1
+	WorkerUtilization float64              `json:"workerUtilization"`
+	Packs             int                  `json:"packs"`
+	Phases            map[Phase]PhaseStats `json:"phases"`
+	SlowestPacks      []PackStats          `json:"slowestPacks"`
+	CacheHits         int                  `json:"cacheHits"`
+	CacheMisses       int                  `json:"cacheMisses"`
+	Retries           int                  `json:"retries"` // Failed downloads retried from a mirror
+}
+
+// Report summarizes the stats collected so far, listing the slowest n packs
+func (s *SyncStats) Report(slowest int) *StatsReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := s.now().Sub(s.start).Seconds()
+	report := &StatsReport{
+		Seconds:      elapsed,
+		Workers:      s.workers,
+		Packs:        len(s.packs),
+		Phases:       map[Phase]PhaseStats{},
+		SlowestPacks: []PackStats{},
+		CacheHits:    s.cacheHits,
+		CacheMisses:  s.cacheMisses,
+		Retries:      s.retries,
+	}
+	for phase, ps := range s.phases {
+		report.Phases[phase] = *ps
+	}
+
+	packs := append([]PackStats{}, s.packs...)
+	sort.Slice(packs, func(i, j int) bool { return packs[i].Seconds > packs[j].Seconds })
+	var busy float64
+	for _, p := range packs {
+		busy += p.Seconds
+	}
+	if len(packs) > slowest {
+		packs = packs[:slowest]
+	}
+	report.SlowestPacks = append(report.SlowestPacks, packs...)
+
+	if elapsed > 0 && s.workers > 0 {
+		report.WorkerUtilization = busy / (elapsed * float64(s.workers))
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *StatsReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// DecompressPack inflates the gzip-compressed data of a pack, recording the time in stats
+func DecompressPack(data []byte, stats *SyncStats) ([]byte, error) {
+	start := time.Now()
+	gzr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+	packData, err := io.ReadAll(gzr)
+	if err != nil {
+		return nil, err
+	}
+	stats.AddPhase(PhaseDecompress, time.Since(start), int64(len(packData)))
+	return packData, nil
+}
@@ -0,0 +1,74 @@
+package gitDeps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncStatsReport(t *testing.T) {
+	s := NewSyncStats(2)
+	clock := s.start
+	s.now = func() time.Time { return clock }
+
+	s.AddPhase(PhaseNetwork, time.Second, 100)
+	s.AddPhase(PhaseNetwork, 2*time.Second, 50)
+	s.AddPhase(PhaseWrite, time.Second, 400)
+	for i, d := range []time.Duration{1, 4, 2, 3} {
+		s.AddPack(string(rune('a'+i)), d*time.Second, 10)
+	}
+	s.CacheHit()
+	s.CacheHit()
+	s.CacheMiss()
+	s.Retry()
+	clock = clock.Add(5 * time.Second)
+
+	report := s.Report(2)
+	assert.Equal(t, 5.0, report.Seconds)
+	assert.Equal(t, 4, report.Packs)
+	assert.Equal(t, PhaseStats{Seconds: 3, Bytes: 150}, report.Phases[PhaseNetwork])
+	assert.Equal(t, PhaseStats{Seconds: 1, Bytes: 400}, report.Phases[PhaseWrite])
+	assert.Equal(t, []PackStats{{Hash: "b", Seconds: 4, Bytes: 10}, {Hash: "d", Seconds: 3, Bytes: 10}}, report.SlowestPacks)
+	assert.Equal(t, 2, report.CacheHits)
+	assert.Equal(t, 1, report.CacheMisses)
+	assert.Equal(t, 1, report.Retries)
+	// 10s of pack work over 5s with 2 workers
+	assert.Equal(t, 1.0, report.WorkerUtilization)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteJSON(&buf))
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Contains(t, decoded["phases"], "network")
+}
+
+func TestSyncStatsCollected(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{"a.txt": "hello", "b.txt": "world"})
+	packsDir := t.TempDir()
+	manifest, err := CreatePacks(src, packsDir, CreatePackOptions{PackSize: 1})
+	assert.NoError(t, err)
+
+	stats := NewSyncStats(1)
+	bundle := tarBundle(t, bundleEntries(t, *manifest, packsDir))
+	_, _, err = ExtractBundle(context.Background(), bytes.NewReader(bundle), *manifest, manifest.Files, t.TempDir(), ExtractOptions{Stats: stats})
+	assert.NoError(t, err)
+
+	report := stats.Report(DefaultSlowestPacks)
+	assert.Equal(t, 2, report.Packs)
+	assert.Equal(t, 2, report.CacheHits)
+	assert.Equal(t, int64(len("hello")+len("world")), report.Phases[PhaseWrite].Bytes)
+	assert.Equal(t, int64(len("hello")+len("world")+2*len(PackHeader)), report.Phases[PhaseDecompress].Bytes)
+	assert.NotZero(t, report.Phases[PhaseRead].Bytes)
+
+	var nilStats *SyncStats
+	nilStats.AddPhase(PhaseWrite, time.Second, 1)
+	nilStats.AddPack("x", time.Second, 1)
+	nilStats.CacheHit()
+	nilStats.CacheMiss()
+	nilStats.Retry()
+}
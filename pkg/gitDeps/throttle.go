@@ -0,0 +1,266 @@
+package gitDeps
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NetworkOptions configures the HTTP client used to download packs
+type NetworkOptions struct {
+	BytesPerSecond  int64           // Global download limit shared by all requests (0 means unlimited)
+	MaxConnsPerHost int             // Maximum concurrent connections per host (0 means unlimited)
+	Polite          *PoliteSchedule // Optional lower limit during working hours
+}
+
+// NewHTTPClient returns a client that enforces opts across every request made with it,
+// so all download workers sharing the client share the same bandwidth budget.
+func NewHTTPClient(opts NetworkOptions) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+
+	if opts.BytesPerSecond <= 0 && opts.Polite == nil {
+		return &http.Client{Transport: transport}
+	}
+
+	return &http.Client{Transport: &throttledTransport{
+		base:    transport,
+		limiter: NewRateLimiter(opts.BytesPerSecond, opts.Polite),
+	}}
+}
+
+// PoliteSchedule applies a lower bandwidth limit during a daily time window,
+// e.g. office hours when a full sync would saturate the uplink for everyone.
+type PoliteSchedule struct {
+	Days           [7]bool       // Days the schedule applies, indexed by time.Weekday
+	Start          time.Duration // Start of the window, as an offset from local midnight
+	End            time.Duration // End of the window, as an offset from local midnight
+	BytesPerSecond int64         // Limit while the window is active
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParsePoliteSchedule parses a schedule like "09:00-18:00" (every day) or "Mon-Fri 09:00-18:00".
+// A window whose end is before its start wraps past midnight.
+func ParsePoliteSchedule(spec string, bytesPerSecond int64) (*PoliteSchedule, error) {
+	if bytesPerSecond <= 0 {
+		return nil, fmt.Errorf("polite schedule %q needs a positive bandwidth limit", spec)
+	}
+
+	s := &PoliteSchedule{BytesPerSecond: bytesPerSecond}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 1:
+		for i := range s.Days {
+			s.Days[i] = true
+		}
+	case 2:
+		first, last, ok := strings.Cut(strings.ToLower(fields[0]), "-")
+		if !ok {
+			last = first
+		}
+		from, okFrom := weekdays[first]
+		to, okTo := weekdays[last]
+		if !okFrom || !okTo {
+			return nil, fmt.Errorf("invalid day range %q in polite schedule", fields[0])
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			s.Days[d] = true
+			if d == to {
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid polite schedule %q (expected e.g. \"Mon-Fri 09:00-18:00\")", spec)
+	}
+
+	window := fields[len(fields)-1]
+	start, end, ok := strings.Cut(window, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid time window %q in polite schedule", window)
+	}
+	var err error
+	if s.Start, err = parseClock(start); err != nil {
+		return nil, err
+	}
+	if s.End, err = parseClock(end); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q in polite schedule (expected HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Active reports whether the schedule applies at t (in t's location)
+func (s *PoliteSchedule) Active(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if s.Start <= s.End {
+		return s.Days[t.Weekday()] && offset >= s.Start && offset < s.End
+	}
+	// The window wraps past midnight; the part after midnight belongs to the previous day
+	if offset >= s.Start {
+		return s.Days[t.Weekday()]
+	}
+	return offset < s.End && s.Days[(t.Weekday()+6)%7]
+}
+
+// RateLimiter is a token bucket limiting the number of bytes per second. It is safe for
+// concurrent use, so a single limiter can be shared by all download workers.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limit    int64
+	schedule *PoliteSchedule
+	tokens   float64
+	last     time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimiter creates a limiter allowing bytesPerSecond (0 means unlimited), lowered to the
+// schedule's limit while it is active. The bucket holds at most one second worth of bytes.
+func NewRateLimiter(bytesPerSecond int64, schedule *PoliteSchedule) *RateLimiter {
+	return &RateLimiter{
+		limit:    bytesPerSecond,
+		schedule: schedule,
+		tokens:   -1,
+		now:      time.Now,
+		sleep:    sleepContext,
+	}
+}
+
+// Limit returns the bytes per second allowed at t, 0 meaning unlimited
+func (r *RateLimiter) Limit(t time.Time) int64 {
+	limit := r.limit
+	if r.schedule != nil && r.schedule.Active(t) && (limit <= 0 || r.schedule.BytesPerSecond < limit) {
+		limit = r.schedule.BytesPerSecond
+	}
+	return limit
+}
+
+// WaitN blocks until n bytes may be transferred or ctx is done. Requests larger than the
+// bucket are allowed and paid back by the following callers.
+func (r *RateLimiter) WaitN(ctx context.Context, n int) error {
+	r.mu.Lock()
+	now := r.now()
+	limit := r.Limit(now)
+	if limit <= 0 {
+		r.mu.Unlock()
+		return nil
+	}
+
+	if r.tokens < 0 && r.last.IsZero() {
+		r.tokens = float64(limit) // Start with a full bucket
+	} else {
+		r.tokens += now.Sub(r.last).Seconds() * float64(limit)
+		if r.tokens > float64(limit) {
+			r.tokens = float64(limit)
+		}
+	}
+	r.last = now
+	r.tokens -= float64(n)
+
+	var wait time.Duration
+	if r.tokens < 0 {
+		wait = time.Duration(-r.tokens / float64(limit) * float64(time.Second))
+	}
+	r.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+	return r.sleep(ctx, wait)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttledTransport limits the rate at which response bodies are read
+type throttledTransport struct {
+	base    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = &throttledBody{ReadCloser: res.Body, limiter: t.limiter, ctx: req.Context()}
+	return res, nil
+}
+
+// throttledChunk bounds a single read so the limiter can interleave concurrent downloads fairly
+const throttledChunk = 32 * 1024
+
+type throttledBody struct {
+	io.ReadCloser
+	limiter *RateLimiter
+	ctx     context.Context
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	if len(p) > throttledChunk {
+		p = p[:throttledChunk]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.WaitN(b.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// ParseByteSize parses sizes like "512", "100K", "1.5MB" or "2MiB". Decimal (KB, MB, GB) and
+// single-letter suffixes use powers of 1024 like the binary (KiB, MiB, GiB) suffixes, matching
+// how download tools such as curl and wget interpret --limit-rate.
+func ParseByteSize(s string) (int64, error) {
+	orig := s
+	s = strings.TrimSpace(strings.ToUpper(s))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/S"), "B")
+	s = strings.TrimSuffix(s, "I")
+
+	multiplier := int64(1)
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			s = s[:len(s)-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", orig)
+	}
+	return int64(value * float64(multiplier)), nil
+}
@@ -0,0 +1,166 @@
+package gitDeps
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock drives a RateLimiter without sleeping
+type fakeClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) install(r *RateLimiter) {
+	r.now = func() time.Time { return c.now }
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		c.slept += d
+		c.now = c.now.Add(d)
+		return nil
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	r := NewRateLimiter(100, nil)
+	clock.install(r)
+
+	// The bucket starts full
+	assert.NoError(t, r.WaitN(context.Background(), 100))
+	assert.Zero(t, clock.slept)
+
+	// Half a second worth of bytes over budget
+	assert.NoError(t, r.WaitN(context.Background(), 50))
+	assert.Equal(t, 500*time.Millisecond, clock.slept)
+
+	// Idle time refills the bucket, but never beyond one second worth
+	clock.now = clock.now.Add(10 * time.Second)
+	clock.slept = 0
+	assert.NoError(t, r.WaitN(context.Background(), 100))
+	assert.Zero(t, clock.slept)
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	r := NewRateLimiter(0, nil)
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		t.Fatal("unlimited limiter should never sleep")
+		return nil
+	}
+	assert.NoError(t, r.WaitN(context.Background(), 1<<30))
+}
+
+func TestPoliteSchedule(t *testing.T) {
+	s, err := ParsePoliteSchedule("Mon-Fri 09:00-18:00", 10)
+	assert.NoError(t, err)
+
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.True(t, s.Active(monday.Add(9*time.Hour)))
+	assert.True(t, s.Active(monday.Add(17*time.Hour+59*time.Minute)))
+	assert.False(t, s.Active(monday.Add(18*time.Hour)))
+	assert.False(t, s.Active(monday.Add(8*time.Hour)))
+	assert.False(t, s.Active(monday.Add(5*24*time.Hour+10*time.Hour)), "saturday")
+
+	// Wrapping windows belong to the day they start on
+	night, err := ParsePoliteSchedule("Fri 22:00-06:00", 10)
+	assert.NoError(t, err)
+	friday := monday.Add(4 * 24 * time.Hour)
+	assert.True(t, night.Active(friday.Add(23*time.Hour)))
+	assert.True(t, night.Active(friday.Add(24*time.Hour+time.Hour)), "early saturday")
+	assert.False(t, night.Active(monday.Add(time.Hour)), "early monday")
+
+	everyDay, err := ParsePoliteSchedule("09:00-18:00", 10)
+	assert.NoError(t, err)
+	assert.True(t, everyDay.Active(monday.Add(5*24*time.Hour+10*time.Hour)))
+
+	for _, bad := range []string{"9-5", "Mon-Funday 09:00-18:00", "Mon 09:00", "a b c"} {
+		_, err := ParsePoliteSchedule(bad, 10)
+		assert.Error(t, err, bad)
+	}
+	_, err = ParsePoliteSchedule("09:00-18:00", 0)
+	assert.Error(t, err)
+
+	// The schedule only ever lowers the limit
+	r := NewRateLimiter(100, s)
+	assert.Equal(t, int64(10), r.Limit(monday.Add(10*time.Hour)))
+	assert.Equal(t, int64(100), r.Limit(monday.Add(20*time.Hour)))
+	r = NewRateLimiter(0, s)
+	assert.Equal(t, int64(10), r.Limit(monday.Add(10*time.Hour)))
+	assert.Equal(t, int64(0), r.Limit(monday.Add(20*time.Hour)))
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":     512,
+		"100K":    100 << 10,
+		"100KB":   100 << 10,
+		"1.5M":    3 << 19,
+		"2MiB":    2 << 20,
+		"1g":      1 << 30,
+		"10MB/s":  10 << 20,
+		" 64 kb ": 64 << 10,
+	}
+	for in, want := range tests {
+		got, err := ParseByteSize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, bad := range []string{"", "fast", "-1M"} {
+		_, err := ParseByteSize(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestNewHTTPClientMaxConnsPerHost(t *testing.T) {
+	var current, peak atomic.Int32
+	release := make(chan struct{})
+	full := make(chan struct{}) // Closed by the handler once the connection cap is reached
+	var fullOnce sync.Once
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		if n == 2 {
+			fullOnce.Do(func() { close(full) })
+		}
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		current.Add(-1)
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	client := NewHTTPClient(NetworkOptions{MaxConnsPerHost: 2, BytesPerSecond: 1 << 20})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := client.Get(ts.URL)
+			if assert.NoError(t, err) {
+				body, err := io.ReadAll(res.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, "ok", string(body))
+				res.Body.Close()
+			}
+		}()
+	}
+
+	<-full
+	close(release)
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
@@ -0,0 +1,215 @@
+package gitDeps
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// VerifyOptions configures VerifyTree
+type VerifyOptions struct {
+	// Only verify files with these path prefixes. Extra files are only reported under them too.
+	// An empty list verifies the whole manifest and directory.
+	Prefixes []string
+
+	// Number of files hashed in parallel. Defaults to the number of CPUs.
+	Workers int
+
+	// Ignore lists files, relative to the directory, that are never reported as extra, e.g. the
+	// working manifest a sync leaves in it
+	Ignore []string
+}
+
+// ModifiedEntry is a file whose content does not match the manifest
+type ModifiedEntry struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ModeMismatch is a file whose executable bit does not match File.IsExecutable
+type ModeMismatch struct {
+	Name         string `json:"name"`
+	IsExecutable bool   `json:"isExecutable"` // What the manifest expects
+}
+
+// VerifyReport is the result of auditing a directory against a manifest
+type VerifyReport struct {
+	Dir          string          `json:"dir"`
+	Checked      int             `json:"checked"`
+	Missing      []string        `json:"missing"`
+	Modified     []ModifiedEntry `json:"modified"`
+	ModeMismatch []ModeMismatch  `json:"modeMismatch"`
+	Extra        []string        `json:"extra"`
+}
+
+// OK reports whether every file of the manifest is in the directory as the manifest describes it.
+// Extra files don't count, see Exact.
+func (r *VerifyReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Modified) == 0 && len(r.ModeMismatch) == 0
+}
+
+// Exact reports whether the directory matches the manifest exactly, without extra files
+func (r *VerifyReport) Exact() bool {
+	return r.OK() && len(r.Extra) == 0
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *VerifyReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// VerifyTree checks every manifest file under dir for presence, SHA1 and executable bit, and
+// lists files in dir that the manifest does not know about. Executable bits are not checked on
+// Windows, which has no such bit.
+func VerifyTree(ctx context.Context, manifest WorkingManifest, dir string, opts VerifyOptions) (*VerifyReport, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	report := &VerifyReport{
+		Dir:          dir,
+		Missing:      []string{},
+		Modified:     []ModifiedEntry{},
+		ModeMismatch: []ModeMismatch{},
+		Extra:        []string{},
+	}
+
+	known := map[string]bool{}
+	var files []File
+	for _, f := range manifest.Files {
+		if !matchesPrefixes(f.Name, opts.Prefixes) || known[f.Name] {
+			continue
+		}
+		known[f.Name] = true
+		files = append(files, f)
+	}
+	report.Checked = len(files)
+
+	var mu sync.Mutex
+	var firstErr error
+	queue := make(chan File)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+				err := verifyFile(f, dir, report, &mu)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for _, f := range files {
+		select {
+		case queue <- f:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !known[name] && !slices.Contains(opts.Ignore, name) && matchesPrefixes(name, opts.Prefixes) {
+			report.Extra = append(report.Extra, name)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sort.Strings(report.Missing)
+	sort.Slice(report.Modified, func(i, j int) bool { return report.Modified[i].Name < report.Modified[j].Name })
+	sort.Slice(report.ModeMismatch, func(i, j int) bool { return report.ModeMismatch[i].Name < report.ModeMismatch[j].Name })
+	sort.Strings(report.Extra)
+	return report, nil
+}
+
+// verifyFile checks a single file and records any difference in report
+func verifyFile(file File, dir string, report *VerifyReport, mu *sync.Mutex) error {
+	if err := checkTargetPath(dir, file.Name); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, file.Name)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		mu.Lock()
+		report.Missing = append(report.Missing, file.Name)
+		mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		mu.Lock()
+		report.Missing = append(report.Missing, file.Name)
+		mu.Unlock()
+		return nil
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if actual != file.Hash {
+		report.Modified = append(report.Modified, ModifiedEntry{Name: file.Name, Expected: file.Hash, Actual: actual})
+	}
+	if runtime.GOOS != "windows" && (info.Mode()&0111 != 0) != file.IsExecutable {
+		report.ModeMismatch = append(report.ModeMismatch, ModeMismatch{Name: file.Name, IsExecutable: file.IsExecutable})
+	}
+	return nil
+}
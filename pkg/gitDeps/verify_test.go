@@ -0,0 +1,92 @@
+package gitDeps
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyTree(t *testing.T) {
+	src := t.TempDir()
+	writeTree(t, src, map[string]string{
+		"Engine/Binaries/tool":    "#!/bin/sh\n",
+		"Engine/Source/a.cpp":     "int a;",
+		"Engine/Source/b.cpp":     "int b;",
+		"Engine/Content/c.uasset": "asset",
+	})
+	assert.NoError(t, os.Chmod(filepath.Join(src, "Engine/Binaries/tool"), 0755))
+
+	manifest, err := CreatePacks(src, t.TempDir(), CreatePackOptions{})
+	assert.NoError(t, err)
+
+	t.Run("clean tree", func(t *testing.T) {
+		report, err := VerifyTree(context.Background(), *manifest, src, VerifyOptions{Workers: 2})
+		assert.NoError(t, err)
+		assert.True(t, report.Exact())
+		assert.Equal(t, 4, report.Checked)
+	})
+
+	t.Run("detects differences", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTree(t, dir, map[string]string{
+			"Engine/Binaries/tool":    "#!/bin/sh\n",
+			"Engine/Source/a.cpp":     "int a = 1;",
+			"Engine/Source/extra.h":   "",
+			"Engine/Content/c.uasset": "asset",
+		})
+
+		report, err := VerifyTree(context.Background(), *manifest, dir, VerifyOptions{})
+		assert.NoError(t, err)
+		assert.False(t, report.OK())
+		assert.Equal(t, []string{"Engine/Source/b.cpp"}, report.Missing)
+		if assert.Len(t, report.Modified, 1) {
+			assert.Equal(t, "Engine/Source/a.cpp", report.Modified[0].Name)
+			assert.Equal(t, sha1Hex([]byte("int a = 1;")), report.Modified[0].Actual)
+		}
+		assert.Equal(t, []string{"Engine/Source/extra.h"}, report.Extra)
+		assert.False(t, report.Exact())
+		if runtime.GOOS != "windows" {
+			assert.Equal(t, []ModeMismatch{{Name: "Engine/Binaries/tool", IsExecutable: true}}, report.ModeMismatch)
+		}
+
+		// Prefixes limit both the checked files and the extra files
+		report, err = VerifyTree(context.Background(), *manifest, dir, VerifyOptions{Prefixes: []string{"Engine/Content"}})
+		assert.NoError(t, err)
+		assert.True(t, report.OK())
+		assert.Equal(t, 1, report.Checked)
+	})
+
+	t.Run("extra files only", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, f := range manifest.Files {
+			data, err := os.ReadFile(filepath.Join(src, f.Name))
+			assert.NoError(t, err)
+			writeTree(t, dir, map[string]string{f.Name: string(data)})
+		}
+		assert.NoError(t, os.Chmod(filepath.Join(dir, "Engine/Binaries/tool"), 0755))
+		writeTree(t, dir, map[string]string{"Engine/Source/extra.h": ""})
+
+		report, err := VerifyTree(context.Background(), *manifest, dir, VerifyOptions{})
+		assert.NoError(t, err)
+		assert.True(t, report.OK())
+		assert.False(t, report.Exact())
+		assert.Equal(t, []string{"Engine/Source/extra.h"}, report.Extra)
+
+		// Ignored files are not extra
+		writeTree(t, dir, map[string]string{".ue4dependencies": ""})
+		report, err = VerifyTree(context.Background(), *manifest, dir, VerifyOptions{Ignore: []string{".ue4dependencies", "Engine/Source/extra.h"}})
+		assert.NoError(t, err)
+		assert.True(t, report.Exact())
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := VerifyTree(ctx, *manifest, src, VerifyOptions{})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -21,7 +21,7 @@ type File struct {
 	Hash         string `xml:"Hash,attr"`
 	ExpectedHash string `xml:"ExpectedHash,attr"`
 	Timestamp    int    `xml:"Timestamp,attr"`
-	IsExecutable bool   `xml:"IsExecutable,attr"`
+	IsExecutable bool   `xml:"IsExecutable,attr,omitempty"`
 }
 
 type Blob struct {
@@ -37,4 +37,8 @@ type Pack struct {
 	Size           int    `xml:"Size,attr"`
 	CompressedSize int    `xml:"CompressedSize,attr"`
 	RemotePath     string `xml:"RemotePath,attr"`
+
+	// BaseUrl of the manifest the pack came from when manifests are merged, see MergeManifests.
+	// Takes precedence over WorkingManifest.BaseUrl.
+	BaseUrl string `xml:"-"`
 }
@@ -0,0 +1,155 @@
+package codegen
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"unicode/utf16"
+)
+
+// CityHash64 (v1.1), which UHT hashes generated code with to tell hot reload what changed
+
+const (
+	k0 uint64 = 0xc3a5c85c97cb3127
+	k1 uint64 = 0xb492b66fbe98f273
+	k2 uint64 = 0x9ae16a3b2f90404f
+)
+
+func fetch64(s []byte) uint64 { return binary.LittleEndian.Uint64(s) }
+func fetch32(s []byte) uint64 { return uint64(binary.LittleEndian.Uint32(s)) }
+
+func rotate(v uint64, shift int) uint64 { return bits.RotateLeft64(v, -shift) }
+
+func shiftMix(v uint64) uint64 { return v ^ (v >> 47) }
+
+func hashLen16(u, v uint64) uint64 { return hashLen16Mul(u, v, 0x9ddfea08eb382d69) }
+
+func hashLen16Mul(u, v, mul uint64) uint64 {
+	a := (u ^ v) * mul
+	a ^= a >> 47
+	b := (v ^ a) * mul
+	b ^= b >> 47
+	return b * mul
+}
+
+func hashLen0to16(s []byte) uint64 {
+	n := uint64(len(s))
+	switch {
+	case n >= 8:
+		mul := k2 + n*2
+		a := fetch64(s) + k2
+		b := fetch64(s[n-8:])
+		c := rotate(b, 37)*mul + a
+		d := (rotate(a, 25) + b) * mul
+		return hashLen16Mul(c, d, mul)
+	case n >= 4:
+		mul := k2 + n*2
+		return hashLen16Mul(n+(fetch32(s)<<3), fetch32(s[n-4:]), mul)
+	case n > 0:
+		y := uint32(s[0]) + uint32(s[n>>1])<<8
+		z := uint32(n) + uint32(s[n-1])<<2
+		return shiftMix(uint64(y)*k2^uint64(z)*k0) * k2
+	}
+	return k2
+}
+
+func hashLen17to32(s []byte) uint64 {
+	n := uint64(len(s))
+	mul := k2 + n*2
+	a := fetch64(s) * k1
+	b := fetch64(s[8:])
+	c := fetch64(s[n-8:]) * mul
+	d := fetch64(s[n-16:]) * k2
+	return hashLen16Mul(rotate(a+b, 43)+rotate(c, 30)+d, a+rotate(b+k2, 18)+c, mul)
+}
+
+func hashLen33to64(s []byte) uint64 {
+	n := uint64(len(s))
+	mul := k2 + n*2
+	a := fetch64(s) * k2
+	b := fetch64(s[8:])
+	c := fetch64(s[n-24:])
+	d := fetch64(s[n-32:])
+	e := fetch64(s[16:]) * k2
+	f := fetch64(s[24:]) * 9
+	g := fetch64(s[n-8:])
+	h := fetch64(s[n-16:]) * mul
+	u := rotate(a+g, 43) + (rotate(b, 30)+c)*9
+	v := ((a + g) ^ d) + f + 1
+	w := bits.ReverseBytes64((u+v)*mul) + h
+	x := rotate(e+f, 42) + c
+	y := (bits.ReverseBytes64((v+w)*mul) + g) * mul
+	z := e + f + c
+	a = bits.ReverseBytes64((x+z)*mul+y) + b
+	b = shiftMix((z+a)*mul+d+h) * mul
+	return b + x
+}
+
+func weakHashLen32WithSeeds(s []byte, a, b uint64) (uint64, uint64) {
+	w, x, y, z := fetch64(s), fetch64(s[8:]), fetch64(s[16:]), fetch64(s[24:])
+	a += w
+	b = rotate(b+a+z, 21)
+	c := a
+	a += x
+	a += y
+	b += rotate(a, 44)
+	return a + z, b + c
+}
+
+func cityHash64(s []byte) uint64 {
+	n := uint64(len(s))
+	switch {
+	case n <= 16:
+		return hashLen0to16(s)
+	case n <= 32:
+		return hashLen17to32(s)
+	case n <= 64:
+		return hashLen33to64(s)
+	}
+
+	x := fetch64(s[n-40:])
+	y := fetch64(s[n-16:]) + fetch64(s[n-56:])
+	z := hashLen16(fetch64(s[n-48:])+n, fetch64(s[n-24:]))
+	v1, v2 := weakHashLen32WithSeeds(s[n-64:], n, z)
+	w1, w2 := weakHashLen32WithSeeds(s[n-32:], y+k1, x)
+	x = x*k1 + fetch64(s)
+
+	for left := (n - 1) &^ 63; left != 0; left -= 64 {
+		x = rotate(x+y+v1+fetch64(s[8:]), 37) * k1
+		y = rotate(y+v2+fetch64(s[48:]), 42) * k1
+		x ^= w2
+		y += v1 + fetch64(s[40:])
+		z = rotate(z+w1, 33) * k1
+		v1, v2 = weakHashLen32WithSeeds(s, v2*k1, x+w1)
+		w1, w2 = weakHashLen32WithSeeds(s[32:], z+w2, y+fetch64(s[16:]))
+		z, x = x, z
+		s = s[64:]
+	}
+	return hashLen16(hashLen16(v1, w1)+shiftMix(y)*k1+z, hashLen16(v2, w2)+x)
+}
+
+func cityHash64WithSeed(s []byte, seed uint64) uint64 {
+	return hashLen16(cityHash64(s)-k2, seed)
+}
+
+// textHash hashes text like UHT's UhtHash is meant to: CityHash64 of the UTF-16 code units of each
+// string, seeded with the hash so far, folded to 32 bits. Which text UHT hashes has not been
+// checked against its output.
+func textHash(texts ...string) uint32 {
+	var hash uint64
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		units := utf16.Encode([]rune(text))
+		b := make([]byte, 2*len(units))
+		for i, u := range units {
+			binary.LittleEndian.PutUint16(b[2*i:], u)
+		}
+		if hash == 0 {
+			hash = cityHash64(b)
+		} else {
+			hash = cityHash64WithSeed(b, hash)
+		}
+	}
+	return uint32(hash + hash>>32)
+}
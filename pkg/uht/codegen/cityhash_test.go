@@ -0,0 +1,26 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCityHash64(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0x9ae16a3b2f90404f},
+		{"a", 0xb3454265b6df75e3},
+		{"abc", 0x24a5b3a074e7f369},
+		{"hello, world", 0x0bddb94646e75817},
+		{"Test enum for UHT code generation", 0x75e34d658dfa056d},
+		{strings.Repeat("UENUM(BlueprintType) enum class ETestEnum : uint8 { Value1 };\n", 3), 0xa65749d3676e6b3d},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, cityHash64([]byte(tt.in)), tt.in)
+	}
+	assert.Equal(t, uint64(0x613c8921333da2c8), cityHash64WithSeed([]byte("abc"), 0x1234))
+}
@@ -0,0 +1,115 @@
+package codegen
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"kreempuff.dev/rules-unreal-engine/pkg/uht/scan"
+)
+
+var update = flag.Bool("update", false, "rewrite the snapshots of the generated code")
+
+const (
+	fixtureDir = "../../../test/verification/uht/simple_enum"
+	rootDir    = "../../.."
+
+	// snapshotDir has the code this package generated for the fixtures, rewritten with -update.
+	// It catches unintended changes, not differences from UHT.
+	snapshotDir = fixtureDir + "/golden/go/5.5"
+
+	// uhtGoldenDir has the code UHT 5.5 generated for the fixtures, captured by
+	// test/verification/uht/run.bats. Tests never write it.
+	uhtGoldenDir = fixtureDir + "/golden/uht/5.5"
+)
+
+var fixtures = []string{"TestEnum", "TestEnumForms"}
+
+func generateFixture(t *testing.T, name string) *Files {
+	h, err := scan.ScanFile(filepath.Join(fixtureDir, "Public", name+".h"))
+	require.NoError(t, err)
+	files, err := Generate(h, Options{ModuleName: "TestModule", BaseDir: fixtureDir, RootDir: rootDir})
+	require.NoError(t, err)
+	return files
+}
+
+func assertSnapshot(t *testing.T, name string, got []byte) {
+	path := filepath.Join(snapshotDir, name)
+	if *update {
+		assert.NoError(t, os.WriteFile(path, got, 0644))
+	}
+	want, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestGenerateSnapshot(t *testing.T) {
+	for _, name := range fixtures {
+		files := generateFixture(t, name)
+		assertSnapshot(t, name+".generated.h", files.GeneratedH)
+		assertSnapshot(t, name+".gen.cpp", files.GenCpp)
+	}
+}
+
+func TestGenerateMatchesUHT(t *testing.T) {
+	for _, name := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			files := generateFixture(t, name)
+			for file, got := range map[string][]byte{name + ".generated.h": files.GeneratedH, name + ".gen.cpp": files.GenCpp} {
+				want, err := os.ReadFile(filepath.Join(uhtGoldenDir, file))
+				if errors.Is(err, os.ErrNotExist) {
+					t.Skipf("no UHT output captured for %s, see test/verification/uht/run.bats", file)
+				}
+				require.NoError(t, err)
+				assert.Equal(t, string(want), string(got), file)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	h, err := scan.Scan(filepath.Join(fixtureDir, "Public/Bad.h"), []byte("USTRUCT()\nstruct FFoo { GENERATED_BODY() };\n"))
+	assert.NoError(t, err)
+	_, err = Generate(h, Options{ModuleName: "TestModule", BaseDir: fixtureDir})
+	assert.ErrorContains(t, err, "only headers declaring just UENUMs are supported")
+
+	h, err = scan.Scan(filepath.Join(fixtureDir, "Public/Bad.h"), []byte("UENUM(Blueprintable)\nenum class EFoo : uint8 { A };\n"))
+	assert.NoError(t, err)
+	_, err = Generate(h, Options{ModuleName: "TestModule", BaseDir: fixtureDir})
+	assert.ErrorContains(t, err, "Bad.h:1: UENUM EFoo: unknown specifier Blueprintable")
+
+	h, err = scan.Scan(filepath.Join(fixtureDir, "Public/Bad.h"), []byte("UENUM()\nenum class EFoo : uint8 {\n#if WITH_EDITOR\nA\n#endif\n};\n"))
+	assert.NoError(t, err)
+	_, err = Generate(h, Options{ModuleName: "TestModule", BaseDir: fixtureDir})
+	assert.ErrorContains(t, err, "preprocessor conditions around enum values are not supported")
+
+	empty, err := scan.Scan(filepath.Join(fixtureDir, "Public/Bad.h"), []byte("UENUM()\nenum class EFoo : uint8 {};\n"))
+	assert.NoError(t, err)
+	_, err = Generate(empty, Options{ModuleName: "TestModule", BaseDir: fixtureDir})
+	assert.ErrorContains(t, err, "Bad.h:1: UENUM EFoo: enums without values are not supported")
+
+	_, err = Generate(h, Options{ModuleName: "TestModule", BaseDir: "/elsewhere"})
+	assert.ErrorContains(t, err, "header is not under the module base directory /elsewhere")
+
+	_, err = Generate(h, Options{ModuleName: "TestModule", BaseDir: fixtureDir, EngineVersion: "5.4"})
+	assert.ErrorContains(t, err, `code generation for engine version "5.4" is not supported`)
+}
+
+func TestMetaData(t *testing.T) {
+	assert.Equal(t, "/**\n * Line one\n *\tLine two\n */", normalizeComment("/**\n\t * Line one\n\t *\tLine two\n\t */"))
+	assert.Equal(t, "Line one\nLine two", toolTip("/**\n * Line one\n * Line two\n */"))
+	assert.Equal(t, "A comment", toolTip("// A comment"))
+	assert.Equal(t, "Two\nlines", toolTip("// Two\n// lines"))
+	assert.Equal(t, `a \"b\"\n\\c`, escape("a \"b\"\n\\c"))
+
+	var m metaData
+	m.add("ToolTip", "Explicit")
+	m.addComment("", "/** From comment */")
+	m.add("b", "1")
+	m.add("A", "2")
+	assert.Equal(t, []metaPair{{"A", "2"}, {"b", "1"}, {"Comment", "/** From comment */"}, {"ToolTip", "Explicit"}}, m.sorted())
+}
@@ -0,0 +1,210 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"kreempuff.dev/rules-unreal-engine/pkg/uht/scan"
+)
+
+// enum is a UENUM being generated
+type enum struct {
+	*scan.Enum
+	header *header
+	values []enumValue
+	meta   []metaPair
+
+	cppType          string // ETestEnum, or ENetRole::Type for namespaced enums
+	cppForm          string // The UEnum::ECppForm
+	flags            string // The EEnumFlags
+	constructName    string // e.g. Z_Construct_UEnum_TestModule_ETestEnum
+	registrationInfo string // e.g. Z_Registration_Info_UEnum_ETestEnum
+	hash             uint32 // Hash of the enum's generated code, for hot reload
+}
+
+// enumValue is a value of an enum with its full C++ name, e.g. ETestEnum::Value1
+type enumValue struct {
+	*scan.EnumValue
+	fullName string
+}
+
+func newEnum(h *header, e *scan.Enum) (*enum, error) {
+	if len(e.Conditions) > 0 {
+		return nil, fmt.Errorf("UENUM %s: preprocessor conditions around UENUMs are not supported", e.Name)
+	}
+	if len(e.Values) == 0 {
+		// The generated Enumerators array would have no elements, which is not valid C++
+		return nil, fmt.Errorf("UENUM %s: enums without values are not supported", e.Name)
+	}
+	en := &enum{
+		Enum:             e,
+		header:           h,
+		cppType:          e.Name,
+		flags:            "EEnumFlags::None",
+		constructName:    "Z_Construct_UEnum_" + h.opts.ModuleName + "_" + e.Name,
+		registrationInfo: "Z_Registration_Info_UEnum_" + e.Name,
+	}
+
+	scope := ""
+	switch e.Form {
+	case scan.EnumClass:
+		en.cppForm = "EnumClass"
+		scope = e.Name + "::"
+	case scan.EnumNamespaced:
+		en.cppForm = "Namespaced"
+		en.cppType = e.Namespace + "::Type"
+		scope = e.Namespace + "::"
+	default:
+		en.cppForm = "Regular"
+	}
+
+	var meta metaData
+	for _, s := range e.Specifiers {
+		switch s.Key {
+		case "BlueprintType":
+			meta.add("BlueprintType", "true")
+		case "Flags":
+			en.flags = "EEnumFlags::Flags"
+		default:
+			return nil, fmt.Errorf("UENUM %s: unknown specifier %s", e.Name, s.Key)
+		}
+	}
+	for _, m := range e.Meta {
+		meta.add(m.Key, m.Value)
+	}
+	meta.addComment("", e.Comment)
+	meta.add("ModuleRelativePath", h.modulePath)
+
+	for _, v := range e.Values {
+		if len(v.Conditions) > 0 {
+			return nil, fmt.Errorf("UENUM %s: preprocessor conditions around enum values are not supported", e.Name)
+		}
+		value := enumValue{EnumValue: v, fullName: scope + v.Name}
+		en.values = append(en.values, value)
+		meta.addComment(v.Name+".", v.Comment)
+		for _, m := range v.Meta {
+			meta.add(v.Name+"."+m.Key, m.Value)
+		}
+		meta.add(v.Name+".Name", value.fullName)
+	}
+	en.meta = meta.sorted()
+	return en, nil
+}
+
+// existingMax returns the index of the value named like the _MAX value UEnum adds itself, which
+// the FOREACH_ENUM macro leaves out, or -1
+func (e *enum) existingMax() int {
+	if len(e.values) == 0 {
+		return -1
+	}
+	prefix := e.values[0].Name
+	for _, v := range e.values[1:] {
+		for !strings.HasPrefix(v.Name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if i := strings.LastIndexByte(prefix, '_'); i > 0 {
+		prefix = prefix[:i]
+	} else {
+		prefix = e.Name
+	}
+	for i, v := range e.values {
+		if v.Name == prefix+"_MAX" {
+			return i
+		}
+	}
+	return -1
+}
+
+// declare writes the enum's part of the .generated.h: its FOREACH_ENUM macro and the
+// declarations of StaticEnum, which need a forward declaration of the enum
+func (e *enum) declare(b *builder) {
+	b.WriteString("#define FOREACH_ENUM_" + strings.ToUpper(e.Name) + "(op) ")
+	existingMax := e.existingMax()
+	for i, v := range e.values {
+		if i != existingMax {
+			b.WriteString("\\\r\n\top(" + v.fullName + ") ")
+		}
+	}
+	b.line()
+	b.line()
+
+	underlying := ""
+	if e.UnderlyingType != "" {
+		underlying = " : " + e.UnderlyingType
+	}
+	switch {
+	case e.Form == scan.EnumClass:
+		b.line("enum class ", e.Name, underlying, ";")
+		b.line("template<> struct TIsUEnumClass<", e.Name, "> { enum { Value = true }; };")
+	case underlying == "":
+		// Enums without an underlying type can't be forward declared
+		b.line()
+		return
+	case e.Form == scan.EnumNamespaced:
+		b.line("namespace ", e.Namespace, " { enum Type", underlying, "; }")
+	default:
+		b.line("enum ", e.Name, underlying, ";")
+	}
+	b.line("template<> ", e.header.api, " UEnum* StaticEnum<", e.cppType, ">();")
+	b.line()
+}
+
+// define writes the enum's part of the .gen.cpp: its registration info, metadata, enumerators
+// and construction
+func (e *enum) define(b *builder) {
+	h := e.header
+	statics := e.constructName + "_Statics"
+
+	b.line("static FEnumRegistrationInfo ", e.registrationInfo, ";")
+	b.line("static UEnum* ", e.Name, "_StaticEnum()")
+	b.line("{")
+	b.line("\tif (!", e.registrationInfo, ".OuterSingleton)")
+	b.line("\t{")
+	b.line("\t\t", e.registrationInfo, ".OuterSingleton = GetStaticEnum(", e.constructName,
+		", (UObject*)", h.packageConstructName(), "(), TEXT(\"", e.Name, "\"));")
+	b.line("\t}")
+	b.line("\treturn ", e.registrationInfo, ".OuterSingleton;")
+	b.line("}")
+	b.line("template<> ", h.api, " UEnum* StaticEnum<", e.cppType, ">()")
+	b.line("{")
+	b.line("\treturn ", e.Name, "_StaticEnum();")
+	b.line("}")
+
+	b.line("struct ", statics)
+	b.line("{")
+	b.line("#if WITH_METADATA")
+	b.line("\tstatic constexpr UECodeGen_Private::FMetaDataPairParam Enum_MetaDataParams[] = {")
+	writeMetaData(b, e.meta)
+	b.line("\t};")
+	b.line("#endif // WITH_METADATA")
+	b.line("\tstatic constexpr UECodeGen_Private::FEnumeratorParam Enumerators[] = {")
+	for _, v := range e.values {
+		b.line("\t\t{ \"", v.fullName, "\", (int64)", v.fullName, " },")
+	}
+	b.line("\t};")
+	b.line("\tstatic const UECodeGen_Private::FEnumParams EnumParams;")
+	b.line("};")
+
+	b.line("const UECodeGen_Private::FEnumParams ", statics, "::EnumParams = {")
+	b.line("\t(UObject*(*)())", h.packageConstructName(), ",")
+	b.line("\tnullptr,")
+	b.line("\t\"", e.Name, "\",")
+	b.line("\t\"", e.cppType, "\",")
+	b.line("\t", statics, "::Enumerators,")
+	b.line("\tRF_Public|RF_Transient|RF_MarkAsNative,")
+	b.line("\tUE_ARRAY_COUNT(", statics, "::Enumerators),")
+	b.line("\t", e.flags, ",")
+	b.line("\t(uint8)UEnum::ECppForm::", e.cppForm, ",")
+	b.line("\tMETADATA_PARAMS(UE_ARRAY_COUNT(", statics, "::Enum_MetaDataParams), ", statics, "::Enum_MetaDataParams)")
+	b.line("};")
+
+	b.line("UEnum* ", e.constructName, "()")
+	b.line("{")
+	b.line("\tif (!", e.registrationInfo, ".InnerSingleton)")
+	b.line("\t{")
+	b.line("\t\tUECodeGen_Private::ConstructUEnum(", e.registrationInfo, ".InnerSingleton, ", statics, "::EnumParams);")
+	b.line("\t}")
+	b.line("\treturn ", e.registrationInfo, ".InnerSingleton;")
+	b.line("}")
+}
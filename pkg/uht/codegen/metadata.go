@@ -0,0 +1,124 @@
+package codegen
+
+import (
+	"sort"
+	"strings"
+)
+
+// metaPair is a metadata entry of generated code
+type metaPair struct {
+	key, value string
+}
+
+// metaData collects the metadata of a type. Later entries replace earlier ones with the same key.
+type metaData []metaPair
+
+func (m *metaData) add(key, value string) {
+	for i := range *m {
+		if strings.EqualFold((*m)[i].key, key) {
+			(*m)[i].value = value
+			return
+		}
+	}
+	*m = append(*m, metaPair{key, value})
+}
+
+// addComment adds the Comment and ToolTip of a documentation comment, with keys prefixed by
+// prefix. A ToolTip given explicitly wins over the one from the comment.
+func (m *metaData) addComment(prefix, comment string) {
+	if comment == "" {
+		return
+	}
+	m.add(prefix+"Comment", normalizeComment(comment))
+	for _, p := range *m {
+		if strings.EqualFold(p.key, prefix+"ToolTip") {
+			return
+		}
+	}
+	if tip := toolTip(comment); tip != "" {
+		m.add(prefix+"ToolTip", tip)
+	}
+}
+
+// sorted returns the entries ordered by key, ignoring case, as UHT writes them
+func (m metaData) sorted() []metaPair {
+	sorted := append([]metaPair{}, m...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := strings.ToLower(sorted[i].key), strings.ToLower(sorted[j].key)
+		if a != b {
+			return a < b
+		}
+		return sorted[i].key < sorted[j].key
+	})
+	return sorted
+}
+
+// writeMetaData writes the entries of an FMetaDataPairParam array. Comments and tooltips are
+// left out of shipping builds.
+func writeMetaData(b *builder, meta []metaPair) {
+	for _, m := range meta {
+		editorOnly := isDocumentationKey(m.key)
+		if editorOnly {
+			b.line("#if !UE_BUILD_SHIPPING")
+		}
+		b.line("\t\t{ \"", m.key, "\", \"", escape(m.value), "\" },")
+		if editorOnly {
+			b.line("#endif")
+		}
+	}
+}
+
+func isDocumentationKey(key string) bool {
+	_, name, _ := strings.Cut(key, ".")
+	if name == "" {
+		name = key
+	}
+	return name == "Comment" || name == "ToolTip"
+}
+
+// escape returns s as the contents of a C++ string literal
+func escape(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\r", "",
+		"\n", `\n`,
+		"\t", `\t`,
+	).Replace(s)
+}
+
+// normalizeComment returns a comment with the indentation of its lines removed, keeping the
+// space before the asterisks of block comments
+func normalizeComment(comment string) string {
+	lines := strings.Split(strings.ReplaceAll(comment, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if i > 0 {
+			line = strings.TrimLeft(line, " \t")
+			if strings.HasPrefix(line, "*") {
+				line = " " + line
+			}
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toolTip returns the text of a comment without comment markers, leading asterisks and blank
+// lines at its start and end
+func toolTip(comment string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(comment, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "//"):
+			line = strings.TrimLeft(line, "/")
+		case strings.HasPrefix(line, "/*"):
+			line = strings.TrimLeft(strings.TrimPrefix(line, "/"), "*")
+		}
+		line = strings.TrimSuffix(line, "*/")
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "*"))
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
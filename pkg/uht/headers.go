@@ -0,0 +1,169 @@
+package uht
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HeaderKind is the header list of a module a header belongs to, given by the directory under the
+// module's BaseDir it lives in
+type HeaderKind string
+
+const (
+	HeaderKindClasses  HeaderKind = "Classes"
+	HeaderKindPublic   HeaderKind = "Public"
+	HeaderKindInternal HeaderKind = "Internal"
+	HeaderKindPrivate  HeaderKind = "Private" // Private/ and anything else not in the other directories
+)
+
+// excludedHeaderDirs are directories whose headers UHT never processes: implementation details
+// and platform-specific code
+var excludedHeaderDirs = map[string]bool{
+	"Detail":    true,
+	"Impl":      true,
+	"Windows":   true,
+	"Microsoft": true,
+	"Apple":     true,
+	"Mac":       true,
+	"Unix":      true,
+	"Linux":     true,
+	"IOS":       true,
+	"Android":   true,
+}
+
+// reflectionMarkup matches the macros that make UBT pass a header to UHT, the same expression as
+// UBT's ReflectionMarkupRegex
+var reflectionMarkup = regexp.MustCompile(`(?m)^\s*U(CLASS|STRUCT|ENUM|INTERFACE|DELEGATE)\b`)
+
+// ClassifiedHeaders are the headers of a module sorted into the manifest's header lists
+type ClassifiedHeaders struct {
+	Classes  []string
+	Public   []string
+	Internal []string
+	Private  []string
+	Skipped  []SkippedHeader
+}
+
+// SkippedHeader is a header ClassifyHeaders left out, and why
+type SkippedHeader struct {
+	Path   string
+	Reason string
+}
+
+// ClassifyHeaderPath returns the kind of a header given by its path relative to the module's
+// BaseDir
+func ClassifyHeaderPath(rel string) HeaderKind {
+	first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	switch HeaderKind(first) {
+	case HeaderKindClasses, HeaderKindPublic, HeaderKindInternal:
+		return HeaderKind(first)
+	default:
+		return HeaderKindPrivate
+	}
+}
+
+// ClassifyHeaders sorts headers into the lists of a module with the given BaseDir. It leaves out
+// files that are not .h headers, headers in Detail, Impl and platform directories, headers outside
+// baseDir, and headers without UCLASS, USTRUCT, UENUM, UINTERFACE or UDELEGATE. UHT needs unique
+// basenames within a module, so of headers sharing a basename only the one with the shortest
+// path is kept. Paths are returned as given.
+func ClassifyHeaders(baseDir string, headers []string) (*ClassifiedHeaders, error) {
+	absBaseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+
+	result := &ClassifiedHeaders{}
+	skip := func(path, reason string) {
+		result.Skipped = append(result.Skipped, SkippedHeader{Path: path, Reason: reason})
+	}
+
+	type candidate struct {
+		path string
+		kind HeaderKind
+	}
+	byBasename := map[string]candidate{}
+	var order []string
+
+	for _, hdr := range headers {
+		if filepath.Ext(hdr) != ".h" {
+			skip(hdr, "not a .h header")
+			continue
+		}
+
+		absHdr, err := filepath.Abs(hdr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve header %s: %w", hdr, err)
+		}
+		if !isUnder(absBaseDir, absHdr) {
+			skip(hdr, "outside base directory "+baseDir)
+			continue
+		}
+		rel, _ := filepath.Rel(absBaseDir, absHdr)
+
+		if dir := excludedDir(rel); dir != "" {
+			skip(hdr, "in excluded directory "+dir)
+			continue
+		}
+
+		data, err := os.ReadFile(absHdr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header %s: %w", hdr, err)
+		}
+		if !reflectionMarkup.Match(data) {
+			skip(hdr, "no reflection markup")
+			continue
+		}
+
+		base := filepath.Base(hdr)
+		if existing, ok := byBasename[base]; ok {
+			// Keep the shorter path, like the Bazel rule always did
+			if len(hdr) < len(existing.path) {
+				skip(existing.path, "duplicate basename of "+hdr)
+				byBasename[base] = candidate{path: hdr, kind: ClassifyHeaderPath(rel)}
+			} else {
+				skip(hdr, "duplicate basename of "+existing.path)
+			}
+			continue
+		}
+		byBasename[base] = candidate{path: hdr, kind: ClassifyHeaderPath(rel)}
+		order = append(order, base)
+	}
+
+	for _, base := range order {
+		c := byBasename[base]
+		switch c.kind {
+		case HeaderKindClasses:
+			result.Classes = append(result.Classes, c.path)
+		case HeaderKindPublic:
+			result.Public = append(result.Public, c.path)
+		case HeaderKindInternal:
+			result.Internal = append(result.Internal, c.path)
+		default:
+			result.Private = append(result.Private, c.path)
+		}
+	}
+	sort.Slice(result.Skipped, func(i, j int) bool { return result.Skipped[i].Path < result.Skipped[j].Path })
+	return result, nil
+}
+
+// isUnder reports whether path is inside dir
+func isUnder(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// excludedDir returns the first excluded directory in rel, or "" if there is none
+func excludedDir(rel string) string {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if excludedHeaderDirs[dir] {
+			return dir
+		}
+	}
+	return ""
+}
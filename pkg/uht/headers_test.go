@@ -0,0 +1,112 @@
+package uht
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeHeaders creates every header of contents under dir and returns their paths in order
+func writeHeaders(t *testing.T, dir string, contents [][2]string) []string {
+	var paths []string
+	for _, c := range contents {
+		path := filepath.Join(dir, c[0])
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(c[1]), 0644))
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestClassifyHeaderPath(t *testing.T) {
+	assert.Equal(t, HeaderKindClasses, ClassifyHeaderPath("Classes/Foo.h"))
+	assert.Equal(t, HeaderKindPublic, ClassifyHeaderPath("Public/Sub/Foo.h"))
+	assert.Equal(t, HeaderKindInternal, ClassifyHeaderPath("Internal/Foo.h"))
+	assert.Equal(t, HeaderKindPrivate, ClassifyHeaderPath("Private/Foo.h"))
+	assert.Equal(t, HeaderKindPrivate, ClassifyHeaderPath("Foo.h"))
+	assert.Equal(t, HeaderKindPrivate, ClassifyHeaderPath("Other/Public/Foo.h"))
+}
+
+func TestClassifyHeaders(t *testing.T) {
+	base := t.TempDir()
+	const enum = "#pragma once\n\nUENUM(BlueprintType)\nenum class EFoo : uint8 { A };\n"
+	paths := writeHeaders(t, base, [][2]string{
+		{"Classes/Legacy.h", "UCLASS()\nclass ULegacy : public UObject { GENERATED_BODY() };\n"},
+		{"Public/Foo.h", enum},
+		{"Public/Sub/Struct.h", "  USTRUCT()\nstruct FStruct { GENERATED_BODY() };\n"},
+		{"Internal/Iface.h", "UINTERFACE()\nclass UIface : public UInterface { GENERATED_BODY() };\n"},
+		{"Private/Delegate.h", "UDELEGATE()\nDECLARE_DYNAMIC_DELEGATE(FOnFoo);\n"},
+		{"Loose.h", enum},
+		{"Public/Plain.h", "// UCLASS() in a comment is not markup\nclass FPlain {};\n"},
+		{"Public/Detail/Helper.h", enum},
+		{"Public/Windows/WindowsFoo.h", enum},
+		{"Public/Foo.inl", enum},
+		{"Private/Sub/Foo.h", enum},
+	})
+
+	outside := writeHeaders(t, t.TempDir(), [][2]string{{"Public/Outside.h", enum}})
+	headers := append(paths, outside...)
+
+	result, err := ClassifyHeaders(base, headers)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{paths[0]}, result.Classes)
+	assert.Equal(t, []string{paths[1], paths[2]}, result.Public)
+	assert.Equal(t, []string{paths[3]}, result.Internal)
+	assert.Equal(t, []string{paths[4], paths[5]}, result.Private)
+
+	reasons := map[string]string{}
+	for _, s := range result.Skipped {
+		reasons[s.Path] = s.Reason
+	}
+	assert.Equal(t, map[string]string{
+		paths[6]:   "no reflection markup",
+		paths[7]:   "in excluded directory Detail",
+		paths[8]:   "in excluded directory Windows",
+		paths[9]:   "not a .h header",
+		paths[10]:  "duplicate basename of " + paths[1],
+		outside[0]: "outside base directory " + base,
+	}, reasons)
+}
+
+func TestClassifyHeadersPrefersShorterPath(t *testing.T) {
+	base := t.TempDir()
+	paths := writeHeaders(t, base, [][2]string{
+		{"Public/Sub/Foo.h", "UENUM()\nenum class EFoo { A };\n"},
+		{"Public/Foo.h", "UENUM()\nenum class EFoo { A };\n"},
+	})
+
+	result, err := ClassifyHeaders(base, paths)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{paths[1]}, result.Public)
+	assert.Equal(t, []SkippedHeader{{Path: paths[0], Reason: "duplicate basename of " + paths[1]}}, result.Skipped)
+
+	_, err = ClassifyHeaders(base, []string{filepath.Join(base, "Public/Missing.h")})
+	assert.ErrorContains(t, err, "failed to read header")
+}
+
+func TestManifestClassifiesHeaders(t *testing.T) {
+	base := t.TempDir()
+	paths := writeHeaders(t, base, [][2]string{
+		{"Public/Foo.h", "UCLASS()\nclass UFoo : public UObject { GENERATED_BODY() };\n"},
+		{"Public/Plain.h", "class FPlain {};\n"},
+		{"Private/Bar.h", "USTRUCT()\nstruct FBar { GENERATED_BODY() };\n"},
+	})
+
+	manifest, err := NewTargetManifest(TargetSpec{Modules: []ModuleSpec{{
+		Name:          "Foo",
+		BaseDir:       base,
+		OutputDir:     filepath.Join(base, "out"),
+		Headers:       paths,
+		ModuleOptions: ModuleOptions{ClassifyHeaders: true},
+	}}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{paths[0]}, manifest.Modules[0].PublicHeaders)
+	assert.Equal(t, []string{paths[2]}, manifest.Modules[0].PrivateHeaders)
+	assert.Equal(t, []string{}, manifest.Modules[0].ClassesHeaders)
+
+	m, err := ParseModuleSpec("name=Foo,classify=true")
+	assert.NoError(t, err)
+	assert.True(t, m.ClassifyHeaders)
+}
@@ -5,42 +5,157 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
 )
 
+// DefaultEngineVersion is the engine version whose manifest schema is used when none is given
+const DefaultEngineVersion = "5.5"
+
+// engineVersions are the engine versions whose manifest schema UHTManifest and UHTModule match.
+// New versions are added once a manifest generated by their UBT has been captured and compared.
+var engineVersions = []string{"5.5"}
+
+// EngineVersions returns the engine versions the manifest schema supports
+func EngineVersions() []string {
+	return append([]string{}, engineVersions...)
+}
+
+// checkEngineVersion returns an error if the manifest schema of version is not supported
+func checkEngineVersion(version string) error {
+	for _, v := range engineVersions {
+		if v == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported engine version %q (supported versions: %s)", version, strings.Join(engineVersions, ", "))
+}
+
 // UHTManifest represents the JSON manifest file that UHT expects
 // Based on Epic's UHTManifest type from Engine/Source/Programs/Shared/EpicGames.Core/UHTTypes.cs
 type UHTManifest struct {
-	IsGameTarget bool        `json:"IsGameTarget"`
-	RootLocalPath string     `json:"RootLocalPath"`
-	TargetName string        `json:"TargetName"`
-	Modules []UHTModule      `json:"Modules"`
+	IsGameTarget             bool        `json:"IsGameTarget"`
+	RootLocalPath            string      `json:"RootLocalPath"`
+	TargetName               string      `json:"TargetName"`
+	ExternalDependenciesFile string      `json:"ExternalDependenciesFile"` // UHT writes the headers it read here
+	Modules                  []UHTModule `json:"Modules"`
 }
 
 // UHTModule represents a single module in the manifest
 type UHTModule struct {
 	Name                     string   `json:"Name"`
-	ModuleType              string   `json:"ModuleType"`
-	BaseDirectory           string   `json:"BaseDirectory"`
-	IncludePaths            []string `json:"IncludePaths"`
-	OutputDirectory         string   `json:"OutputDirectory"`
-	PublicHeaders           []string `json:"PublicHeaders"`
-	GeneratedCPPFilenameBase string  `json:"GeneratedCPPFilenameBase"`
-	SaveExportedHeaders     bool     `json:"SaveExportedHeaders"`
-	UHTGeneratedCodeVersion string   `json:"UHTGeneratedCodeVersion"`
+	ModuleType               string   `json:"ModuleType"`
+	OverrideModuleType       string   `json:"OverrideModuleType"`
+	BaseDirectory            string   `json:"BaseDirectory"`
+	IncludePaths             []string `json:"IncludePaths"`
+	OutputDirectory          string   `json:"OutputDirectory"`
+	ClassesHeaders           []string `json:"ClassesHeaders"`
+	PublicHeaders            []string `json:"PublicHeaders"`
+	InternalHeaders          []string `json:"InternalHeaders"`
+	PrivateHeaders           []string `json:"PrivateHeaders"`
+	PublicDefines            []string `json:"PublicDefines"`
+	GeneratedCPPFilenameBase string   `json:"GeneratedCPPFilenameBase"`
+	SaveExportedHeaders      bool     `json:"SaveExportedHeaders"`
+	UHTGeneratedCodeVersion  string   `json:"UHTGeneratedCodeVersion"`
+	VersePath                string   `json:"VersePath"`
+	VerseScope               string   `json:"VerseScope"`
+	HasVerse                 bool     `json:"HasVerse"`
+	VerseMountPoint          string   `json:"VerseMountPoint"`
+	AlwaysExportStructs      bool     `json:"AlwaysExportStructs"`
+	AlwaysExportEnums        bool     `json:"AlwaysExportEnums"`
+}
+
+// ModuleOptions are the optional fields of a module. The zero value of each field gives the
+// value UBT writes for a regular C++ module.
+type ModuleOptions struct {
+	// Game marks a module of a project or project plugin, whose module host types map to Game
+	// module types instead of Engine ones (see ParseModuleType)
+	Game bool `json:"game,omitempty" yaml:"game,omitempty"`
+
+	// ClassifyHeaders sorts the module's headers into the header lists by their directory under
+	// BaseDir and leaves out those UHT should not process (see the ClassifyHeaders function),
+	// instead of making every header a public header
+	ClassifyHeaders bool `json:"classifyHeaders,omitempty" yaml:"classifyHeaders,omitempty"`
+
+	OverrideModuleType string   `json:"overrideModuleType,omitempty" yaml:"overrideModuleType,omitempty"` // Default: "None"
+	ClassesHeaders     []string `json:"classesHeaders,omitempty" yaml:"classesHeaders,omitempty"`
+	InternalHeaders    []string `json:"internalHeaders,omitempty" yaml:"internalHeaders,omitempty"`
+	PrivateHeaders     []string `json:"privateHeaders,omitempty" yaml:"privateHeaders,omitempty"`
+	PublicDefines      []string `json:"publicDefines,omitempty" yaml:"publicDefines,omitempty"`
+
+	// GeneratedCPPFilenameBase defaults to <OutputDir>/<Name>.gen
+	GeneratedCPPFilenameBase string `json:"generatedCppFilenameBase,omitempty" yaml:"generatedCppFilenameBase,omitempty"`
+
+	SaveExportedHeaders     *bool  `json:"saveExportedHeaders,omitempty" yaml:"saveExportedHeaders,omitempty"`         // Default: true
+	UHTGeneratedCodeVersion string `json:"uhtGeneratedCodeVersion,omitempty" yaml:"uhtGeneratedCodeVersion,omitempty"` // Default: "None"
+
+	VersePath       string `json:"versePath,omitempty" yaml:"versePath,omitempty"`
+	VerseScope      string `json:"verseScope,omitempty" yaml:"verseScope,omitempty"` // Default: "PublicUser"
+	HasVerse        bool   `json:"hasVerse,omitempty" yaml:"hasVerse,omitempty"`
+	VerseMountPoint string `json:"verseMountPoint,omitempty" yaml:"verseMountPoint,omitempty"`
+
+	AlwaysExportStructs *bool `json:"alwaysExportStructs,omitempty" yaml:"alwaysExportStructs,omitempty"` // Default: true
+	AlwaysExportEnums   *bool `json:"alwaysExportEnums,omitempty" yaml:"alwaysExportEnums,omitempty"`     // Default: true
+}
+
+// TargetOptions are the optional fields of a manifest
+type TargetOptions struct {
+	// EngineVersion selects the manifest schema (default: DefaultEngineVersion)
+	EngineVersion string `json:"engineVersion,omitempty" yaml:"engineVersion,omitempty"`
+
+	// TargetType defaults to Game. Modules without a type default to Program for Program targets.
+	TargetType TargetType `json:"targetType,omitempty" yaml:"targetType,omitempty"`
+
+	// IsGameTarget overrides the value derived from TargetType
+	IsGameTarget *bool `json:"isGameTarget,omitempty" yaml:"isGameTarget,omitempty"`
+
+	// ExternalDependenciesFile defaults to <TargetName>.deps in the first module's OutputDir
+	ExternalDependenciesFile string `json:"externalDependenciesFile,omitempty" yaml:"externalDependenciesFile,omitempty"`
+
+	// Relocatable writes paths under the working directory (the Bazel execroot) relative to
+	// ExecRootPlaceholder, so the manifest is the same wherever it is generated. Such manifests
+	// must be resolved with ResolveManifest before UHT reads them.
+	Relocatable bool `json:"relocatable,omitempty" yaml:"relocatable,omitempty"`
+}
+
+// Bool returns a pointer to b, for the optional fields of ModuleOptions and TargetOptions
+func Bool(b bool) *bool {
+	return &b
+}
+
+// boolOr returns *b, or def if b is nil
+func boolOr(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+// stringOr returns s, or def if s is empty
+func stringOr(s string, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
 }
 
 // GenerateManifestOptions contains parameters for manifest generation
 type GenerateManifestOptions struct {
-	ModuleName  string
-	ModuleType  string   // "Runtime", "Developer", "Editor", etc.
-	BaseDir     string   // Module source directory (absolute path)
-	OutputDir   string   // Where UHT writes generated files (absolute path)
-	Headers     []string // List of header files (absolute paths)
-	UERoot      string   // Unreal Engine root directory
-	TargetName  string   // Build target name (default: "BazelTarget")
+	ModuleName   string
+	ModuleType   string   // ModuleType or module host type, see ParseModuleType
+	BaseDir      string   // Module source directory (absolute path)
+	OutputDir    string   // Where UHT writes generated files (absolute path)
+	Headers      []string // List of public header files, or of all headers with ClassifyHeaders (absolute paths)
+	IncludePaths []string // Replace the default, the Public and Private subdirectories of BaseDir
+	UERoot       string   // Unreal Engine root directory
+	TargetName   string   // Build target name (default: "BazelTarget")
+
+	ModuleOptions
+	TargetOptions
 }
 
-// GenerateManifest creates a UHT manifest JSON file
+// GenerateManifest creates a UHT manifest JSON file for a single module
 func GenerateManifest(opts GenerateManifestOptions) ([]byte, error) {
 	// Validate required fields
 	if opts.ModuleName == "" {
@@ -53,72 +168,219 @@ func GenerateManifest(opts GenerateManifestOptions) ([]byte, error) {
 		return nil, fmt.Errorf("output directory is required")
 	}
 
+	return GenerateTargetManifest(TargetSpec{
+		TargetName:    opts.TargetName,
+		UERoot:        opts.UERoot,
+		TargetOptions: opts.TargetOptions,
+		Modules: []ModuleSpec{{
+			Name:          opts.ModuleName,
+			Type:          opts.ModuleType,
+			BaseDir:       opts.BaseDir,
+			OutputDir:     opts.OutputDir,
+			Headers:       opts.Headers,
+			IncludePaths:  opts.IncludePaths,
+			ModuleOptions: opts.ModuleOptions,
+		}},
+	})
+}
+
+// NewTargetManifest builds the manifest for every module of spec. Modules are ordered so that
+// each comes after the modules it depends on, and all paths are made absolute.
+func NewTargetManifest(spec TargetSpec) (*UHTManifest, error) {
+	if len(spec.Modules) == 0 {
+		return nil, fmt.Errorf("at least one module is required")
+	}
+
 	// Set defaults
-	if opts.ModuleType == "" {
-		opts.ModuleType = "Runtime"
+	spec.EngineVersion = stringOr(spec.EngineVersion, DefaultEngineVersion)
+	if err := checkEngineVersion(spec.EngineVersion); err != nil {
+		return nil, err
+	}
+	if spec.TargetName == "" {
+		spec.TargetName = "BazelTarget"
+	}
+	if spec.TargetType == "" {
+		spec.TargetType = TargetTypeGame
 	}
-	if opts.TargetName == "" {
-		opts.TargetName = "BazelTarget"
+	if _, err := ParseTargetType(string(spec.TargetType)); err != nil {
+		return nil, err
 	}
-	if opts.UERoot == "" {
-		// Default to BaseDir (will be overridden by caller)
-		opts.UERoot = opts.BaseDir
+	if spec.UERoot == "" {
+		// Default to the first module's BaseDir (will be overridden by caller)
+		spec.UERoot = spec.Modules[0].BaseDir
 	}
 
-	// Convert all paths to absolute (handles both relative Bazel paths and absolute paths)
-	// When running with no-sandbox, filepath.Abs resolves relative paths from real execroot
-	absBaseDir, err := filepath.Abs(opts.BaseDir)
+	modules, err := orderModules(spec.Modules)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve base directory: %w", err)
+		return nil, err
 	}
 
-	absOutputDir, err := filepath.Abs(opts.OutputDir)
+	absUERoot, err := filepath.Abs(spec.UERoot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+		return nil, fmt.Errorf("failed to resolve UE root: %w", err)
+	}
+
+	manifest := &UHTManifest{
+		IsGameTarget:  boolOr(spec.IsGameTarget, spec.TargetType.IsGameTarget()),
+		RootLocalPath: absUERoot,
+		TargetName:    spec.TargetName,
+	}
+	for _, m := range modules {
+		module, err := newModule(m, spec.TargetType)
+		if err != nil {
+			return nil, fmt.Errorf("module %s: %w", m.Name, err)
+		}
+		manifest.Modules = append(manifest.Modules, module)
 	}
 
-	absUERoot, err := filepath.Abs(opts.UERoot)
+	depsFile := spec.ExternalDependenciesFile
+	if depsFile == "" {
+		depsFile = filepath.Join(manifest.Modules[0].OutputDirectory, spec.TargetName+".deps")
+	}
+	manifest.ExternalDependenciesFile, err = filepath.Abs(depsFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve UE root: %w", err)
+		return nil, fmt.Errorf("failed to resolve external dependencies file: %w", err)
 	}
 
-	// Convert header paths to absolute
-	absHeaders := make([]string, len(opts.Headers))
-	for i, hdr := range opts.Headers {
-		absHdr, err := filepath.Abs(hdr)
+	if spec.Relocatable {
+		execRoot, err := os.Getwd()
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve header %s: %w", hdr, err)
+			return nil, fmt.Errorf("failed to get working directory: %w", err)
+		}
+		relocateManifest(manifest, execRoot)
+	}
+	return manifest, nil
+}
+
+// newModule builds the manifest entry of a single module of a target of the given type
+func newModule(spec ModuleSpec, targetType TargetType) (UHTModule, error) {
+	if spec.BaseDir == "" {
+		return UHTModule{}, fmt.Errorf("base directory is required")
+	}
+	if spec.OutputDir == "" {
+		return UHTModule{}, fmt.Errorf("output directory is required")
+	}
+	if spec.Type == "" {
+		spec.Type = "Runtime"
+		if targetType == TargetTypeProgram {
+			spec.Type = string(ModuleTypeProgram)
+		}
+	}
+	moduleType, err := ParseModuleType(spec.Type, spec.Game)
+	if err != nil {
+		return UHTModule{}, err
+	}
+
+	// Convert all paths to absolute (handles both relative Bazel paths and absolute paths)
+	// Relative paths resolve from the execroot; see TargetOptions.Relocatable
+	absBaseDir, err := filepath.Abs(spec.BaseDir)
+	if err != nil {
+		return UHTModule{}, fmt.Errorf("failed to resolve base directory: %w", err)
+	}
+
+	absOutputDir, err := filepath.Abs(spec.OutputDir)
+	if err != nil {
+		return UHTModule{}, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+
+	if spec.ClassifyHeaders {
+		classified, err := ClassifyHeaders(spec.BaseDir, spec.Headers)
+		if err != nil {
+			return UHTModule{}, err
+		}
+		for _, skipped := range classified.Skipped {
+			logrus.Debugf("module %s: skipping header %s: %s", spec.Name, skipped.Path, skipped.Reason)
 		}
-		absHeaders[i] = absHdr
+		spec.Headers = classified.Public
+		spec.ClassesHeaders = append(spec.ClassesHeaders, classified.Classes...)
+		spec.InternalHeaders = append(spec.InternalHeaders, classified.Internal...)
+		spec.PrivateHeaders = append(spec.PrivateHeaders, classified.Private...)
 	}
 
-	// Build include paths (Public, Private subdirectories of BaseDir)
+	// Convert header paths to absolute
+	headers := map[string][]string{
+		"public":   spec.Headers,
+		"classes":  spec.ClassesHeaders,
+		"internal": spec.InternalHeaders,
+		"private":  spec.PrivateHeaders,
+	}
+	absHeaders := make(map[string][]string, len(headers))
+	for kind, paths := range headers {
+		absHeaders[kind] = make([]string, len(paths))
+		for i, hdr := range paths {
+			absHdr, err := filepath.Abs(hdr)
+			if err != nil {
+				return UHTModule{}, fmt.Errorf("failed to resolve header %s: %w", hdr, err)
+			}
+			absHeaders[kind][i] = absHdr
+		}
+	}
+
+	// Build include paths: Public and Private subdirectories of BaseDir, unless given. Given
+	// paths replace the default instead of adding to it, like UBT's manifests.
 	includePaths := []string{
 		filepath.Join(absBaseDir, "Public"),
 		filepath.Join(absBaseDir, "Private"),
 	}
+	if len(spec.IncludePaths) > 0 {
+		includePaths = make([]string, len(spec.IncludePaths))
+		for i, inc := range spec.IncludePaths {
+			absInc, err := filepath.Abs(inc)
+			if err != nil {
+				return UHTModule{}, fmt.Errorf("failed to resolve include path %s: %w", inc, err)
+			}
+			includePaths[i] = absInc
+		}
+	}
 
-	// Build module entry
-	module := UHTModule{
-		Name:                     opts.ModuleName,
-		ModuleType:              "Engine" + opts.ModuleType, // "EngineRuntime", "EngineDeveloper", etc.
-		BaseDirectory:           absBaseDir,
-		IncludePaths:            includePaths,
-		OutputDirectory:         absOutputDir,
-		PublicHeaders:           absHeaders,
-		GeneratedCPPFilenameBase: filepath.Join(absOutputDir, opts.ModuleName+".gen"),
-		SaveExportedHeaders:     true,
-		UHTGeneratedCodeVersion: "None",
+	generatedBase := filepath.Join(absOutputDir, spec.Name+".gen")
+	if spec.GeneratedCPPFilenameBase != "" {
+		generatedBase, err = filepath.Abs(spec.GeneratedCPPFilenameBase)
+		if err != nil {
+			return UHTModule{}, fmt.Errorf("failed to resolve generated cpp filename base: %w", err)
+		}
 	}
 
-	// Build manifest
-	manifest := UHTManifest{
-		IsGameTarget:  true,
-		RootLocalPath: absUERoot,
-		TargetName:    opts.TargetName,
-		Modules:       []UHTModule{module},
+	publicDefines := spec.PublicDefines
+	if publicDefines == nil {
+		publicDefines = []string{}
 	}
 
+	return UHTModule{
+		Name:                     spec.Name,
+		ModuleType:               string(moduleType),
+		OverrideModuleType:       stringOr(spec.OverrideModuleType, "None"),
+		BaseDirectory:            absBaseDir,
+		IncludePaths:             includePaths,
+		OutputDirectory:          absOutputDir,
+		ClassesHeaders:           absHeaders["classes"],
+		PublicHeaders:            absHeaders["public"],
+		InternalHeaders:          absHeaders["internal"],
+		PrivateHeaders:           absHeaders["private"],
+		PublicDefines:            publicDefines,
+		GeneratedCPPFilenameBase: generatedBase,
+		SaveExportedHeaders:      boolOr(spec.SaveExportedHeaders, true),
+		UHTGeneratedCodeVersion:  stringOr(spec.UHTGeneratedCodeVersion, "None"),
+		VersePath:                spec.VersePath,
+		VerseScope:               stringOr(spec.VerseScope, "PublicUser"),
+		HasVerse:                 spec.HasVerse,
+		VerseMountPoint:          spec.VerseMountPoint,
+		AlwaysExportStructs:      boolOr(spec.AlwaysExportStructs, true),
+		AlwaysExportEnums:        boolOr(spec.AlwaysExportEnums, true),
+	}, nil
+}
+
+// GenerateTargetManifest creates a UHT manifest JSON file for every module of spec
+func GenerateTargetManifest(spec TargetSpec) ([]byte, error) {
+	manifest, err := NewTargetManifest(spec)
+	if err != nil {
+		return nil, err
+	}
+	return marshalManifest(manifest)
+}
+
+// marshalManifest serializes a manifest the way UBT does
+func marshalManifest(manifest *UHTManifest) ([]byte, error) {
 	// Serialize to JSON with indentation
 	data, err := json.MarshalIndent(manifest, "", "  ")
 	if err != nil {
@@ -134,7 +396,19 @@ func WriteManifestFile(path string, opts GenerateManifestOptions) error {
 	if err != nil {
 		return err
 	}
+	return writeFile(path, data)
+}
+
+// WriteTargetManifestFile generates and writes a target-wide UHT manifest to a file
+func WriteTargetManifestFile(path string, spec TargetSpec) error {
+	data, err := GenerateTargetManifest(spec)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
 
+func writeFile(path string, data []byte) error {
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write manifest to %s: %w", path, err)
 	}
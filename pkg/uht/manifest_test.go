@@ -0,0 +1,181 @@
+package uht
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Manifests written by Epic's UBT for the modules of test/verification/uht/simple_enum
+const epicManifestDir = "../../test/verification/uht/simple_enum"
+
+func readEpicManifest(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join(epicManifestDir, name))
+	assert.NoError(t, err)
+	return bytes.TrimSpace(data)
+}
+
+func TestGenerateManifestMatchesEpic(t *testing.T) {
+	const root = "/Users/kareemmarch/projects/rules_unreal_engine"
+	const base = root + "/test/uht_test"
+
+	tests := []struct {
+		name     string
+		manifest string
+		opts     GenerateManifestOptions
+	}{
+		{
+			name:     "module with UENUM",
+			manifest: "TestModule.uhtmanifest",
+			opts: GenerateManifestOptions{
+				ModuleName: "TestModule",
+				BaseDir:    base,
+				OutputDir:  base + "/generated",
+				Headers:    []string{base + "/Public/TestEnum.h"},
+				UERoot:     root,
+				TargetName: "TestTarget",
+				TargetOptions: TargetOptions{
+					ExternalDependenciesFile: base + "/TestTarget.deps",
+				},
+			},
+		},
+		{
+			name:     "module with custom include paths",
+			manifest: "TestPlain.uhtmanifest",
+			opts: GenerateManifestOptions{
+				ModuleName:   "TestPlain",
+				ModuleType:   "Runtime",
+				BaseDir:      base,
+				OutputDir:    base + "/generated_plain",
+				Headers:      []string{base + "/Public/PlainClass.h"},
+				IncludePaths: []string{base + "/Public"},
+				UERoot:       root,
+				TargetName:   "TestTarget",
+				TargetOptions: TargetOptions{
+					EngineVersion:            "5.5",
+					ExternalDependenciesFile: base + "/TestTarget.deps",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := GenerateManifest(tt.opts)
+			assert.NoError(t, err)
+			assert.Equal(t, string(readEpicManifest(t, tt.manifest)), string(data))
+		})
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	for _, name := range []string{"TestModule.uhtmanifest", "TestPlain.uhtmanifest"} {
+		t.Run(name, func(t *testing.T) {
+			epic := readEpicManifest(t, name)
+			dec := json.NewDecoder(bytes.NewReader(epic))
+			dec.DisallowUnknownFields()
+			var manifest UHTManifest
+			assert.NoError(t, dec.Decode(&manifest), "UHTManifest is missing fields of the captured manifest")
+
+			data, err := json.MarshalIndent(manifest, "", "  ")
+			assert.NoError(t, err)
+			assert.Equal(t, string(epic), string(data))
+		})
+	}
+}
+
+func TestGenerateManifestOptions(t *testing.T) {
+	t.Run("every field can be set", func(t *testing.T) {
+		manifest, err := NewTargetManifest(TargetSpec{
+			UERoot: "/ue",
+			TargetOptions: TargetOptions{
+				IsGameTarget:             Bool(false),
+				ExternalDependenciesFile: "/out/Editor.deps",
+			},
+			Modules: []ModuleSpec{{
+				Name:      "Foo",
+				BaseDir:   "/ue/Foo",
+				OutputDir: "/out/Foo",
+				ModuleOptions: ModuleOptions{
+					OverrideModuleType:       "EngineEditor",
+					ClassesHeaders:           []string{"/ue/Foo/Classes/A.h"},
+					InternalHeaders:          []string{"/ue/Foo/Internal/B.h"},
+					PrivateHeaders:           []string{"/ue/Foo/Private/C.h"},
+					PublicDefines:            []string{"WITH_FOO=1"},
+					GeneratedCPPFilenameBase: "/out/Foo/Custom.gen",
+					SaveExportedHeaders:      Bool(false),
+					UHTGeneratedCodeVersion:  "V1",
+					VersePath:                "/Foo",
+					VerseScope:               "InternalAPI",
+					HasVerse:                 true,
+					VerseMountPoint:          "/Game",
+					AlwaysExportStructs:      Bool(false),
+					AlwaysExportEnums:        Bool(false),
+				},
+			}},
+		})
+		assert.NoError(t, err)
+		assert.False(t, manifest.IsGameTarget)
+		assert.Equal(t, "/out/Editor.deps", manifest.ExternalDependenciesFile)
+		assert.Equal(t, UHTModule{
+			Name:                     "Foo",
+			ModuleType:               "EngineRuntime",
+			OverrideModuleType:       "EngineEditor",
+			BaseDirectory:            "/ue/Foo",
+			IncludePaths:             []string{"/ue/Foo/Public", "/ue/Foo/Private"},
+			OutputDirectory:          "/out/Foo",
+			ClassesHeaders:           []string{"/ue/Foo/Classes/A.h"},
+			PublicHeaders:            []string{},
+			InternalHeaders:          []string{"/ue/Foo/Internal/B.h"},
+			PrivateHeaders:           []string{"/ue/Foo/Private/C.h"},
+			PublicDefines:            []string{"WITH_FOO=1"},
+			GeneratedCPPFilenameBase: "/out/Foo/Custom.gen",
+			SaveExportedHeaders:      false,
+			UHTGeneratedCodeVersion:  "V1",
+			VersePath:                "/Foo",
+			VerseScope:               "InternalAPI",
+			HasVerse:                 true,
+			VerseMountPoint:          "/Game",
+			AlwaysExportStructs:      false,
+			AlwaysExportEnums:        false,
+		}, manifest.Modules[0])
+	})
+
+	t.Run("defaults the dependencies file to the output directory", func(t *testing.T) {
+		manifest, err := NewTargetManifest(TargetSpec{
+			TargetName: "Game",
+			Modules:    []ModuleSpec{{Name: "Foo", BaseDir: "/src/Foo", OutputDir: "/out/Foo"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "/out/Foo/Game.deps", manifest.ExternalDependenciesFile)
+	})
+
+	t.Run("rejects unsupported engine versions", func(t *testing.T) {
+		_, err := NewTargetManifest(TargetSpec{
+			TargetOptions: TargetOptions{EngineVersion: "4.27"},
+			Modules:       []ModuleSpec{{Name: "Foo", BaseDir: "/src/Foo", OutputDir: "/out/Foo"}},
+		})
+		assert.ErrorContains(t, err, `unsupported engine version "4.27"`)
+	})
+
+	t.Run("options can be set in spec files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "spec.yaml")
+		assert.NoError(t, os.WriteFile(path, []byte(`isGameTarget: false
+modules:
+  - name: Foo
+    baseDir: /src/Foo
+    outputDir: /out/Foo
+    publicDefines: [WITH_FOO=1]
+    alwaysExportEnums: false
+`), 0644))
+		spec, err := ReadTargetSpec(path)
+		assert.NoError(t, err)
+		assert.Equal(t, Bool(false), spec.IsGameTarget)
+		assert.Equal(t, []string{"WITH_FOO=1"}, spec.Modules[0].PublicDefines)
+		assert.Equal(t, Bool(false), spec.Modules[0].AlwaysExportEnums)
+	})
+}
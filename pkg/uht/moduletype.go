@@ -0,0 +1,133 @@
+package uht
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModuleType is the type of a module in a UHT manifest, matching UBT's UHTModuleType
+type ModuleType string
+
+const (
+	ModuleTypeProgram          ModuleType = "Program"
+	ModuleTypeEngineRuntime    ModuleType = "EngineRuntime"
+	ModuleTypeEngineUncooked   ModuleType = "EngineUncooked"
+	ModuleTypeEngineDeveloper  ModuleType = "EngineDeveloper"
+	ModuleTypeEngineEditor     ModuleType = "EngineEditor"
+	ModuleTypeEngineThirdParty ModuleType = "EngineThirdParty"
+	ModuleTypeGameRuntime      ModuleType = "GameRuntime"
+	ModuleTypeGameUncooked     ModuleType = "GameUncooked"
+	ModuleTypeGameDeveloper    ModuleType = "GameDeveloper"
+	ModuleTypeGameEditor       ModuleType = "GameEditor"
+	ModuleTypeGameThirdParty   ModuleType = "GameThirdParty"
+)
+
+// ModuleTypes lists every ModuleType in UBT's order
+var ModuleTypes = []ModuleType{
+	ModuleTypeProgram,
+	ModuleTypeEngineRuntime,
+	ModuleTypeEngineUncooked,
+	ModuleTypeEngineDeveloper,
+	ModuleTypeEngineEditor,
+	ModuleTypeEngineThirdParty,
+	ModuleTypeGameRuntime,
+	ModuleTypeGameUncooked,
+	ModuleTypeGameDeveloper,
+	ModuleTypeGameEditor,
+	ModuleTypeGameThirdParty,
+}
+
+// hostTypeKinds maps the module host types of .uproject and .uplugin descriptors to the kind of
+// module UBT gives them, without the Engine or Game prefix. Program is never prefixed.
+var hostTypeKinds = map[string]string{
+	"Runtime":                "Runtime",
+	"RuntimeNoCommandlet":    "Runtime",
+	"RuntimeAndProgram":      "Runtime",
+	"CookedOnly":             "Runtime",
+	"ServerOnly":             "Runtime",
+	"ClientOnly":             "Runtime",
+	"ClientOnlyNoCommandlet": "Runtime",
+	"UncookedOnly":           "Uncooked",
+	"Uncooked":               "Uncooked",
+	"Developer":              "Developer",
+	"DeveloperTool":          "Developer",
+	"Editor":                 "Editor",
+	"EditorNoCommandlet":     "Editor",
+	"EditorAndProgram":       "Editor",
+	"ThirdParty":             "ThirdParty",
+	"Program":                "Program",
+}
+
+// ParseModuleType parses a ModuleType, e.g. "GameEditor", or a module host type as used in
+// .uproject and .uplugin descriptors, e.g. "Runtime" or "EditorNoCommandlet". Host types give an
+// Engine module type, or a Game module type if game is set (for project and project plugin
+// modules).
+func ParseModuleType(s string, game bool) (ModuleType, error) {
+	if isModuleType(s) {
+		return ModuleType(s), nil
+	}
+
+	kind, ok := hostTypeKinds[s]
+	if !ok {
+		return "", fmt.Errorf("invalid module type %q: valid values are %s, or a module host type such as Runtime, Developer, Editor, UncookedOnly or Program", s, joinModuleTypes(ModuleTypes))
+	}
+	if kind == "Program" {
+		return ModuleTypeProgram, nil
+	}
+	if game {
+		return ModuleType("Game" + kind), nil
+	}
+	return ModuleType("Engine" + kind), nil
+}
+
+// isModuleType reports whether s is one of ModuleTypes
+func isModuleType(s string) bool {
+	for _, t := range ModuleTypes {
+		if string(t) == s {
+			return true
+		}
+	}
+	return false
+}
+
+func joinModuleTypes(types []ModuleType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// TargetType is the type of the target a manifest is generated for, matching UBT's TargetType
+type TargetType string
+
+const (
+	TargetTypeGame    TargetType = "Game"
+	TargetTypeEditor  TargetType = "Editor"
+	TargetTypeClient  TargetType = "Client"
+	TargetTypeServer  TargetType = "Server"
+	TargetTypeProgram TargetType = "Program"
+)
+
+// TargetTypes lists every TargetType
+var TargetTypes = []TargetType{TargetTypeGame, TargetTypeEditor, TargetTypeClient, TargetTypeServer, TargetTypeProgram}
+
+// ParseTargetType parses a TargetType
+func ParseTargetType(s string) (TargetType, error) {
+	for _, t := range TargetTypes {
+		if string(t) == s {
+			return t, nil
+		}
+	}
+	names := make([]string, len(TargetTypes))
+	for i, t := range TargetTypes {
+		names[i] = string(t)
+	}
+	return "", fmt.Errorf("invalid target type %q: valid values are %s", s, strings.Join(names, ", "))
+}
+
+// IsGameTarget reports whether UBT marks manifests of this target type as game targets, which
+// it does for every target type except Program
+func (t TargetType) IsGameTarget() bool {
+	return t != TargetTypeProgram
+}
@@ -0,0 +1,91 @@
+package uht
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModuleType(t *testing.T) {
+	tests := []struct {
+		input string
+		game  bool
+		want  ModuleType
+	}{
+		{"EngineRuntime", false, ModuleTypeEngineRuntime},
+		{"GameEditor", false, ModuleTypeGameEditor},
+		{"EngineThirdParty", true, ModuleTypeEngineThirdParty},
+		{"Runtime", false, ModuleTypeEngineRuntime},
+		{"Runtime", true, ModuleTypeGameRuntime},
+		{"RuntimeNoCommandlet", true, ModuleTypeGameRuntime},
+		{"ClientOnly", false, ModuleTypeEngineRuntime},
+		{"UncookedOnly", true, ModuleTypeGameUncooked},
+		{"DeveloperTool", false, ModuleTypeEngineDeveloper},
+		{"EditorNoCommandlet", true, ModuleTypeGameEditor},
+		{"Program", false, ModuleTypeProgram},
+		{"Program", true, ModuleTypeProgram},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseModuleType(tt.input, tt.game)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := ParseModuleType("runtime", false)
+	assert.ErrorContains(t, err, `invalid module type "runtime"`)
+	assert.ErrorContains(t, err, "GameUncooked")
+}
+
+func TestTargetType(t *testing.T) {
+	for _, tt := range TargetTypes {
+		parsed, err := ParseTargetType(string(tt))
+		assert.NoError(t, err)
+		assert.Equal(t, tt != TargetTypeProgram, parsed.IsGameTarget())
+	}
+
+	_, err := ParseTargetType("Tool")
+	assert.ErrorContains(t, err, "valid values are Game, Editor, Client, Server, Program")
+}
+
+func TestManifestModuleTypes(t *testing.T) {
+	t.Run("game modules", func(t *testing.T) {
+		manifest, err := NewTargetManifest(TargetSpec{
+			TargetOptions: TargetOptions{TargetType: TargetTypeEditor},
+			Modules: []ModuleSpec{
+				{Name: "Core", BaseDir: "/ue/Core", OutputDir: "/out/Core"},
+				{Name: "MyGame", BaseDir: "/game/MyGame", OutputDir: "/out/MyGame", ModuleOptions: ModuleOptions{Game: true}},
+				{Name: "MyGameEditor", Type: "Editor", BaseDir: "/game/MyGameEditor", OutputDir: "/out/MyGameEditor", ModuleOptions: ModuleOptions{Game: true}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.True(t, manifest.IsGameTarget)
+		assert.Equal(t, "EngineRuntime", manifest.Modules[0].ModuleType)
+		assert.Equal(t, "GameRuntime", manifest.Modules[1].ModuleType)
+		assert.Equal(t, "GameEditor", manifest.Modules[2].ModuleType)
+	})
+
+	t.Run("program target", func(t *testing.T) {
+		manifest, err := NewTargetManifest(TargetSpec{
+			TargetOptions: TargetOptions{TargetType: TargetTypeProgram},
+			Modules:       []ModuleSpec{{Name: "MyTool", BaseDir: "/ue/Programs/MyTool", OutputDir: "/out/MyTool"}},
+		})
+		assert.NoError(t, err)
+		assert.False(t, manifest.IsGameTarget)
+		assert.Equal(t, "Program", manifest.Modules[0].ModuleType)
+	})
+
+	t.Run("invalid types", func(t *testing.T) {
+		_, err := NewTargetManifest(TargetSpec{
+			Modules: []ModuleSpec{{Name: "Foo", Type: "Bogus", BaseDir: "/src", OutputDir: "/out"}},
+		})
+		assert.ErrorContains(t, err, `module Foo: invalid module type "Bogus"`)
+
+		_, err = NewTargetManifest(TargetSpec{
+			TargetOptions: TargetOptions{TargetType: "Tool"},
+			Modules:       []ModuleSpec{{Name: "Foo", BaseDir: "/src", OutputDir: "/out"}},
+		})
+		assert.ErrorContains(t, err, `invalid target type "Tool"`)
+	})
+}
@@ -0,0 +1,115 @@
+package uht
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// outputLayout is how UHT of an engine version names the files it writes
+type outputLayout struct {
+	generatedHeader string // Suffix of the header written for each header, after its basename
+	genCpp          string // Suffix of the source written for each header, after its basename
+	initGenCpp      string // Suffix of the source written for each module, after its name
+
+	// splitsGenCpp is true if UHT also writes module-wide generated code split into numbered
+	// files after GeneratedCPPFilenameBase. Predicting how it splits is not supported.
+	splitsGenCpp bool
+}
+
+// outputLayouts has a layout for every version of engineVersions
+var outputLayouts = map[string]outputLayout{
+	"5.5": {
+		generatedHeader: ".generated.h",
+		genCpp:          ".gen.cpp",
+		initGenCpp:      ".init.gen.cpp",
+		splitsGenCpp:    false, // UHT 5.5 writes generated code per header only
+	},
+}
+
+// TargetOutputs are the files UHT writes for a manifest
+type TargetOutputs struct {
+	EngineVersion            string          `json:"engineVersion"`
+	ExternalDependenciesFile string          `json:"externalDependenciesFile"`
+	Modules                  []ModuleOutputs `json:"modules"`
+}
+
+// ModuleOutputs are the files UHT writes for one module
+type ModuleOutputs struct {
+	Name       string          `json:"name"`
+	InitGenCpp string          `json:"initGenCpp"`
+	Headers    []HeaderOutputs `json:"headers"`
+}
+
+// HeaderOutputs are the files UHT writes for one header
+type HeaderOutputs struct {
+	Header     string `json:"header"`
+	GeneratedH string `json:"generatedH"`
+	GenCpp     string `json:"genCpp"`
+}
+
+// PredictOutputs returns the files UHT of engineVersion (default: DefaultEngineVersion) writes
+// for manifest. Every header listed in the manifest gets a .generated.h and a .gen.cpp named
+// after its basename in the module's OutputDirectory, and every module an .init.gen.cpp. An error
+// is returned for engine versions that split module-wide generated code into more files.
+func PredictOutputs(manifest *UHTManifest, engineVersion string) (*TargetOutputs, error) {
+	engineVersion = stringOr(engineVersion, DefaultEngineVersion)
+	if err := checkEngineVersion(engineVersion); err != nil {
+		return nil, err
+	}
+	layout := outputLayouts[engineVersion]
+	if layout.splitsGenCpp {
+		return nil, fmt.Errorf("predicting the split generated code files of UHT %s is not supported", engineVersion)
+	}
+
+	outputs := &TargetOutputs{
+		EngineVersion:            engineVersion,
+		ExternalDependenciesFile: manifest.ExternalDependenciesFile,
+		Modules:                  []ModuleOutputs{},
+	}
+	for _, m := range manifest.Modules {
+		module := ModuleOutputs{
+			Name:       m.Name,
+			InitGenCpp: filepath.Join(m.OutputDirectory, m.Name+layout.initGenCpp),
+			Headers:    []HeaderOutputs{},
+		}
+		for _, list := range [][]string{m.ClassesHeaders, m.PublicHeaders, m.InternalHeaders, m.PrivateHeaders} {
+			for _, hdr := range list {
+				base := generatedBaseName(hdr)
+				module.Headers = append(module.Headers, HeaderOutputs{
+					Header:     hdr,
+					GeneratedH: filepath.Join(m.OutputDirectory, base+layout.generatedHeader),
+					GenCpp:     filepath.Join(m.OutputDirectory, base+layout.genCpp),
+				})
+			}
+		}
+		outputs.Modules = append(outputs.Modules, module)
+	}
+	return outputs, nil
+}
+
+// Files returns every generated source and header of o, module by module
+func (o *TargetOutputs) Files() []string {
+	files := []string{}
+	for _, m := range o.Modules {
+		files = append(files, m.InitGenCpp)
+		for _, h := range m.Headers {
+			files = append(files, h.GeneratedH, h.GenCpp)
+		}
+	}
+	return files
+}
+
+// WriteJSON writes the outputs as indented JSON
+func (o *TargetOutputs) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(o)
+}
+
+// generatedBaseName returns the name UHT gives the files it generates for hdr, without suffix
+func generatedBaseName(hdr string) string {
+	return strings.TrimSuffix(filepath.Base(hdr), filepath.Ext(hdr))
+}
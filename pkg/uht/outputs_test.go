@@ -0,0 +1,75 @@
+package uht
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredictOutputs(t *testing.T) {
+	manifest, err := NewTargetManifest(TargetSpec{Modules: []ModuleSpec{
+		{Name: "Core", BaseDir: "/ue/Core", OutputDir: "/out/Core", Headers: []string{"/ue/Core/Public/A.h"}},
+		{
+			Name:      "Foo",
+			BaseDir:   "/ue/Foo",
+			OutputDir: "/out/Foo",
+			Headers:   []string{"/ue/Foo/Public/Sub/B.h"},
+			ModuleOptions: ModuleOptions{
+				ClassesHeaders: []string{"/ue/Foo/Classes/C.h"},
+				PrivateHeaders: []string{"/ue/Foo/Private/D.hpp"},
+			},
+		},
+	}})
+	assert.NoError(t, err)
+
+	outputs, err := PredictOutputs(manifest, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.5", outputs.EngineVersion)
+	assert.Equal(t, ModuleOutputs{
+		Name:       "Foo",
+		InitGenCpp: "/out/Foo/Foo.init.gen.cpp",
+		Headers: []HeaderOutputs{
+			{Header: "/ue/Foo/Classes/C.h", GeneratedH: "/out/Foo/C.generated.h", GenCpp: "/out/Foo/C.gen.cpp"},
+			{Header: "/ue/Foo/Public/Sub/B.h", GeneratedH: "/out/Foo/B.generated.h", GenCpp: "/out/Foo/B.gen.cpp"},
+			{Header: "/ue/Foo/Private/D.hpp", GeneratedH: "/out/Foo/D.generated.h", GenCpp: "/out/Foo/D.gen.cpp"},
+		},
+	}, outputs.Modules[1])
+	assert.Equal(t, []string{
+		"/out/Core/Core.init.gen.cpp",
+		"/out/Core/A.generated.h",
+		"/out/Core/A.gen.cpp",
+		"/out/Foo/Foo.init.gen.cpp",
+		"/out/Foo/C.generated.h",
+		"/out/Foo/C.gen.cpp",
+		"/out/Foo/B.generated.h",
+		"/out/Foo/B.gen.cpp",
+		"/out/Foo/D.generated.h",
+		"/out/Foo/D.gen.cpp",
+	}, outputs.Files())
+
+	_, err = PredictOutputs(manifest, "5.0")
+	assert.ErrorContains(t, err, `unsupported engine version "5.0"`)
+
+	layout := outputLayouts["5.5"]
+	t.Cleanup(func() { outputLayouts["5.5"] = layout })
+	split := layout
+	split.splitsGenCpp = true
+	outputLayouts["5.5"] = split
+	_, err = PredictOutputs(manifest, "5.5")
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestPredictOutputsEpicManifest(t *testing.T) {
+	manifest, err := ReadManifest(epicManifestDir + "/TestModule.uhtmanifest")
+	assert.NoError(t, err)
+	outputs, err := PredictOutputs(manifest, "5.5")
+	assert.NoError(t, err)
+
+	const out = "/Users/kareemmarch/projects/rules_unreal_engine/test/uht_test/generated"
+	assert.Equal(t, []string{out + "/TestModule.init.gen.cpp", out + "/TestEnum.generated.h", out + "/TestEnum.gen.cpp"}, outputs.Files())
+
+	var buf bytes.Buffer
+	assert.NoError(t, outputs.WriteJSON(&buf))
+	assert.Contains(t, buf.String(), `"generatedH": "`+out+`/TestEnum.generated.h"`)
+}
@@ -0,0 +1,85 @@
+package uht
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ExecRootPlaceholder stands for the directory a relocatable manifest was generated in (the
+// Bazel execroot) at the start of its paths, until ResolveManifest replaces it
+const ExecRootPlaceholder = "${EXECROOT}"
+
+// manifestPaths returns pointers to every path in manifest
+func manifestPaths(manifest *UHTManifest) []*string {
+	paths := []*string{&manifest.RootLocalPath, &manifest.ExternalDependenciesFile}
+	for i := range manifest.Modules {
+		m := &manifest.Modules[i]
+		paths = append(paths, &m.BaseDirectory, &m.OutputDirectory, &m.GeneratedCPPFilenameBase)
+		for _, list := range [][]string{m.IncludePaths, m.ClassesHeaders, m.PublicHeaders, m.InternalHeaders, m.PrivateHeaders} {
+			for j := range list {
+				paths = append(paths, &list[j])
+			}
+		}
+	}
+	return paths
+}
+
+// relocateManifest replaces execRoot at the start of every path in manifest with
+// ExecRootPlaceholder, so the manifest no longer depends on where it was generated. Paths
+// outside execRoot are left absolute.
+func relocateManifest(manifest *UHTManifest, execRoot string) {
+	for _, p := range manifestPaths(manifest) {
+		if *p == execRoot {
+			*p = ExecRootPlaceholder
+		} else if isUnder(execRoot, *p) {
+			rel, _ := filepath.Rel(execRoot, *p)
+			*p = ExecRootPlaceholder + "/" + filepath.ToSlash(rel)
+		}
+	}
+}
+
+// IsRelocatable reports whether any path of manifest starts with ExecRootPlaceholder
+func IsRelocatable(manifest *UHTManifest) bool {
+	for _, p := range manifestPaths(manifest) {
+		if hasExecRootPlaceholder(*p) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasExecRootPlaceholder(p string) bool {
+	return p == ExecRootPlaceholder || strings.HasPrefix(p, ExecRootPlaceholder+"/")
+}
+
+// ResolveManifest replaces ExecRootPlaceholder in every path of a relocatable manifest with
+// execRoot, giving the absolute paths UHT needs. Manifests without placeholders are unchanged.
+func ResolveManifest(manifest *UHTManifest, execRoot string) error {
+	if !filepath.IsAbs(execRoot) {
+		return fmt.Errorf("execroot %s is not an absolute path", execRoot)
+	}
+	for _, p := range manifestPaths(manifest) {
+		if hasExecRootPlaceholder(*p) {
+			*p = filepath.Join(execRoot, filepath.FromSlash(strings.TrimPrefix(*p, ExecRootPlaceholder)))
+		}
+	}
+	return nil
+}
+
+// ResolveManifestFile resolves the relocatable manifest at path against execRoot and writes the
+// result to output
+func ResolveManifestFile(path, output, execRoot string) error {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return err
+	}
+	if err := ResolveManifest(manifest, execRoot); err != nil {
+		return err
+	}
+	data, err := marshalManifest(manifest)
+	if err != nil {
+		return err
+	}
+	return writeFile(output, data)
+}
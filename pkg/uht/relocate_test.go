@@ -0,0 +1,69 @@
+package uht
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelocatableManifest(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	spec := TargetSpec{
+		TargetName: "Game",
+		UERoot:     "/ue",
+		Modules: []ModuleSpec{{
+			Name:      "Foo",
+			BaseDir:   "src/Foo",
+			OutputDir: "bazel-out/bin/Foo",
+			Headers:   []string{"src/Foo/Public/A.h", filepath.Join(wd, "src/Foo/Public/B.h")},
+		}},
+		TargetOptions: TargetOptions{Relocatable: true},
+	}
+
+	manifest, err := NewTargetManifest(spec)
+	assert.NoError(t, err)
+	assert.True(t, IsRelocatable(manifest))
+	assert.Equal(t, "/ue", manifest.RootLocalPath, "paths outside the execroot stay absolute")
+	assert.Equal(t, "${EXECROOT}/bazel-out/bin/Foo/Game.deps", manifest.ExternalDependenciesFile)
+
+	foo := manifest.Modules[0]
+	assert.Equal(t, "${EXECROOT}/src/Foo", foo.BaseDirectory)
+	assert.Equal(t, []string{"${EXECROOT}/src/Foo/Public", "${EXECROOT}/src/Foo/Private"}, foo.IncludePaths)
+	assert.Equal(t, "${EXECROOT}/bazel-out/bin/Foo", foo.OutputDirectory)
+	assert.Equal(t, []string{"${EXECROOT}/src/Foo/Public/A.h", "${EXECROOT}/src/Foo/Public/B.h"}, foo.PublicHeaders)
+	assert.Equal(t, "${EXECROOT}/bazel-out/bin/Foo/Foo.gen", foo.GeneratedCPPFilenameBase)
+
+	// Resolving against the directory the manifest was generated in gives the regular manifest
+	spec.Relocatable = false
+	want, err := NewTargetManifest(spec)
+	assert.NoError(t, err)
+	assert.NoError(t, ResolveManifest(manifest, wd))
+	assert.Equal(t, want, manifest)
+	assert.False(t, IsRelocatable(manifest))
+
+	assert.ErrorContains(t, ResolveManifest(manifest, "relative"), "not an absolute path")
+}
+
+func TestResolveManifestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Foo.uhtmanifest")
+	epic := readEpicManifest(t, "TestModule.uhtmanifest")
+	manifest := &UHTManifest{}
+	assert.NoError(t, json.Unmarshal(epic, manifest))
+	relocateManifest(manifest, "/Users/kareemmarch/projects/rules_unreal_engine")
+	data, err := marshalManifest(manifest)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"BaseDirectory": "${EXECROOT}/test/uht_test"`)
+	assert.NoError(t, os.WriteFile(path, data, 0644))
+
+	output := filepath.Join(dir, "resolved.uhtmanifest")
+	assert.NoError(t, ResolveManifestFile(path, output, "/Users/kareemmarch/projects/rules_unreal_engine"))
+	resolved, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, string(epic), string(resolved))
+}
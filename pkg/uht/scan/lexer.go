@@ -0,0 +1,388 @@
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind is the kind of a Token
+type TokenKind int
+
+const (
+	Identifier TokenKind = iota
+	Number
+	String // String and character literals, including the quotes
+	Symbol // Punctuation, one character except for ::, -> and ...
+)
+
+// Token is a C++ token of a header
+type Token struct {
+	Kind       TokenKind
+	Text       string
+	Line       int
+	Comment    string   // The comments right before the token, if any
+	Conditions []string // The preprocessor conditions the token is in, outermost first
+}
+
+// conditional is an #if block the lexer is in, at its current #if, #elif or #else branch
+type conditional struct {
+	condition string   // Recorded for tokens in the branch, "" if it is always true for UHT
+	skip      bool     // The branch is invisible to UHT, e.g. #if CPP or #if 0
+	taken     bool     // An earlier branch is always visible to UHT, so later ones never are
+	earlier   []string // Conditions of the earlier branches, all false in later ones
+}
+
+// lexer splits a header into tokens. Comments are attached to the next token, and preprocessor
+// directives are applied to the tokens instead of being returned: #include paths are collected
+// and #if blocks become Token.Conditions, except blocks UHT never sees (#if 0, #if CPP).
+type lexer struct {
+	src      string
+	pos      int
+	line     int
+	comments []string
+	conds    []conditional
+	includes []string
+	tokens   []Token
+}
+
+// tokenize returns the tokens and included paths of src
+func tokenize(src string) ([]Token, []string, error) {
+	l := &lexer{src: src, line: 1}
+	atLineStart := true
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.pos++
+			l.line++
+			atLineStart = true
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			l.pos++
+			continue
+		case c == '\\' && l.peek(1) == '\n':
+			l.pos += 2
+			l.line++
+			continue
+		case c == '/' && l.peek(1) == '/':
+			l.lineComment()
+			continue
+		case c == '/' && l.peek(1) == '*':
+			if err := l.blockComment(); err != nil {
+				return nil, nil, err
+			}
+			continue
+		case c == '#' && atLineStart:
+			if err := l.directive(); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		atLineStart = false
+		if err := l.token(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(l.conds) > 0 {
+		return nil, nil, fmt.Errorf("line %d: missing #endif", l.line)
+	}
+	return l.tokens, l.includes, nil
+}
+
+func (l *lexer) peek(n int) byte {
+	if l.pos+n < len(l.src) {
+		return l.src[l.pos+n]
+	}
+	return 0
+}
+
+func (l *lexer) skipping() bool {
+	for _, c := range l.conds {
+		if c.skip {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *lexer) lineComment() {
+	start := l.pos
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+	l.comments = append(l.comments, l.src[start:l.pos])
+}
+
+func (l *lexer) blockComment() error {
+	start, startLine := l.pos, l.line
+	end := strings.Index(l.src[l.pos+2:], "*/")
+	if end < 0 {
+		return fmt.Errorf("line %d: unterminated comment", startLine)
+	}
+	l.pos += 2 + end + 2
+	text := l.src[start:l.pos]
+	l.line += strings.Count(text, "\n")
+	l.comments = append(l.comments, text)
+	return nil
+}
+
+// directive handles a preprocessor line, including its continuation lines
+func (l *lexer) directive() error {
+	startLine := l.line
+	var b strings.Builder
+	l.pos++ // #
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\\' && l.peek(1) == '\n' {
+			b.WriteByte(' ')
+			l.pos += 2
+			l.line++
+			continue
+		}
+		if c == '\\' && l.peek(1) == '\r' && l.peek(2) == '\n' {
+			b.WriteByte(' ')
+			l.pos += 3
+			l.line++
+			continue
+		}
+		if c == '\n' {
+			break
+		}
+		if c == '/' && l.peek(1) == '/' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			break
+		}
+		if c == '/' && l.peek(1) == '*' {
+			end := strings.Index(l.src[l.pos+2:], "*/")
+			if end < 0 {
+				return fmt.Errorf("line %d: unterminated comment", l.line)
+			}
+			l.line += strings.Count(l.src[l.pos:l.pos+2+end], "\n")
+			l.pos += 2 + end + 2
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteByte(c)
+		l.pos++
+	}
+	// Comments before a directive don't document the next token
+	l.comments = nil
+
+	name, arg, _ := strings.Cut(strings.TrimSpace(b.String()), " ")
+	arg = strings.Join(strings.Fields(arg), " ")
+	switch name {
+	case "if":
+		l.conds = append(l.conds, conditional{})
+		l.conds[len(l.conds)-1].branch(arg)
+	case "ifdef":
+		l.conds = append(l.conds, conditional{})
+		l.conds[len(l.conds)-1].branch("defined(" + arg + ")")
+	case "ifndef":
+		l.conds = append(l.conds, conditional{})
+		l.conds[len(l.conds)-1].branch("!defined(" + arg + ")")
+	case "elif":
+		if len(l.conds) == 0 {
+			return fmt.Errorf("line %d: #elif without #if", startLine)
+		}
+		l.conds[len(l.conds)-1].branch(arg)
+	case "else":
+		if len(l.conds) == 0 {
+			return fmt.Errorf("line %d: #else without #if", startLine)
+		}
+		l.conds[len(l.conds)-1].branch("1")
+	case "endif":
+		if len(l.conds) == 0 {
+			return fmt.Errorf("line %d: #endif without #if", startLine)
+		}
+		l.conds = l.conds[:len(l.conds)-1]
+	case "include":
+		if !l.skipping() {
+			l.includes = append(l.includes, strings.Trim(arg, `"<>`))
+		}
+	}
+	return nil
+}
+
+// branch moves c to the next branch of its block, which is taken if cond holds and none of the
+// earlier branches did. Conditions UHT evaluates itself (0, 1, CPP, !CPP) are not recorded.
+func (c *conditional) branch(cond string) {
+	if c.taken {
+		c.skip = true
+		return
+	}
+
+	var conds []string
+	for _, earlier := range c.earlier {
+		conds = append(conds, negate(earlier))
+	}
+	switch cond {
+	case "0", "CPP":
+		c.skip = true
+	case "1", "!CPP":
+		c.skip = false
+		c.taken = true
+	default:
+		c.skip = false
+		conds = append(conds, cond)
+		c.earlier = append(c.earlier, cond)
+	}
+	c.condition = and(conds)
+}
+
+// and returns the conjunction of conds, "" if there are none
+func and(conds []string) string {
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	for i, cond := range conds {
+		if strings.ContainsAny(cond, " &|") && !strings.HasPrefix(cond, "!(") {
+			conds[i] = "(" + cond + ")"
+		}
+	}
+	return strings.Join(conds, " && ")
+}
+
+func negate(cond string) string {
+	switch {
+	case cond == "":
+		return ""
+	case strings.HasPrefix(cond, "!") && !strings.ContainsAny(cond, " &|"):
+		return cond[1:]
+	case strings.ContainsAny(cond, " &|"):
+		return "!(" + cond + ")"
+	default:
+		return "!" + cond
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// token reads the token at l.pos
+func (l *lexer) token() error {
+	start, line := l.pos, l.line
+	c := l.src[l.pos]
+	var kind TokenKind
+	switch {
+	case isIdentStart(c):
+		kind = Identifier
+		for l.pos < len(l.src) && isIdentChar(l.src[l.pos]) {
+			l.pos++
+		}
+		prefix := l.src[start:l.pos]
+		switch {
+		case l.pos < len(l.src) && l.src[l.pos] == '"' && strings.HasSuffix(prefix, "R") && isStringPrefix(prefix[:len(prefix)-1]):
+			kind = String
+			if err := l.rawString(); err != nil {
+				return err
+			}
+		case l.pos < len(l.src) && (l.src[l.pos] == '"' || l.src[l.pos] == '\'') && isStringPrefix(prefix) && prefix != "":
+			kind = String
+			if err := l.quoted(l.src[l.pos]); err != nil {
+				return err
+			}
+		}
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		kind = Number
+		for l.pos < len(l.src) {
+			d := l.src[l.pos]
+			if isIdentChar(d) || d == '.' || d == '\'' {
+				l.pos++
+			} else if (d == '+' || d == '-') && strings.ContainsRune("eEpP", rune(l.src[l.pos-1])) {
+				l.pos++
+			} else {
+				break
+			}
+		}
+	case c == '"' || c == '\'':
+		kind = String
+		if err := l.quoted(c); err != nil {
+			return err
+		}
+	default:
+		kind = Symbol
+		l.pos++
+		for _, s := range []string{"::", "->", "..."} {
+			if strings.HasPrefix(l.src[start:], s) {
+				l.pos = start + len(s)
+				break
+			}
+		}
+	}
+
+	if l.skipping() {
+		l.comments = nil
+		return nil
+	}
+	tok := Token{Kind: kind, Text: l.src[start:l.pos], Line: line}
+	if len(l.comments) > 0 {
+		tok.Comment = strings.Join(l.comments, "\n")
+		l.comments = nil
+	}
+	for _, c := range l.conds {
+		if c.condition != "" {
+			tok.Conditions = append(tok.Conditions, c.condition)
+		}
+	}
+	l.tokens = append(l.tokens, tok)
+	return nil
+}
+
+// isStringPrefix reports whether s is an encoding prefix of string literals, e.g. the L of L"x"
+func isStringPrefix(s string) bool {
+	switch s {
+	case "", "L", "u", "U", "u8":
+		return true
+	}
+	return false
+}
+
+// rawString reads a raw string literal, R"delim(...)delim", starting at its opening quote
+func (l *lexer) rawString() error {
+	line := l.line
+	open := l.pos
+	paren := strings.IndexByte(l.src[open:], '(')
+	if paren < 0 {
+		return fmt.Errorf("line %d: invalid raw string literal", line)
+	}
+	delim := l.src[open+1 : open+paren]
+	end := strings.Index(l.src[open+paren:], ")"+delim+"\"")
+	if end < 0 {
+		return fmt.Errorf("line %d: unterminated raw string literal", line)
+	}
+	l.pos = open + paren + end + len(delim) + 2
+	l.line += strings.Count(l.src[open:l.pos], "\n")
+	return nil
+}
+
+// quoted reads a string or character literal starting at l.pos
+func (l *lexer) quoted(quote byte) error {
+	line := l.line
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '\n':
+			return fmt.Errorf("line %d: unterminated literal", line)
+		case quote:
+			l.pos++
+			return nil
+		}
+		l.pos++
+	}
+	return fmt.Errorf("line %d: unterminated literal", line)
+}
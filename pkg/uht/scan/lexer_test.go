@@ -0,0 +1,54 @@
+package scan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizeConditionals(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		want map[string][]string // Visible identifiers and their conditions
+	}{
+		"if 1 elif else": {
+			src:  "#if 1\nA\n#elif WITH_EDITOR\nB\n#else\nC\n#endif\n",
+			want: map[string][]string{"A": nil},
+		},
+		"if 0 elif 1 else": {
+			src:  "#if 0\nA\n#elif 1\nB\n#else\nC\n#endif\n",
+			want: map[string][]string{"B": nil},
+		},
+		"if 0 elif else": {
+			src:  "#if 0\nA\n#elif WITH_EDITOR\nB\n#else\nC\n#endif\n",
+			want: map[string][]string{"B": {"WITH_EDITOR"}, "C": {"!WITH_EDITOR"}},
+		},
+		"else after several elifs": {
+			src: "#if A_ON\nA\n#elif B_ON || C_ON\nB\n#elif CPP\nC\n#else\nD\n#endif\n",
+			want: map[string][]string{
+				"A": {"A_ON"},
+				"B": {"!A_ON && (B_ON || C_ON)"},
+				"D": {"!A_ON && !(B_ON || C_ON)"},
+			},
+		},
+		"elif after ifdef": {
+			src:  "#ifdef FOO\nA\n#elif !CPP\nB\n#elif BAR\nC\n#endif\n",
+			want: map[string][]string{"A": {"defined(FOO)"}, "B": {"!defined(FOO)"}},
+		},
+		"nested": {
+			src:  "#if 0\n#if 1\nA\n#else\nB\n#endif\n#else\n#if X\nC\n#endif\n#endif\n",
+			want: map[string][]string{"C": {"X"}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tokens, _, err := tokenize(tt.src)
+			assert.NoError(t, err)
+			got := map[string][]string{}
+			for _, tok := range tokens {
+				got[tok.Text] = tok.Conditions
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
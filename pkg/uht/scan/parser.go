@@ -0,0 +1,872 @@
+package scan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// generatedBodyMacros are GENERATED_BODY and its legacy forms
+var generatedBodyMacros = map[string]bool{
+	"GENERATED_BODY":            true,
+	"GENERATED_UCLASS_BODY":     true,
+	"GENERATED_USTRUCT_BODY":    true,
+	"GENERATED_UINTERFACE_BODY": true,
+	"GENERATED_IINTERFACE_BODY": true,
+}
+
+// functionPrefixes are the keywords and macros before a function's return type that are not
+// part of it
+var functionPrefixes = map[string]bool{
+	"inline":          true,
+	"explicit":        true,
+	"FORCEINLINE":     true,
+	"FORCENOINLINE":   true,
+	"UE_NODISCARD":    true,
+	"virtual":         true,
+	"static":          true,
+	"constexpr":       true,
+	"UE_API":          true,
+	"UE_DEPRECATED":   true,
+	"UE_INTERNAL":     true,
+	"UE_EXPERIMENTAL": true,
+}
+
+// parser finds the reflected declarations in the tokens of a header. It only understands as
+// much C++ as it needs to: the declarations following reflection macros, and enough nesting to
+// skip everything else.
+type parser struct {
+	tokens []Token
+	pos    int
+	header *Header
+
+	// interfaces maps the native class name of every UINTERFACE, e.g. IFoo for UFoo, to it
+	interfaces map[string]*Class
+}
+
+func (p *parser) peek() *Token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+// peekIs reports whether the token n tokens ahead is text
+func (p *parser) peekIs(n int, text string) bool {
+	return p.pos+n < len(p.tokens) && p.tokens[p.pos+n].Text == text
+}
+
+func (p *parser) next() (*Token, error) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, p.eof()
+	}
+	p.pos++
+	return tok, nil
+}
+
+func (p *parser) eof() error {
+	line := 0
+	if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].Line
+	}
+	return fmt.Errorf("line %d: unexpected end of file", line)
+}
+
+func (p *parser) expect(text string) (*Token, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Text != text {
+		return nil, fmt.Errorf("line %d: expected %q, got %q", tok.Line, text, tok.Text)
+	}
+	return tok, nil
+}
+
+func (p *parser) expectIdentifier(what string) (*Token, error) {
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Kind != Identifier {
+		return nil, fmt.Errorf("line %d: expected %s, got %q", tok.Line, what, tok.Text)
+	}
+	return tok, nil
+}
+
+// skipBalanced skips the bracketed tokens starting at the opening bracket at p.pos
+func (p *parser) skipBalanced() error {
+	open := p.tokens[p.pos]
+	var close string
+	switch open.Text {
+	case "(":
+		close = ")"
+	case "[":
+		close = "]"
+	case "{":
+		close = "}"
+	case "<":
+		close = ">"
+	}
+	depth := 0
+	for ; p.pos < len(p.tokens); p.pos++ {
+		switch p.tokens[p.pos].Text {
+		case open.Text:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("line %d: %q is never closed", open.Line, open.Text)
+}
+
+func (p *parser) parse() error {
+	p.interfaces = map[string]*Class{}
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		if tok.Kind != Identifier {
+			p.pos++
+			continue
+		}
+		switch tok.Text {
+		case "UCLASS", "USTRUCT", "UINTERFACE":
+			c, err := p.parseClass()
+			if err != nil {
+				return err
+			}
+			switch tok.Text {
+			case "UCLASS":
+				p.header.Classes = append(p.header.Classes, c)
+			case "USTRUCT":
+				p.header.Structs = append(p.header.Structs, c)
+			default:
+				p.header.Interfaces = append(p.header.Interfaces, c)
+				p.interfaces["I"+strings.TrimPrefix(c.Name, "U")] = c
+			}
+		case "UENUM":
+			e, err := p.parseEnum()
+			if err != nil {
+				return err
+			}
+			p.header.Enums = append(p.header.Enums, e)
+		case "template":
+			p.pos++
+			if p.peekIs(0, "<") {
+				if err := p.skipBalanced(); err != nil {
+					return err
+				}
+			}
+		case "class":
+			if err := p.parseNativeInterface(); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+	return nil
+}
+
+// decl starts the declaration of the reflection macro at p.pos, reading its specifiers
+func (p *parser) decl() (Decl, error) {
+	macro := p.tokens[p.pos]
+	p.pos++
+	d := Decl{Line: macro.Line, Comment: macro.Comment, Conditions: macro.Conditions}
+	specs, meta, err := p.parseSpecifiers(macro.Text == "UMETA")
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", macro.Text, err)
+	}
+	d.Specifiers, d.Meta = specs, meta
+	return d, nil
+}
+
+// parseSpecifiers reads the parenthesized specifiers of a reflection macro. meta=(...) and, with
+// allMeta, every specifier become metadata.
+func (p *parser) parseSpecifiers(allMeta bool) ([]Specifier, []MetaData, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, nil, err
+	}
+	var specs []Specifier
+	var meta []MetaData
+	for {
+		if p.peekIs(0, ")") {
+			p.pos++
+			return specs, meta, nil
+		}
+		key, err := p.specifierTokens()
+		if err != nil {
+			return nil, nil, err
+		}
+		spec := Specifier{Key: joinTokens(key)}
+		if p.peekIs(0, "=") {
+			p.pos++
+			if p.peekIs(0, "(") {
+				values, err := p.parseList()
+				if err != nil {
+					return nil, nil, err
+				}
+				if strings.EqualFold(spec.Key, "meta") {
+					for _, v := range values {
+						k, val, _ := strings.Cut(v, "=")
+						meta = append(meta, MetaData{Key: strings.TrimSpace(k), Value: unquote(strings.TrimSpace(val))})
+					}
+				} else {
+					spec.Values = values
+					for i := range spec.Values {
+						spec.Values[i] = unquote(spec.Values[i])
+					}
+				}
+			} else {
+				value, err := p.specifierTokens()
+				if err != nil {
+					return nil, nil, err
+				}
+				spec.Value = unquote(joinTokens(value))
+			}
+		}
+		if allMeta {
+			meta = append(meta, MetaData{Key: spec.Key, Value: spec.Value})
+		} else if !strings.EqualFold(spec.Key, "meta") {
+			specs = append(specs, spec)
+		}
+		if p.peekIs(0, ",") {
+			p.pos++
+		}
+	}
+}
+
+// specifierTokens returns the tokens up to the next =, comma or closing parenthesis
+func (p *parser) specifierTokens() ([]Token, error) {
+	start := p.pos
+	for p.pos < len(p.tokens) {
+		switch p.tokens[p.pos].Text {
+		case "=", ",", ")":
+			return p.tokens[start:p.pos], nil
+		case "(":
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.pos++
+	}
+	return nil, p.eof()
+}
+
+// parseList reads a parenthesized, comma-separated list, returning each element as text
+func (p *parser) parseList() ([]string, error) {
+	p.pos++ // (
+	var values []string
+	start := p.pos
+	for p.pos < len(p.tokens) {
+		switch p.tokens[p.pos].Text {
+		case "(":
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+			continue
+		case ",", ")":
+			if p.pos > start {
+				values = append(values, joinTokens(p.tokens[start:p.pos]))
+			}
+			if p.tokens[p.pos].Text == ")" {
+				p.pos++
+				return values, nil
+			}
+			start = p.pos + 1
+		}
+		p.pos++
+	}
+	return nil, p.eof()
+}
+
+// parseClass reads a UCLASS, USTRUCT or UINTERFACE and the class or struct it annotates
+func (p *parser) parseClass() (*Class, error) {
+	d, err := p.decl()
+	if err != nil {
+		return nil, err
+	}
+	keyword, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if keyword.Text != "class" && keyword.Text != "struct" {
+		return nil, fmt.Errorf("line %d: expected class or struct after reflection macro, got %q", keyword.Line, keyword.Text)
+	}
+	c := &Class{Decl: d, Keyword: keyword.Text, Properties: []*Property{}, Functions: []*Function{}}
+	if err := p.parseClassHead(c); err != nil {
+		return nil, err
+	}
+	return c, p.parseClassBody(c)
+}
+
+// parseClassHead reads the name and bases of a class, up to and including its opening brace
+func (p *parser) parseClassHead(c *Class) error {
+	for {
+		tok, err := p.expectIdentifier("class name")
+		if err != nil {
+			return err
+		}
+		if p.peekIs(0, "(") {
+			// alignas(16), MS_ALIGN(16), UE_DEPRECATED(...)
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+			continue
+		}
+		if strings.HasSuffix(tok.Text, "_API") && p.peek() != nil && p.peek().Kind == Identifier {
+			c.APIMacro = tok.Text
+			continue
+		}
+		c.Name = tok.Text
+		break
+	}
+	if p.peekIs(0, "final") {
+		c.Final = true
+		p.pos++
+	}
+
+	if p.peekIs(0, ":") {
+		p.pos++
+		var base []Token
+		for {
+			tok, err := p.next()
+			if err != nil {
+				return err
+			}
+			switch tok.Text {
+			case ",", "{":
+				if len(base) > 0 {
+					c.Bases = append(c.Bases, joinTokens(base))
+				}
+				base = nil
+				if tok.Text == "{" {
+					return nil
+				}
+			case "public", "protected", "private", "virtual":
+			default:
+				base = append(base, *tok)
+			}
+		}
+	}
+	_, err := p.expect("{")
+	return err
+}
+
+// parseClassBody reads the members of a class after its opening brace, up to and including the
+// closing brace
+func (p *parser) parseClassBody(c *Class) error {
+	access := "private"
+	if c.Keyword == "struct" {
+		access = "public"
+	}
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		switch {
+		case tok.Text == "}":
+			p.pos++
+			if p.peekIs(0, ";") {
+				p.pos++
+			}
+			return nil
+		case tok.Text == "{" || tok.Text == "(" || tok.Text == "[":
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+		case (tok.Text == "public" || tok.Text == "protected" || tok.Text == "private") && p.peekIs(1, ":"):
+			access = tok.Text
+			p.pos += 2
+		case tok.Text == "UPROPERTY":
+			prop, err := p.parseProperty(access)
+			if err != nil {
+				return err
+			}
+			c.Properties = append(c.Properties, prop)
+		case tok.Text == "UFUNCTION":
+			fn, err := p.parseFunction(access)
+			if err != nil {
+				return err
+			}
+			c.Functions = append(c.Functions, fn)
+		case generatedBodyMacros[tok.Text]:
+			c.GeneratedBody = &GeneratedBody{Macro: tok.Text, Line: tok.Line, Access: access}
+			p.pos++
+			if p.peekIs(0, "(") {
+				if err := p.skipBalanced(); err != nil {
+					return err
+				}
+			}
+		default:
+			p.pos++
+		}
+	}
+	return p.eof()
+}
+
+// parseNativeInterface reads the I-prefixed class of a UINTERFACE declared earlier in the
+// header. Other classes are skipped.
+func (p *parser) parseNativeInterface() error {
+	start := p.pos
+	p.pos++ // class
+	c := &Class{Keyword: "class", Properties: []*Property{}, Functions: []*Function{}}
+	// Lookahead: only classes with a body whose name belongs to an interface
+	for p.pos < len(p.tokens) && p.tokens[p.pos].Kind == Identifier {
+		p.pos++
+	}
+	if p.pos == start+1 || (!p.peekIs(0, ":") && !p.peekIs(0, "{") && !p.peekIs(0, "final")) {
+		return nil
+	}
+	name := p.tokens[p.pos-1].Text
+	iface, ok := p.interfaces[name]
+	if !ok || iface.Native != nil {
+		return nil
+	}
+
+	p.pos = start + 1
+	tok := p.tokens[start]
+	c.Decl = Decl{Line: tok.Line, Comment: tok.Comment, Conditions: tok.Conditions}
+	if err := p.parseClassHead(c); err != nil {
+		return err
+	}
+	iface.Native = c
+	return p.parseClassBody(c)
+}
+
+// declarationTokens returns the tokens up to the semicolon ending a member declaration,
+// skipping the semicolon
+func (p *parser) declarationTokens() ([]Token, error) {
+	start := p.pos
+	for p.pos < len(p.tokens) {
+		switch p.tokens[p.pos].Text {
+		case ";":
+			p.pos++
+			return p.tokens[start : p.pos-1], nil
+		case "(", "{", "[":
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.pos++
+	}
+	return nil, p.eof()
+}
+
+// parseProperty reads a UPROPERTY and the member variable it annotates
+func (p *parser) parseProperty(access string) (*Property, error) {
+	d, err := p.decl()
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := p.declarationTokens()
+	if err != nil {
+		return nil, err
+	}
+	prop := &Property{Decl: d, Access: access}
+
+	// The declarator ends at the first of an array dimension, bit field width or initializer
+	end := len(tokens)
+	depth := 0
+	for i, tok := range tokens {
+		switch tok.Text {
+		case "<", "(":
+			depth++
+		case ">", ")":
+			depth--
+		case "[", ":", "=", "{":
+			if depth == 0 && i < end {
+				end = i
+			}
+		}
+		if end != len(tokens) {
+			break
+		}
+	}
+	if end == 0 || tokens[end-1].Kind != Identifier {
+		line := d.Line
+		if len(tokens) > 0 {
+			line = tokens[0].Line
+		}
+		return nil, fmt.Errorf("line %d: expected property declaration after UPROPERTY", line)
+	}
+	prop.Name = tokens[end-1].Text
+	prop.Type = joinTokens(tokens[:end-1])
+
+	rest := tokens[end:]
+	for len(rest) > 0 {
+		switch rest[0].Text {
+		case "[":
+			close := indexOf(rest, "]")
+			prop.ArrayDim = joinTokens(rest[1:close])
+			rest = rest[close+1:]
+		case ":":
+			stop := len(rest)
+			if i := indexOf(rest, "="); i >= 0 {
+				stop = i
+			} else if i := indexOf(rest, "{"); i >= 0 {
+				stop = i
+			}
+			prop.BitField = joinTokens(rest[1:stop])
+			rest = rest[stop:]
+		case "=":
+			prop.Default = joinTokens(rest[1:])
+			rest = nil
+		default: // Brace initializer
+			prop.Default = joinTokens(rest)
+			rest = nil
+		}
+	}
+	return prop, nil
+}
+
+// parseFunction reads a UFUNCTION and the member function it annotates
+func (p *parser) parseFunction(access string) (*Function, error) {
+	d, err := p.decl()
+	if err != nil {
+		return nil, err
+	}
+	fn := &Function{Decl: d, Access: access, Params: []*Param{}}
+
+	// Return type and name, up to the parameter list
+	var ret []Token
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Kind == Identifier && p.peekIs(0, "(") {
+			if isMacroName(tok.Text) {
+				// UE_DEPRECATED(5.0, "...") and similar
+				if err := p.skipBalanced(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			fn.Name = tok.Text
+			break
+		}
+		switch {
+		case tok.Text == "virtual":
+			fn.Virtual = true
+		case tok.Text == "static":
+			fn.Static = true
+		case functionPrefixes[tok.Text], strings.HasSuffix(tok.Text, "_API"):
+		case tok.Text == "<":
+			// Template arguments of the return type, which may contain parentheses
+			p.pos--
+			start := p.pos
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+			ret = append(ret, p.tokens[start:p.pos]...)
+		case tok.Text == ";" || tok.Text == "{" || tok.Text == "}":
+			return nil, fmt.Errorf("line %d: expected function declaration after UFUNCTION", tok.Line)
+		default:
+			ret = append(ret, *tok)
+		}
+	}
+	fn.ReturnType = joinTokens(ret)
+
+	params, err := p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+	fn.Params = params
+
+	// Qualifiers, up to the end of the declaration or definition
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.Text == ";":
+			return fn, nil
+		case tok.Text == "{":
+			p.pos--
+			return fn, p.skipBalanced()
+		case tok.Text == "const":
+			fn.Const = true
+		case tok.Text == "=":
+			value, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			fn.Qualifiers = append(fn.Qualifiers, "= "+value.Text)
+		case tok.Kind == Identifier && p.peekIs(0, "("):
+			// PURE_VIRTUAL(Name, ...)
+			fn.Qualifiers = append(fn.Qualifiers, tok.Text)
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+		default:
+			fn.Qualifiers = append(fn.Qualifiers, tok.Text)
+		}
+	}
+}
+
+// parseParams reads a parameter list starting at its opening parenthesis
+func (p *parser) parseParams() ([]*Param, error) {
+	start := p.pos
+	if err := p.skipBalanced(); err != nil {
+		return nil, err
+	}
+	tokens := p.tokens[start+1 : p.pos-1]
+
+	params := []*Param{}
+	for _, param := range splitTopLevel(tokens, ",") {
+		if len(param) == 0 || (len(param) == 1 && param[0].Text == "void") {
+			continue
+		}
+		decl := param
+		var def string
+		if i := indexTopLevel(param, "="); i >= 0 {
+			decl, def = param[:i], joinTokens(param[i+1:])
+		}
+		pr := &Param{Default: def}
+		last := len(decl) - 1
+		if last > 0 && decl[last].Kind == Identifier && !isTypeKeyword(decl[last].Text) && decl[last-1].Text != "::" {
+			pr.Name = decl[last].Text
+			decl = decl[:last]
+		}
+		pr.Type = joinTokens(decl)
+		params = append(params, pr)
+	}
+	return params, nil
+}
+
+// parseEnum reads a UENUM and the enum it annotates
+func (p *parser) parseEnum() (*Enum, error) {
+	d, err := p.decl()
+	if err != nil {
+		return nil, err
+	}
+	e := &Enum{Decl: d, Values: []*EnumValue{}}
+
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.Text {
+	case "namespace":
+		ns, err := p.expectIdentifier("namespace name")
+		if err != nil {
+			return nil, err
+		}
+		e.Form, e.Namespace, e.Name = EnumNamespaced, ns.Text, ns.Text
+		if _, err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("enum"); err != nil {
+			return nil, err
+		}
+		if _, err := p.expectIdentifier("enum name"); err != nil {
+			return nil, err
+		}
+	case "enum":
+		e.Form = EnumRegular
+		if p.peekIs(0, "class") || p.peekIs(0, "struct") {
+			e.Form = EnumClass
+			p.pos++
+		}
+		name, err := p.expectIdentifier("enum name")
+		if err != nil {
+			return nil, err
+		}
+		e.Name = name.Text
+	default:
+		return nil, fmt.Errorf("line %d: expected enum or namespace after UENUM, got %q", tok.Line, tok.Text)
+	}
+
+	if p.peekIs(0, ":") {
+		p.pos++
+		var typ []Token
+		for !p.peekIs(0, "{") {
+			tok, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			typ = append(typ, *tok)
+		}
+		e.UnderlyingType = joinTokens(typ)
+	}
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.parseEnumValues(e); err != nil {
+		return nil, err
+	}
+	if p.peekIs(0, ";") {
+		p.pos++
+	}
+
+	if e.Form == EnumNamespaced {
+		// Skip the rest of the namespace
+		depth := 1
+		for depth > 0 {
+			tok, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			switch tok.Text {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			}
+		}
+	}
+	return e, nil
+}
+
+// parseEnumValues reads the values of an enum after its opening brace, up to and including the
+// closing brace
+func (p *parser) parseEnumValues(e *Enum) error {
+	for {
+		tok, err := p.next()
+		if err != nil {
+			return err
+		}
+		if tok.Text == "}" {
+			return nil
+		}
+		if tok.Kind != Identifier {
+			return fmt.Errorf("line %d: expected enum value, got %q", tok.Line, tok.Text)
+		}
+		v := &EnumValue{Name: tok.Text, Line: tok.Line, Comment: tok.Comment, Conditions: tok.Conditions}
+
+		var value []Token
+	values:
+		for {
+			next := p.peek()
+			if next == nil {
+				return p.eof()
+			}
+			switch {
+			case next.Text == "," || next.Text == "}":
+				if next.Text == "," {
+					p.pos++
+				}
+				break values
+			case next.Text == "UMETA":
+				d, err := p.decl()
+				if err != nil {
+					return err
+				}
+				v.Meta = d.Meta
+			case next.Text == "=":
+				p.pos++
+			case next.Text == "(":
+				start := p.pos
+				if err := p.skipBalanced(); err != nil {
+					return err
+				}
+				value = append(value, p.tokens[start:p.pos]...)
+			default:
+				value = append(value, *next)
+				p.pos++
+			}
+		}
+		v.Value = joinTokens(value)
+		e.Values = append(e.Values, v)
+	}
+}
+
+// isMacroName reports whether name looks like a macro (all caps), as opposed to a function name
+func isMacroName(name string) bool {
+	return strings.ToUpper(name) == name && strings.ContainsAny(name, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+}
+
+// isTypeKeyword reports whether name is a keyword that can end a type, so it is not a name
+func isTypeKeyword(name string) bool {
+	switch name {
+	case "int", "char", "short", "long", "float", "double", "bool", "signed", "unsigned", "const", "void":
+		return true
+	}
+	return false
+}
+
+func indexOf(tokens []Token, text string) int {
+	for i, tok := range tokens {
+		if tok.Text == text {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexTopLevel returns the index of the first text in tokens outside any brackets, or -1
+func indexTopLevel(tokens []Token, text string) int {
+	depth := 0
+	for i, tok := range tokens {
+		switch tok.Text {
+		case "(", "<", "[", "{":
+			depth++
+		case ")", ">", "]", "}":
+			depth--
+		case text:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits tokens at every sep outside any brackets
+func splitTopLevel(tokens []Token, sep string) [][]Token {
+	var parts [][]Token
+	for {
+		i := indexTopLevel(tokens, sep)
+		if i < 0 {
+			return append(parts, tokens)
+		}
+		parts = append(parts, tokens[:i])
+		tokens = tokens[i+1:]
+	}
+}
+
+// joinTokens turns tokens back into text, with spaces only between words and after commas,
+// e.g. "const TArray<FString, FDefaultAllocator>&"
+func joinTokens(tokens []Token) string {
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 {
+			prev := tokens[i-1]
+			if prev.Text == "," || (prev.Kind != Symbol && tok.Kind != Symbol) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(tok.Text)
+	}
+	return b.String()
+}
+
+// unquote removes the quotes of a string literal and resolves its escapes. Other text is
+// returned as is.
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		if s[i] == '\\' && i+1 < len(s)-1 {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+// Package scan reads Unreal Engine headers the way UnrealHeaderTool does: it finds the UCLASS,
+// USTRUCT, UENUM and UINTERFACE types of a header with their UPROPERTY and UFUNCTION members,
+// specifiers, metadata and line numbers, without needing a full C++ parser.
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Header is everything UHT reflects in one header
+type Header struct {
+	Path       string   `json:"path"`
+	Includes   []string `json:"includes"`
+	Classes    []*Class `json:"classes"`
+	Structs    []*Class `json:"structs"`
+	Enums      []*Enum  `json:"enums"`
+	Interfaces []*Class `json:"interfaces"`
+}
+
+// Specifier is a specifier of a reflection macro, e.g. BlueprintType, Category="Foo" or
+// HideCategories=(A, B). Values holds the values of parenthesized lists.
+type Specifier struct {
+	Key    string   `json:"key"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+// MetaData is a metadata entry, from meta=(...) of a reflection macro or from UMETA(...)
+type MetaData struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Decl holds what every reflected declaration has
+type Decl struct {
+	Name       string      `json:"name"`
+	Line       int         `json:"line"`
+	Specifiers []Specifier `json:"specifiers,omitempty"`
+	Meta       []MetaData  `json:"meta,omitempty"`
+	Comment    string      `json:"comment,omitempty"`    // The comment before the reflection macro
+	Conditions []string    `json:"conditions,omitempty"` // Preprocessor conditions, e.g. WITH_EDITORONLY_DATA
+}
+
+// Class is a UCLASS, USTRUCT or UINTERFACE
+type Class struct {
+	Decl
+	Keyword  string   `json:"keyword"`            // class or struct
+	APIMacro string   `json:"apiMacro,omitempty"` // e.g. COREUOBJECT_API
+	Final    bool     `json:"final,omitempty"`
+	Bases    []string `json:"bases,omitempty"` // Base classes, the super class first
+
+	GeneratedBody *GeneratedBody `json:"generatedBody,omitempty"`
+	Properties    []*Property    `json:"properties"`
+	Functions     []*Function    `json:"functions"`
+
+	// Native is the I-prefixed class that declares the functions of a UINTERFACE
+	Native *Class `json:"native,omitempty"`
+}
+
+// GeneratedBody is a GENERATED_BODY macro or one of its legacy forms, e.g. GENERATED_UCLASS_BODY
+type GeneratedBody struct {
+	Macro  string `json:"macro"`
+	Line   int    `json:"line"`
+	Access string `json:"access"` // The access specifier in effect after the macro
+}
+
+// Enum is a UENUM
+type Enum struct {
+	Decl
+	Form           EnumForm     `json:"form"`
+	Namespace      string       `json:"namespace,omitempty"` // For Namespaced enums, e.g. ENetRole
+	UnderlyingType string       `json:"underlyingType,omitempty"`
+	Values         []*EnumValue `json:"values"`
+}
+
+// EnumForm is how an enum is declared
+type EnumForm string
+
+const (
+	EnumClass      EnumForm = "EnumClass"  // enum class EFoo
+	EnumNamespaced EnumForm = "Namespaced" // namespace EFoo { enum Type { ... }; }
+	EnumRegular    EnumForm = "Regular"    // enum EFoo
+)
+
+// EnumValue is a value of a UENUM
+type EnumValue struct {
+	Name       string     `json:"name"`
+	Line       int        `json:"line"`
+	Value      string     `json:"value,omitempty"` // The initializer, if any
+	Meta       []MetaData `json:"meta,omitempty"`  // From UMETA(...)
+	Comment    string     `json:"comment,omitempty"`
+	Conditions []string   `json:"conditions,omitempty"`
+}
+
+// Property is a UPROPERTY
+type Property struct {
+	Decl
+	Type     string `json:"type"`
+	ArrayDim string `json:"arrayDim,omitempty"` // e.g. 4 for int32 Foo[4]
+	BitField string `json:"bitField,omitempty"` // e.g. 1 for uint8 bFoo : 1
+	Default  string `json:"default,omitempty"`  // The default member initializer
+	Access   string `json:"access"`
+}
+
+// Function is a UFUNCTION
+type Function struct {
+	Decl
+	ReturnType string   `json:"returnType"`
+	Params     []*Param `json:"params"`
+	Const      bool     `json:"const,omitempty"`
+	Virtual    bool     `json:"virtual,omitempty"`
+	Static     bool     `json:"static,omitempty"`
+	Qualifiers []string `json:"qualifiers,omitempty"` // e.g. override, final
+	Access     string   `json:"access"`
+}
+
+// Param is a parameter of a UFUNCTION
+type Param struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default,omitempty"`
+}
+
+// Scan reads the reflected types of a header, given by its path and contents
+func Scan(path string, src []byte) (*Header, error) {
+	tokens, includes, err := tokenize(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	h := &Header{
+		Path:       path,
+		Includes:   includes,
+		Classes:    []*Class{},
+		Structs:    []*Class{},
+		Enums:      []*Enum{},
+		Interfaces: []*Class{},
+	}
+	if h.Includes == nil {
+		h.Includes = []string{}
+	}
+	p := &parser{tokens: tokens, header: h}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return h, nil
+}
+
+// ScanFile reads the reflected types of the header at path
+func ScanFile(path string) (*Header, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Scan(path, src)
+}
+
+// Module is the scanned headers of a module
+type Module struct {
+	Name    string    `json:"name"`
+	Headers []*Header `json:"headers"`
+}
+
+// ScanModule reads the reflected types of every header of a module
+func ScanModule(name string, headers []string) (*Module, error) {
+	m := &Module{Name: name, Headers: []*Header{}}
+	for _, path := range headers {
+		h, err := ScanFile(path)
+		if err != nil {
+			return nil, err
+		}
+		m.Headers = append(m.Headers, h)
+	}
+	return m, nil
+}
+
+// WriteJSON writes the module as indented JSON
+func (m *Module) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
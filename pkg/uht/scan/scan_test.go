@@ -0,0 +1,297 @@
+package scan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fixtureDir = "../../../test/verification/uht/simple_enum"
+
+func TestScanFixture(t *testing.T) {
+	h, err := ScanFile(filepath.Join(fixtureDir, "Public/TestEnum.h"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CoreMinimal.h", "TestEnum.generated.h"}, h.Includes)
+	assert.Len(t, h.Enums, 1)
+
+	e := h.Enums[0]
+	assert.Equal(t, "ETestEnum", e.Name)
+	assert.Equal(t, 11, e.Line)
+	assert.Equal(t, EnumClass, e.Form)
+	assert.Equal(t, "uint8", e.UnderlyingType)
+	assert.Equal(t, []Specifier{{Key: "BlueprintType"}}, e.Specifiers)
+	assert.Equal(t, "/**\n * Test enum for UHT code generation\n */", e.Comment)
+	assert.Equal(t, []*EnumValue{
+		{Name: "Value1", Line: 14, Meta: []MetaData{{Key: "DisplayName", Value: "First Value"}}},
+		{Name: "Value2", Line: 15, Meta: []MetaData{{Key: "DisplayName", Value: "Second Value"}}},
+		{Name: "Value3", Line: 16, Meta: []MetaData{{Key: "DisplayName", Value: "Third Value"}}},
+	}, e.Values)
+
+	plain, err := ScanFile(filepath.Join(fixtureDir, "Public/PlainClass.h"))
+	assert.NoError(t, err)
+	assert.Empty(t, plain.Classes)
+	assert.Empty(t, plain.Enums)
+}
+
+func TestScanClass(t *testing.T) {
+	h, err := Scan("MyActor.h", []byte(`#pragma once
+#include "GameFramework/Actor.h"
+#include "MyActor.generated.h"
+
+template<class T> class TNotReflected { T Value; };
+
+// An actor
+UCLASS(Blueprintable, HideCategories=(Rendering, "Input"), meta=(DisplayName="My Actor", ShortTooltip))
+class MYGAME_API AMyActor final : public AActor, public IMyInterface
+{
+	GENERATED_BODY()
+
+public:
+	/** Health, in points */
+	UPROPERTY(EditAnywhere, BlueprintReadWrite, Category="Stats|Health", meta=(ClampMin=0, ClampMax="100"))
+	float Health = 100.f;
+
+	UPROPERTY()
+	TMap<FName, TArray<TObjectPtr<UObject>>> Lookup;
+
+	UPROPERTY(VisibleAnywhere)
+	uint8 bIsAlive : 1;
+
+	UPROPERTY()
+	int32 Slots[4];
+
+#if WITH_EDITORONLY_DATA
+	UPROPERTY()
+	FString EditorNote{TEXT("note")};
+#endif
+
+	UFUNCTION(BlueprintCallable, Category="Stats")
+	virtual float Heal(float Amount, const FString& Reason = TEXT("none"), bool) const override;
+
+	UFUNCTION(BlueprintPure)
+	static TArray<int32> GetSlots() { return TArray<int32>(); }
+
+#if CPP
+	UPROPERTY()
+	int32 Hidden;
+#endif
+
+protected:
+	void NotReflected(int32 X) { if (X) { UPROPERTY_LIKE(); } }
+
+	UFUNCTION(BlueprintImplementableEvent)
+	UE_DEPRECATED(5.0, "Use Heal") void OnHeal();
+};
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GameFramework/Actor.h", "MyActor.generated.h"}, h.Includes)
+	assert.Len(t, h.Classes, 1)
+
+	c := h.Classes[0]
+	assert.Equal(t, "AMyActor", c.Name)
+	assert.Equal(t, 8, c.Line)
+	assert.Equal(t, "// An actor", c.Comment)
+	assert.Equal(t, "class", c.Keyword)
+	assert.Equal(t, "MYGAME_API", c.APIMacro)
+	assert.True(t, c.Final)
+	assert.Equal(t, []string{"AActor", "IMyInterface"}, c.Bases)
+	assert.Equal(t, []Specifier{{Key: "Blueprintable"}, {Key: "HideCategories", Values: []string{"Rendering", "Input"}}}, c.Specifiers)
+	assert.Equal(t, []MetaData{{Key: "DisplayName", Value: "My Actor"}, {Key: "ShortTooltip"}}, c.Meta)
+	assert.Equal(t, &GeneratedBody{Macro: "GENERATED_BODY", Line: 11, Access: "private"}, c.GeneratedBody)
+
+	assert.Len(t, c.Properties, 5)
+	health := c.Properties[0]
+	assert.Equal(t, "Health", health.Name)
+	assert.Equal(t, 15, health.Line)
+	assert.Equal(t, "float", health.Type)
+	assert.Equal(t, "100.f", health.Default)
+	assert.Equal(t, "public", health.Access)
+	assert.Equal(t, "/** Health, in points */", health.Comment)
+	assert.Equal(t, []Specifier{{Key: "EditAnywhere"}, {Key: "BlueprintReadWrite"}, {Key: "Category", Value: "Stats|Health"}}, health.Specifiers)
+	assert.Equal(t, []MetaData{{Key: "ClampMin", Value: "0"}, {Key: "ClampMax", Value: "100"}}, health.Meta)
+
+	assert.Equal(t, "TMap<FName, TArray<TObjectPtr<UObject>>>", c.Properties[1].Type)
+	assert.Equal(t, "Lookup", c.Properties[1].Name)
+	assert.Equal(t, "1", c.Properties[2].BitField)
+	assert.Equal(t, "uint8", c.Properties[2].Type)
+	assert.Equal(t, "4", c.Properties[3].ArrayDim)
+	assert.Equal(t, "EditorNote", c.Properties[4].Name)
+	assert.Equal(t, "{TEXT(\"note\")}", c.Properties[4].Default)
+	assert.Equal(t, []string{"WITH_EDITORONLY_DATA"}, c.Properties[4].Conditions)
+
+	assert.Len(t, c.Functions, 3)
+	heal := c.Functions[0]
+	assert.Equal(t, "Heal", heal.Name)
+	assert.Equal(t, "float", heal.ReturnType)
+	assert.True(t, heal.Virtual)
+	assert.True(t, heal.Const)
+	assert.Equal(t, []string{"override"}, heal.Qualifiers)
+	assert.Equal(t, []*Param{
+		{Name: "Amount", Type: "float"},
+		{Name: "Reason", Type: "const FString&", Default: "TEXT(\"none\")"},
+		{Type: "bool"},
+	}, heal.Params)
+
+	slots := c.Functions[1]
+	assert.Equal(t, "GetSlots", slots.Name)
+	assert.Equal(t, "TArray<int32>", slots.ReturnType)
+	assert.True(t, slots.Static)
+	assert.Empty(t, slots.Params)
+
+	onHeal := c.Functions[2]
+	assert.Equal(t, "OnHeal", onHeal.Name)
+	assert.Equal(t, "void", onHeal.ReturnType)
+	assert.Equal(t, "protected", onHeal.Access)
+}
+
+func TestScanStructAndInterface(t *testing.T) {
+	h, err := Scan("Types.h", []byte(`
+USTRUCT(BlueprintType)
+struct alignas(16) FMyStruct : public FTableRowBase
+{
+	GENERATED_USTRUCT_BODY()
+
+	UPROPERTY()
+	TFunction<void(int32)> Callback;
+};
+
+UINTERFACE(MinimalAPI, Blueprintable)
+class UMyInterface : public UInterface
+{
+	GENERATED_BODY()
+};
+
+class IMyInterface;
+
+class MYGAME_API IMyInterface
+{
+	GENERATED_BODY()
+
+public:
+	UFUNCTION(BlueprintNativeEvent)
+	void Interact(AActor* Instigator);
+
+	UFUNCTION()
+	virtual int32 GetPriority() const = 0;
+};
+`))
+	assert.NoError(t, err)
+
+	assert.Len(t, h.Structs, 1)
+	s := h.Structs[0]
+	assert.Equal(t, "FMyStruct", s.Name)
+	assert.Equal(t, "struct", s.Keyword)
+	assert.Equal(t, []string{"FTableRowBase"}, s.Bases)
+	assert.Equal(t, "GENERATED_USTRUCT_BODY", s.GeneratedBody.Macro)
+	assert.Equal(t, "public", s.GeneratedBody.Access)
+	assert.Equal(t, "TFunction<void(int32)>", s.Properties[0].Type)
+	assert.Equal(t, "Callback", s.Properties[0].Name)
+
+	assert.Len(t, h.Interfaces, 1)
+	i := h.Interfaces[0]
+	assert.Equal(t, "UMyInterface", i.Name)
+	assert.Equal(t, []Specifier{{Key: "MinimalAPI"}, {Key: "Blueprintable"}}, i.Specifiers)
+	if assert.NotNil(t, i.Native) {
+		assert.Equal(t, "IMyInterface", i.Native.Name)
+		assert.Equal(t, "MYGAME_API", i.Native.APIMacro)
+		assert.Equal(t, 21, i.Native.GeneratedBody.Line)
+		assert.Len(t, i.Native.Functions, 2)
+		assert.Equal(t, []*Param{{Name: "Instigator", Type: "AActor*"}}, i.Native.Functions[0].Params)
+		assert.Equal(t, []string{"= 0"}, i.Native.Functions[1].Qualifiers)
+	}
+}
+
+func TestScanEnums(t *testing.T) {
+	h, err := Scan("Enums.h", []byte(`
+UENUM()
+namespace ENetRole
+{
+	enum Type : int
+	{
+		/** No role */
+		ROLE_None,
+		ROLE_Authority = 3 UMETA(Hidden),
+		ROLE_MAX,
+	};
+}
+
+UENUM(BlueprintType, meta=(Bitflags))
+enum EFlags
+{
+	Flag_A = 1 << 0, // trailing comments document the next value
+	Flag_B = (1 << 1) UMETA(DisplayName = "B"),
+#if WITH_EDITOR
+	Flag_Editor = 0x10,
+#else
+	Flag_Runtime = 0x20,
+#endif
+};
+
+#if 0
+UENUM()
+enum class EIgnored { A };
+#endif
+`))
+	assert.NoError(t, err)
+	assert.Len(t, h.Enums, 2)
+
+	net := h.Enums[0]
+	assert.Equal(t, "ENetRole", net.Name)
+	assert.Equal(t, EnumNamespaced, net.Form)
+	assert.Equal(t, "ENetRole", net.Namespace)
+	assert.Equal(t, "int", net.UnderlyingType)
+	assert.Equal(t, []*EnumValue{
+		{Name: "ROLE_None", Line: 8, Comment: "/** No role */"},
+		{Name: "ROLE_Authority", Line: 9, Value: "3", Meta: []MetaData{{Key: "Hidden"}}},
+		{Name: "ROLE_MAX", Line: 10},
+	}, net.Values)
+
+	flags := h.Enums[1]
+	assert.Equal(t, EnumRegular, flags.Form)
+	assert.Equal(t, []MetaData{{Key: "Bitflags"}}, flags.Meta)
+	assert.Equal(t, "1<<0", flags.Values[0].Value)
+	assert.Equal(t, "(1<<1)", flags.Values[1].Value)
+	assert.Equal(t, []MetaData{{Key: "DisplayName", Value: "B"}}, flags.Values[1].Meta)
+	assert.Equal(t, []string{"WITH_EDITOR"}, flags.Values[2].Conditions)
+	assert.Equal(t, "Flag_Runtime", flags.Values[3].Name)
+	assert.Equal(t, []string{"!WITH_EDITOR"}, flags.Values[3].Conditions)
+}
+
+func TestScanLexing(t *testing.T) {
+	h, err := Scan("Lexing.h", []byte(`
+#define DECLARE_STUFF(Name) \
+	UENUM() enum class Name { A };
+const TCHAR* Raw = R"x(UENUM() "quoted" )x";
+const char* Str = "UCLASS() \" escaped";
+/* UCLASS() in a comment */
+#ifdef SOMETHING
+#elif !CPP
+UENUM()
+enum class EVisible : uint8 { A };
+#endif
+`))
+	assert.NoError(t, err)
+	assert.Len(t, h.Enums, 1)
+	assert.Equal(t, "EVisible", h.Enums[0].Name)
+	assert.Equal(t, []string{"!defined(SOMETHING)"}, h.Enums[0].Conditions)
+	assert.Equal(t, 9, h.Enums[0].Line)
+}
+
+func TestScanErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"UCLASS()\nint X;", `line 2: expected class or struct after reflection macro, got "int"`},
+		{"UENUM()\nenum class EFoo {\n", "line 2: unexpected end of file"},
+		{"#if WITH_EDITOR\n", "line 2: missing #endif"},
+		{"#endif\n", "line 1: #endif without #if"},
+		{"/* unterminated", "line 1: unterminated comment"},
+		{"USTRUCT()\nstruct FFoo {\nUPROPERTY()\n;\n};", "line 3: expected property declaration after UPROPERTY"},
+	}
+	for _, tt := range tests {
+		_, err := Scan("Bad.h", []byte(tt.src))
+		assert.ErrorContains(t, err, "Bad.h: "+tt.want)
+	}
+}
@@ -0,0 +1,151 @@
+package uht
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ModuleSpec describes one module of a target-wide manifest
+type ModuleSpec struct {
+	Name      string   `json:"name" yaml:"name"`
+	Type      string   `json:"type,omitempty" yaml:"type,omitempty"` // ModuleType or module host type, see ParseModuleType
+	BaseDir   string   `json:"baseDir" yaml:"baseDir"`
+	OutputDir string   `json:"outputDir" yaml:"outputDir"`
+	Headers   []string `json:"headers,omitempty" yaml:"headers,omitempty"` // Public headers, or all headers with ClassifyHeaders
+
+	// IncludePaths replace the module's Public and Private directories, which are the default,
+	// as in the manifests UBT writes. List those too to keep them.
+	IncludePaths []string `json:"includePaths,omitempty" yaml:"includePaths,omitempty"`
+
+	// Dependencies are modules that are listed before this one if they are part of the target.
+	// Dependencies outside the target are ignored.
+	Dependencies []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+
+	ModuleOptions `yaml:",inline"`
+}
+
+// TargetSpec describes every module UHT should process at once for a target
+type TargetSpec struct {
+	TargetName string       `json:"targetName,omitempty" yaml:"targetName,omitempty"` // Default: "BazelTarget"
+	UERoot     string       `json:"ueRoot,omitempty" yaml:"ueRoot,omitempty"`         // Default: BaseDir of the first module
+	Modules    []ModuleSpec `json:"modules" yaml:"modules"`
+
+	TargetOptions `yaml:",inline"`
+}
+
+// ReadTargetSpec reads a TargetSpec from a .json file, or from YAML for any other extension
+func ReadTargetSpec(path string) (*TargetSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &TargetSpec{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(spec)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec file %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// ParseModuleSpec parses a module given on the command line as comma-separated key=value pairs,
+// e.g. "name=Core,type=Runtime,base-dir=Source/Core,output-dir=out,header=Public/A.h,header=Public/B.h".
+// The keys header, include and dep can be repeated. Values cannot contain commas, as there is no
+// escaping; use a spec file (ReadTargetSpec) for paths with commas.
+func ParseModuleSpec(s string) (ModuleSpec, error) {
+	var m ModuleSpec
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return m, fmt.Errorf("invalid module %q: expected key=value, got %q", s, pair)
+		}
+		switch key {
+		case "name":
+			m.Name = value
+		case "type":
+			m.Type = value
+		case "game":
+			game, err := strconv.ParseBool(value)
+			if err != nil {
+				return m, fmt.Errorf("invalid module %q: game must be true or false", s)
+			}
+			m.Game = game
+		case "base-dir":
+			m.BaseDir = value
+		case "output-dir":
+			m.OutputDir = value
+		case "header":
+			m.Headers = append(m.Headers, value)
+		case "include":
+			m.IncludePaths = append(m.IncludePaths, value)
+		case "dep":
+			m.Dependencies = append(m.Dependencies, value)
+		case "classify":
+			classify, err := strconv.ParseBool(value)
+			if err != nil {
+				return m, fmt.Errorf("invalid module %q: classify must be true or false", s)
+			}
+			m.ClassifyHeaders = classify
+		default:
+			return m, fmt.Errorf("invalid module %q: unknown key %q (valid keys are name, type, game, base-dir, output-dir, header, include, dep and classify)", s, key)
+		}
+	}
+	return m, nil
+}
+
+// orderModules returns modules with every module after the target modules it depends on,
+// keeping the given order otherwise. Circular dependencies, which UBT allows between some
+// engine modules, are broken at the module listed first.
+func orderModules(modules []ModuleSpec) ([]ModuleSpec, error) {
+	byName := make(map[string]int, len(modules))
+	for i, m := range modules {
+		if m.Name == "" {
+			return nil, fmt.Errorf("module %d: name is required", i+1)
+		}
+		if _, ok := byName[m.Name]; ok {
+			return nil, fmt.Errorf("module %s is listed more than once", m.Name)
+		}
+		byName[m.Name] = i
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(modules))
+	ordered := make([]ModuleSpec, 0, len(modules))
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != unvisited {
+			return
+		}
+		state[i] = visiting
+		for _, dep := range modules[i].Dependencies {
+			if j, ok := byName[dep]; ok {
+				visit(j)
+			}
+		}
+		state[i] = done
+		ordered = append(ordered, modules[i])
+	}
+	for i := range modules {
+		visit(i)
+	}
+	return ordered, nil
+}
@@ -0,0 +1,133 @@
+package uht
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func moduleNames(m *UHTManifest) []string {
+	var names []string
+	for _, module := range m.Modules {
+		names = append(names, module.Name)
+	}
+	return names
+}
+
+func TestNewTargetManifest(t *testing.T) {
+	t.Run("orders modules after their dependencies", func(t *testing.T) {
+		manifest, err := NewTargetManifest(TargetSpec{
+			UERoot: "/ue",
+			Modules: []ModuleSpec{
+				{Name: "Engine", BaseDir: "/ue/Engine", OutputDir: "/out/Engine", Dependencies: []string{"CoreUObject", "Core", "Landscape"}},
+				{Name: "Landscape", BaseDir: "/ue/Landscape", OutputDir: "/out/Landscape", Dependencies: []string{"Engine"}},
+				{Name: "CoreUObject", BaseDir: "/ue/CoreUObject", OutputDir: "/out/CoreUObject", Dependencies: []string{"Core", "TraceLog"}},
+				{Name: "Core", BaseDir: "/ue/Core", OutputDir: "/out/Core"},
+			},
+		})
+		assert.NoError(t, err)
+		// Engine and Landscape depend on each other; the cycle is broken at Engine
+		assert.Equal(t, []string{"Core", "CoreUObject", "Landscape", "Engine"}, moduleNames(manifest))
+	})
+
+	t.Run("gives every module its own include paths", func(t *testing.T) {
+		manifest, err := NewTargetManifest(TargetSpec{
+			TargetName: "UnrealEditor",
+			UERoot:     "/ue",
+			Modules: []ModuleSpec{
+				{Name: "Core", BaseDir: "/ue/Core", OutputDir: "/out/Core", Headers: []string{"/ue/Core/Public/A.h"}},
+				{Name: "Foo", Type: "Editor", BaseDir: "/ue/Foo", OutputDir: "/out/Foo", IncludePaths: []string{"/ue/Foo/Public", "/ue/Foo/Classes"}},
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "UnrealEditor", manifest.TargetName)
+		assert.Equal(t, "/ue", manifest.RootLocalPath)
+
+		core, foo := manifest.Modules[0], manifest.Modules[1]
+		assert.Equal(t, []string{"/ue/Core/Public", "/ue/Core/Private"}, core.IncludePaths)
+		assert.Equal(t, []string{"/ue/Core/Public/A.h"}, core.PublicHeaders)
+		assert.Equal(t, "/out/Core/Core.gen", core.GeneratedCPPFilenameBase)
+		assert.Equal(t, []string{"/ue/Foo/Public", "/ue/Foo/Classes"}, foo.IncludePaths)
+		assert.Equal(t, "EngineEditor", foo.ModuleType)
+	})
+
+	t.Run("rejects invalid specs", func(t *testing.T) {
+		_, err := NewTargetManifest(TargetSpec{})
+		assert.ErrorContains(t, err, "at least one module")
+
+		_, err = NewTargetManifest(TargetSpec{Modules: []ModuleSpec{
+			{Name: "Core", BaseDir: "/a", OutputDir: "/b"},
+			{Name: "Core", BaseDir: "/c", OutputDir: "/d"},
+		}})
+		assert.ErrorContains(t, err, "module Core is listed more than once")
+
+		_, err = NewTargetManifest(TargetSpec{Modules: []ModuleSpec{{Name: "Core", BaseDir: "/a"}}})
+		assert.ErrorContains(t, err, "module Core: output directory is required")
+	})
+}
+
+func TestParseModuleSpec(t *testing.T) {
+	m, err := ParseModuleSpec("name=Core,type=Runtime,base-dir=Source/Core,output-dir=out,header=Public/A.h,header=Public/B.h,include=Classes,dep=TraceLog")
+	assert.NoError(t, err)
+	assert.Equal(t, ModuleSpec{
+		Name:         "Core",
+		Type:         "Runtime",
+		BaseDir:      "Source/Core",
+		OutputDir:    "out",
+		Headers:      []string{"Public/A.h", "Public/B.h"},
+		IncludePaths: []string{"Classes"},
+		Dependencies: []string{"TraceLog"},
+	}, m)
+
+	_, err = ParseModuleSpec("name=Core,Public/A.h")
+	assert.ErrorContains(t, err, "expected key=value")
+	_, err = ParseModuleSpec("name=Core,headers=Public/A.h")
+	assert.ErrorContains(t, err, `unknown key "headers"`)
+}
+
+func TestReadTargetSpec(t *testing.T) {
+	dir := t.TempDir()
+	want := &TargetSpec{
+		TargetName: "Game",
+		Modules: []ModuleSpec{
+			{Name: "Core", BaseDir: "Core", OutputDir: "out/Core", Headers: []string{"Core/Public/A.h"}},
+			{Name: "Game", Type: "Runtime", BaseDir: "Game", OutputDir: "out/Game", Dependencies: []string{"Core"}},
+		},
+	}
+
+	jsonPath := filepath.Join(dir, "spec.json")
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(`{
+  "targetName": "Game",
+  "modules": [
+    {"name": "Core", "baseDir": "Core", "outputDir": "out/Core", "headers": ["Core/Public/A.h"]},
+    {"name": "Game", "type": "Runtime", "baseDir": "Game", "outputDir": "out/Game", "dependencies": ["Core"]}
+  ]
+}`), 0644))
+	spec, err := ReadTargetSpec(jsonPath)
+	assert.NoError(t, err)
+	assert.Equal(t, want, spec)
+
+	yamlPath := filepath.Join(dir, "spec.yaml")
+	assert.NoError(t, os.WriteFile(yamlPath, []byte(`targetName: Game
+modules:
+  - name: Core
+    baseDir: Core
+    outputDir: out/Core
+    headers: [Core/Public/A.h]
+  - name: Game
+    type: Runtime
+    baseDir: Game
+    outputDir: out/Game
+    dependencies: [Core]
+`), 0644))
+	spec, err = ReadTargetSpec(yamlPath)
+	assert.NoError(t, err)
+	assert.Equal(t, want, spec)
+
+	typoPath := filepath.Join(dir, "typo.yaml")
+	assert.NoError(t, os.WriteFile(typoPath, []byte("modules:\n  - name: Core\n    basedir: Core\n"), 0644))
+	_, err = ReadTargetSpec(typoPath)
+	assert.ErrorContains(t, err, "basedir")
+}
@@ -0,0 +1,214 @@
+package uht
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationError is a problem ValidateManifest found in a manifest
+type ValidationError struct {
+	Module  string // Empty for target-wide fields
+	Field   string // Manifest field, e.g. "PublicHeaders"
+	Path    string // The offending path, if any
+	Problem string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.Module != "" {
+		fmt.Fprintf(&b, "module %s: ", e.Module)
+	}
+	b.WriteString(e.Field)
+	if e.Path != "" {
+		fmt.Fprintf(&b, " %s", e.Path)
+	}
+	fmt.Fprintf(&b, ": %s", e.Problem)
+	return b.String()
+}
+
+// ReadManifest reads a .uhtmanifest file. Fields the manifest schema doesn't know are an error.
+func ReadManifest(path string) (*UHTManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &UHTManifest{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", path, err)
+	}
+	return manifest, nil
+}
+
+// ValidateManifest checks the things UHT fails on with unhelpful messages: every path is absolute
+// and exists, headers are under their module's BaseDirectory, module names are unique, output
+// directories are writable, and no two headers generate files of the same name. It returns
+// every problem found, or nil if there are none.
+func ValidateManifest(manifest *UHTManifest) []*ValidationError {
+	v := &validator{}
+
+	v.checkDir("", "RootLocalPath", manifest.RootLocalPath)
+	if v.checkAbs("", "ExternalDependenciesFile", manifest.ExternalDependenciesFile) {
+		v.checkWritable("", "ExternalDependenciesFile", filepath.Dir(manifest.ExternalDependenciesFile))
+	}
+	if len(manifest.Modules) == 0 {
+		v.add("", "Modules", "", "no modules")
+	}
+
+	names := map[string]bool{}
+	generated := map[string]string{} // Generated file path without extension -> header or module generating it
+	for i, m := range manifest.Modules {
+		name := m.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+			v.add(name, "Name", "", "is empty")
+		} else if names[name] {
+			v.add(name, "Name", "", "module is listed more than once")
+		}
+		names[name] = true
+
+		if !isModuleType(m.ModuleType) {
+			v.add(name, "ModuleType", "", fmt.Sprintf("invalid module type %q: valid values are %s", m.ModuleType, joinModuleTypes(ModuleTypes)))
+		}
+
+		baseDirOk := v.checkDir(name, "BaseDirectory", m.BaseDirectory)
+		for _, inc := range m.IncludePaths {
+			v.checkDir(name, "IncludePaths", inc)
+		}
+		if v.checkAbs(name, "OutputDirectory", m.OutputDirectory) {
+			v.checkWritable(name, "OutputDirectory", m.OutputDirectory)
+		}
+		if v.checkAbs(name, "GeneratedCPPFilenameBase", m.GeneratedCPPFilenameBase) {
+			if owner, ok := generated[m.GeneratedCPPFilenameBase]; ok {
+				v.add(name, "GeneratedCPPFilenameBase", m.GeneratedCPPFilenameBase, "is also generated for "+owner)
+			}
+			generated[m.GeneratedCPPFilenameBase] = "module " + name
+		}
+
+		for _, list := range []struct {
+			field   string
+			headers []string
+		}{
+			{"ClassesHeaders", m.ClassesHeaders},
+			{"PublicHeaders", m.PublicHeaders},
+			{"InternalHeaders", m.InternalHeaders},
+			{"PrivateHeaders", m.PrivateHeaders},
+		} {
+			for _, hdr := range list.headers {
+				if !v.checkFile(name, list.field, hdr) {
+					continue
+				}
+				if baseDirOk && !isUnder(m.BaseDirectory, hdr) {
+					v.add(name, list.field, hdr, "is not under BaseDirectory "+m.BaseDirectory)
+				}
+
+				out := filepath.Join(m.OutputDirectory, generatedBaseName(hdr))
+				if owner, ok := generated[out]; ok {
+					v.add(name, list.field, hdr, fmt.Sprintf("generates %s.generated.h, which %s also generates", out, owner))
+					continue
+				}
+				generated[out] = hdr
+			}
+		}
+	}
+	return v.errs
+}
+
+type validator struct {
+	errs []*ValidationError
+}
+
+func (v *validator) add(module, field, path, problem string) {
+	v.errs = append(v.errs, &ValidationError{Module: module, Field: field, Path: path, Problem: problem})
+}
+
+// checkAbs reports whether path is set and absolute
+func (v *validator) checkAbs(module, field, path string) bool {
+	if path == "" {
+		v.add(module, field, "", "is empty")
+		return false
+	}
+	if !filepath.IsAbs(path) {
+		v.add(module, field, path, "is not an absolute path")
+		return false
+	}
+	return true
+}
+
+// checkDir reports whether path is an absolute path to an existing directory
+func (v *validator) checkDir(module, field, path string) bool {
+	if !v.checkAbs(module, field, path) {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		v.add(module, field, path, describeStatError(err))
+		return false
+	}
+	if !info.IsDir() {
+		v.add(module, field, path, "is not a directory")
+		return false
+	}
+	return true
+}
+
+// checkFile reports whether path is an absolute path to an existing file
+func (v *validator) checkFile(module, field, path string) bool {
+	if !v.checkAbs(module, field, path) {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		v.add(module, field, path, describeStatError(err))
+		return false
+	}
+	if info.IsDir() {
+		v.add(module, field, path, "is a directory")
+		return false
+	}
+	return true
+}
+
+// checkWritable checks that files can be created in dir. UHT creates missing directories, so a
+// missing dir is writable if its closest existing parent is.
+func (v *validator) checkWritable(module, field, dir string) {
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				v.add(module, field, dir, existing+" is not a directory")
+				return
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if !errors.Is(err, os.ErrNotExist) || parent == existing {
+			v.add(module, field, dir, describeStatError(err))
+			return
+		}
+		existing = parent
+	}
+
+	f, err := os.CreateTemp(existing, ".uht-validate-*")
+	if err != nil {
+		v.add(module, field, dir, "is not writable")
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+func describeStatError(err error) string {
+	if errors.Is(err, os.ErrNotExist) {
+		return "does not exist"
+	}
+	return err.Error()
+}
@@ -0,0 +1,94 @@
+package uht
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func problems(errs []*ValidationError) []string {
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
+
+func TestValidateManifest(t *testing.T) {
+	root := t.TempDir()
+	writeHeaders(t, root, [][2]string{
+		{"Core/Public/A.h", "UENUM()\nenum class EA { X };\n"},
+		{"Core/Private/B.h", "UENUM()\nenum class EB { X };\n"},
+		{"Game/Public/A.h", "UENUM()\nenum class EA { X };\n"},
+	})
+
+	manifest, err := NewTargetManifest(TargetSpec{
+		UERoot: root,
+		Modules: []ModuleSpec{
+			{Name: "Core", BaseDir: filepath.Join(root, "Core"), OutputDir: filepath.Join(root, "out/Core"), Headers: []string{filepath.Join(root, "Core/Public/A.h")}},
+			{Name: "Game", BaseDir: filepath.Join(root, "Game"), OutputDir: filepath.Join(root, "out/Game"), Headers: []string{filepath.Join(root, "Game/Public/A.h")}, IncludePaths: []string{filepath.Join(root, "Game/Public")}},
+		},
+	})
+	assert.NoError(t, err)
+	manifest.Modules[0].IncludePaths = []string{filepath.Join(root, "Core/Public")}
+
+	t.Run("valid manifest", func(t *testing.T) {
+		assert.Empty(t, problems(ValidateManifest(manifest)))
+	})
+
+	t.Run("reports every problem with its module", func(t *testing.T) {
+		bad := *manifest
+		bad.RootLocalPath = "relative/root"
+		core, game := bad.Modules[0], bad.Modules[1]
+		core.ModuleType = "Runtime"
+		core.PublicHeaders = []string{
+			filepath.Join(root, "Core/Public/A.h"),
+			filepath.Join(root, "Core/Public/Missing.h"),
+			filepath.Join(root, "Game/Public/A.h"),
+		}
+		core.PrivateHeaders = []string{filepath.Join(root, "Core/Private/B.h")}
+		game.Name = "Core"
+		game.OutputDirectory = core.OutputDirectory
+		game.GeneratedCPPFilenameBase = core.GeneratedCPPFilenameBase
+		bad.Modules = []UHTModule{core, game}
+
+		out := filepath.Join(root, "out/Core")
+		assert.Equal(t, []string{
+			"RootLocalPath relative/root: is not an absolute path",
+			`module Core: ModuleType: invalid module type "Runtime": valid values are ` + joinModuleTypes(ModuleTypes),
+			"module Core: PublicHeaders " + filepath.Join(root, "Core/Public/Missing.h") + ": does not exist",
+			"module Core: PublicHeaders " + filepath.Join(root, "Game/Public/A.h") + ": is not under BaseDirectory " + filepath.Join(root, "Core"),
+			"module Core: PublicHeaders " + filepath.Join(root, "Game/Public/A.h") + ": generates " + out + "/A.generated.h, which " + filepath.Join(root, "Core/Public/A.h") + " also generates",
+			"module Core: Name: module is listed more than once",
+			"module Core: GeneratedCPPFilenameBase " + out + "/Core.gen: is also generated for module Core",
+			"module Core: PublicHeaders " + filepath.Join(root, "Game/Public/A.h") + ": generates " + out + "/A.generated.h, which " + filepath.Join(root, "Core/Public/A.h") + " also generates",
+		}, problems(ValidateManifest(&bad)))
+	})
+
+	t.Run("output directories must be writable", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root can write to read-only directories")
+		}
+		readOnly := filepath.Join(root, "readonly")
+		assert.NoError(t, os.Mkdir(readOnly, 0555))
+		bad := *manifest
+		bad.Modules = []UHTModule{manifest.Modules[0]}
+		bad.Modules[0].OutputDirectory = filepath.Join(readOnly, "Core")
+		assert.Equal(t, []string{
+			"module Core: OutputDirectory " + filepath.Join(readOnly, "Core") + ": is not writable",
+		}, problems(ValidateManifest(&bad)))
+	})
+}
+
+func TestReadManifest(t *testing.T) {
+	manifest, err := ReadManifest(filepath.Join(epicManifestDir, "TestModule.uhtmanifest"))
+	assert.NoError(t, err)
+	assert.Equal(t, "TestModule", manifest.Modules[0].Name)
+
+	path := filepath.Join(t.TempDir(), "bad.uhtmanifest")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Modules": [{"Nmae": "Typo"}]}`), 0644))
+	_, err = ReadManifest(path)
+	assert.ErrorContains(t, err, "Nmae")
+}
@@ -93,3 +93,36 @@ EOF
     rm -f test/uht_test/test_empty.cpp /tmp/test_empty.o
     rm -rf test/uht_test/generated_empty
 }
+
+@test "Capture UHT output as golden files for pkg/uht/codegen" {
+    # Overwrites checked-in files, so only run when asked to
+    if [ -z "$CAPTURE_UHT_GOLDEN" ]; then
+        skip "set CAPTURE_UHT_GOLDEN=1 to capture UHT output into golden/uht"
+    fi
+
+    FIXTURE="$(pwd)/test/verification/uht/simple_enum"
+    GOLDEN="$FIXTURE/golden/uht/5.5"
+    WORK="$(mktemp -d)"
+
+    run go run . uht manifest --config none \
+        --module-name TestModule \
+        --base-dir "$FIXTURE" \
+        --output-dir "$WORK/generated" \
+        --header "$FIXTURE/Public/TestEnum.h" \
+        --header "$FIXTURE/Public/TestEnumForms.h" \
+        --output "$WORK/TestModule.uhtmanifest"
+    [ "$status" -eq 0 ]
+
+    run ./tools/uht_wrapper.sh \
+        "$UE_ROOT/Engine/Binaries/ThirdParty/DotNet/8.0.300/mac-arm64/dotnet" \
+        "$UE_ROOT/Engine/Binaries/DotNET/UnrealBuildTool/UnrealBuildTool.dll" \
+        "$FIXTURE/TestProject.uproject" \
+        "$WORK/TestModule.uhtmanifest"
+    [ "$status" -eq 0 ]
+
+    mkdir -p "$GOLDEN"
+    for name in TestEnum TestEnumForms; do
+        cp "$WORK/generated/$name.generated.h" "$WORK/generated/$name.gen.cpp" "$GOLDEN/"
+    done
+    rm -rf "$WORK"
+}
@@ -0,0 +1,29 @@
+// Copyright Test
+
+#pragma once
+
+#include "CoreMinimal.h"
+#include "TestEnumForms.generated.h"
+
+/** Namespaced enum for UHT code generation */
+UENUM()
+namespace ETestNamespacedEnum
+{
+	enum Type : int
+	{
+		/** The first value */
+		First,
+		Second UMETA(DisplayName="Second Value"),
+		Max UMETA(Hidden),
+	};
+}
+
+// Regular flags enum for UHT code generation
+UENUM(BlueprintType, Flags, meta=(Bitflags, UseEnumValuesAsMaskValuesInEditor="true"))
+enum ETestFlags : uint8
+{
+	TF_None = 0,
+	TF_Red = 1 << 0 UMETA(ToolTip="Red \"bit\""),
+	TF_Green = 1 << 1,
+	TF_MAX UMETA(Hidden),
+};
@@ -0,0 +1,92 @@
+// Copyright Epic Games, Inc. All Rights Reserved.
+/*===========================================================================
+	Generated code exported from UnrealHeaderTool.
+	DO NOT modify this manually! Edit the corresponding .h files instead!
+===========================================================================*/
+
+#include "UObject/GeneratedCppIncludes.h"
+#include "TestEnum.h"
+PRAGMA_DISABLE_DEPRECATION_WARNINGS
+void EmptyLinkFunctionForGeneratedCodeTestEnum() {}
+
+// Begin Cross Module References
+TESTMODULE_API UEnum* Z_Construct_UEnum_TestModule_ETestEnum();
+UPackage* Z_Construct_UPackage__Script_TestModule();
+// End Cross Module References
+
+// Begin Enum ETestEnum
+static FEnumRegistrationInfo Z_Registration_Info_UEnum_ETestEnum;
+static UEnum* ETestEnum_StaticEnum()
+{
+	if (!Z_Registration_Info_UEnum_ETestEnum.OuterSingleton)
+	{
+		Z_Registration_Info_UEnum_ETestEnum.OuterSingleton = GetStaticEnum(Z_Construct_UEnum_TestModule_ETestEnum, (UObject*)Z_Construct_UPackage__Script_TestModule(), TEXT("ETestEnum"));
+	}
+	return Z_Registration_Info_UEnum_ETestEnum.OuterSingleton;
+}
+template<> TESTMODULE_API UEnum* StaticEnum<ETestEnum>()
+{
+	return ETestEnum_StaticEnum();
+}
+struct Z_Construct_UEnum_TestModule_ETestEnum_Statics
+{
+#if WITH_METADATA
+	static constexpr UECodeGen_Private::FMetaDataPairParam Enum_MetaDataParams[] = {
+		{ "BlueprintType", "true" },
+#if !UE_BUILD_SHIPPING
+		{ "Comment", "/**\n * Test enum for UHT code generation\n */" },
+#endif
+		{ "ModuleRelativePath", "Public/TestEnum.h" },
+#if !UE_BUILD_SHIPPING
+		{ "ToolTip", "Test enum for UHT code generation" },
+#endif
+		{ "Value1.DisplayName", "First Value" },
+		{ "Value1.Name", "ETestEnum::Value1" },
+		{ "Value2.DisplayName", "Second Value" },
+		{ "Value2.Name", "ETestEnum::Value2" },
+		{ "Value3.DisplayName", "Third Value" },
+		{ "Value3.Name", "ETestEnum::Value3" },
+	};
+#endif // WITH_METADATA
+	static constexpr UECodeGen_Private::FEnumeratorParam Enumerators[] = {
+		{ "ETestEnum::Value1", (int64)ETestEnum::Value1 },
+		{ "ETestEnum::Value2", (int64)ETestEnum::Value2 },
+		{ "ETestEnum::Value3", (int64)ETestEnum::Value3 },
+	};
+	static const UECodeGen_Private::FEnumParams EnumParams;
+};
+const UECodeGen_Private::FEnumParams Z_Construct_UEnum_TestModule_ETestEnum_Statics::EnumParams = {
+	(UObject*(*)())Z_Construct_UPackage__Script_TestModule,
+	nullptr,
+	"ETestEnum",
+	"ETestEnum",
+	Z_Construct_UEnum_TestModule_ETestEnum_Statics::Enumerators,
+	RF_Public|RF_Transient|RF_MarkAsNative,
+	UE_ARRAY_COUNT(Z_Construct_UEnum_TestModule_ETestEnum_Statics::Enumerators),
+	EEnumFlags::None,
+	(uint8)UEnum::ECppForm::EnumClass,
+	METADATA_PARAMS(UE_ARRAY_COUNT(Z_Construct_UEnum_TestModule_ETestEnum_Statics::Enum_MetaDataParams), Z_Construct_UEnum_TestModule_ETestEnum_Statics::Enum_MetaDataParams)
+};
+UEnum* Z_Construct_UEnum_TestModule_ETestEnum()
+{
+	if (!Z_Registration_Info_UEnum_ETestEnum.InnerSingleton)
+	{
+		UECodeGen_Private::ConstructUEnum(Z_Registration_Info_UEnum_ETestEnum.InnerSingleton, Z_Construct_UEnum_TestModule_ETestEnum_Statics::EnumParams);
+	}
+	return Z_Registration_Info_UEnum_ETestEnum.InnerSingleton;
+}
+// End Enum ETestEnum
+
+// Begin Registration
+struct Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnum_h_Statics
+{
+	static constexpr FEnumRegisterCompiledInInfo EnumInfo[] = {
+		{ ETestEnum_StaticEnum, TEXT("ETestEnum"), &Z_Registration_Info_UEnum_ETestEnum, CONSTRUCT_RELOAD_VERSION_INFO(FEnumReloadVersionInfo, 3311631296U) },
+	};
+};
+static FRegisterCompiledInInfo Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnum_h_3311631296(TEXT("/Script/TestModule"),
+	nullptr, 0,
+	nullptr, 0,
+	Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnum_h_Statics::EnumInfo, UE_ARRAY_COUNT(Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnum_h_Statics::EnumInfo));
+// End Registration
+PRAGMA_ENABLE_DEPRECATION_WARNINGS
@@ -0,0 +1,34 @@
+// Copyright Epic Games, Inc. All Rights Reserved.
+/*===========================================================================
+	Generated code exported from UnrealHeaderTool.
+	DO NOT modify this manually! Edit the corresponding .h files instead!
+===========================================================================*/
+
+// IWYU pragma: private, include "TestEnum.h"
+
+#ifdef TESTMODULE_TestEnum_generated_h
+#error "TestEnum.generated.h already included, missing '#pragma once' in TestEnum.h"
+#endif
+#define TESTMODULE_TestEnum_generated_h
+
+#include "Templates/IsUEnumClass.h"
+#include "UObject/ObjectMacros.h"
+#include "UObject/ReflectedTypeAccessors.h"
+
+PRAGMA_DISABLE_DEPRECATION_WARNINGS
+
+#undef CURRENT_FILE_ID
+#define CURRENT_FILE_ID FID_test_verification_uht_simple_enum_Public_TestEnum_h
+
+
+#define FOREACH_ENUM_ETESTENUM(op) \
+	op(ETestEnum::Value1) \
+	op(ETestEnum::Value2) \
+	op(ETestEnum::Value3) 
+
+enum class ETestEnum : uint8;
+template<> struct TIsUEnumClass<ETestEnum> { enum { Value = true }; };
+template<> TESTMODULE_API UEnum* StaticEnum<ETestEnum>();
+
+PRAGMA_ENABLE_DEPRECATION_WARNINGS
+
@@ -0,0 +1,166 @@
+// Copyright Epic Games, Inc. All Rights Reserved.
+/*===========================================================================
+	Generated code exported from UnrealHeaderTool.
+	DO NOT modify this manually! Edit the corresponding .h files instead!
+===========================================================================*/
+
+#include "UObject/GeneratedCppIncludes.h"
+#include "TestEnumForms.h"
+PRAGMA_DISABLE_DEPRECATION_WARNINGS
+void EmptyLinkFunctionForGeneratedCodeTestEnumForms() {}
+
+// Begin Cross Module References
+TESTMODULE_API UEnum* Z_Construct_UEnum_TestModule_ETestFlags();
+TESTMODULE_API UEnum* Z_Construct_UEnum_TestModule_ETestNamespacedEnum();
+UPackage* Z_Construct_UPackage__Script_TestModule();
+// End Cross Module References
+
+// Begin Enum ETestNamespacedEnum
+static FEnumRegistrationInfo Z_Registration_Info_UEnum_ETestNamespacedEnum;
+static UEnum* ETestNamespacedEnum_StaticEnum()
+{
+	if (!Z_Registration_Info_UEnum_ETestNamespacedEnum.OuterSingleton)
+	{
+		Z_Registration_Info_UEnum_ETestNamespacedEnum.OuterSingleton = GetStaticEnum(Z_Construct_UEnum_TestModule_ETestNamespacedEnum, (UObject*)Z_Construct_UPackage__Script_TestModule(), TEXT("ETestNamespacedEnum"));
+	}
+	return Z_Registration_Info_UEnum_ETestNamespacedEnum.OuterSingleton;
+}
+template<> TESTMODULE_API UEnum* StaticEnum<ETestNamespacedEnum::Type>()
+{
+	return ETestNamespacedEnum_StaticEnum();
+}
+struct Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics
+{
+#if WITH_METADATA
+	static constexpr UECodeGen_Private::FMetaDataPairParam Enum_MetaDataParams[] = {
+#if !UE_BUILD_SHIPPING
+		{ "Comment", "/** Namespaced enum for UHT code generation */" },
+#endif
+#if !UE_BUILD_SHIPPING
+		{ "First.Comment", "/** The first value */" },
+#endif
+		{ "First.Name", "ETestNamespacedEnum::First" },
+#if !UE_BUILD_SHIPPING
+		{ "First.ToolTip", "The first value" },
+#endif
+		{ "Max.Hidden", "" },
+		{ "Max.Name", "ETestNamespacedEnum::Max" },
+		{ "ModuleRelativePath", "Public/TestEnumForms.h" },
+		{ "Second.DisplayName", "Second Value" },
+		{ "Second.Name", "ETestNamespacedEnum::Second" },
+#if !UE_BUILD_SHIPPING
+		{ "ToolTip", "Namespaced enum for UHT code generation" },
+#endif
+	};
+#endif // WITH_METADATA
+	static constexpr UECodeGen_Private::FEnumeratorParam Enumerators[] = {
+		{ "ETestNamespacedEnum::First", (int64)ETestNamespacedEnum::First },
+		{ "ETestNamespacedEnum::Second", (int64)ETestNamespacedEnum::Second },
+		{ "ETestNamespacedEnum::Max", (int64)ETestNamespacedEnum::Max },
+	};
+	static const UECodeGen_Private::FEnumParams EnumParams;
+};
+const UECodeGen_Private::FEnumParams Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics::EnumParams = {
+	(UObject*(*)())Z_Construct_UPackage__Script_TestModule,
+	nullptr,
+	"ETestNamespacedEnum",
+	"ETestNamespacedEnum::Type",
+	Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics::Enumerators,
+	RF_Public|RF_Transient|RF_MarkAsNative,
+	UE_ARRAY_COUNT(Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics::Enumerators),
+	EEnumFlags::None,
+	(uint8)UEnum::ECppForm::Namespaced,
+	METADATA_PARAMS(UE_ARRAY_COUNT(Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics::Enum_MetaDataParams), Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics::Enum_MetaDataParams)
+};
+UEnum* Z_Construct_UEnum_TestModule_ETestNamespacedEnum()
+{
+	if (!Z_Registration_Info_UEnum_ETestNamespacedEnum.InnerSingleton)
+	{
+		UECodeGen_Private::ConstructUEnum(Z_Registration_Info_UEnum_ETestNamespacedEnum.InnerSingleton, Z_Construct_UEnum_TestModule_ETestNamespacedEnum_Statics::EnumParams);
+	}
+	return Z_Registration_Info_UEnum_ETestNamespacedEnum.InnerSingleton;
+}
+// End Enum ETestNamespacedEnum
+
+// Begin Enum ETestFlags
+static FEnumRegistrationInfo Z_Registration_Info_UEnum_ETestFlags;
+static UEnum* ETestFlags_StaticEnum()
+{
+	if (!Z_Registration_Info_UEnum_ETestFlags.OuterSingleton)
+	{
+		Z_Registration_Info_UEnum_ETestFlags.OuterSingleton = GetStaticEnum(Z_Construct_UEnum_TestModule_ETestFlags, (UObject*)Z_Construct_UPackage__Script_TestModule(), TEXT("ETestFlags"));
+	}
+	return Z_Registration_Info_UEnum_ETestFlags.OuterSingleton;
+}
+template<> TESTMODULE_API UEnum* StaticEnum<ETestFlags>()
+{
+	return ETestFlags_StaticEnum();
+}
+struct Z_Construct_UEnum_TestModule_ETestFlags_Statics
+{
+#if WITH_METADATA
+	static constexpr UECodeGen_Private::FMetaDataPairParam Enum_MetaDataParams[] = {
+		{ "Bitflags", "" },
+		{ "BlueprintType", "true" },
+#if !UE_BUILD_SHIPPING
+		{ "Comment", "// Regular flags enum for UHT code generation" },
+#endif
+		{ "ModuleRelativePath", "Public/TestEnumForms.h" },
+		{ "TF_Green.Name", "TF_Green" },
+		{ "TF_MAX.Hidden", "" },
+		{ "TF_MAX.Name", "TF_MAX" },
+		{ "TF_None.Name", "TF_None" },
+		{ "TF_Red.Name", "TF_Red" },
+#if !UE_BUILD_SHIPPING
+		{ "TF_Red.ToolTip", "Red \"bit\"" },
+#endif
+#if !UE_BUILD_SHIPPING
+		{ "ToolTip", "Regular flags enum for UHT code generation" },
+#endif
+		{ "UseEnumValuesAsMaskValuesInEditor", "true" },
+	};
+#endif // WITH_METADATA
+	static constexpr UECodeGen_Private::FEnumeratorParam Enumerators[] = {
+		{ "TF_None", (int64)TF_None },
+		{ "TF_Red", (int64)TF_Red },
+		{ "TF_Green", (int64)TF_Green },
+		{ "TF_MAX", (int64)TF_MAX },
+	};
+	static const UECodeGen_Private::FEnumParams EnumParams;
+};
+const UECodeGen_Private::FEnumParams Z_Construct_UEnum_TestModule_ETestFlags_Statics::EnumParams = {
+	(UObject*(*)())Z_Construct_UPackage__Script_TestModule,
+	nullptr,
+	"ETestFlags",
+	"ETestFlags",
+	Z_Construct_UEnum_TestModule_ETestFlags_Statics::Enumerators,
+	RF_Public|RF_Transient|RF_MarkAsNative,
+	UE_ARRAY_COUNT(Z_Construct_UEnum_TestModule_ETestFlags_Statics::Enumerators),
+	EEnumFlags::Flags,
+	(uint8)UEnum::ECppForm::Regular,
+	METADATA_PARAMS(UE_ARRAY_COUNT(Z_Construct_UEnum_TestModule_ETestFlags_Statics::Enum_MetaDataParams), Z_Construct_UEnum_TestModule_ETestFlags_Statics::Enum_MetaDataParams)
+};
+UEnum* Z_Construct_UEnum_TestModule_ETestFlags()
+{
+	if (!Z_Registration_Info_UEnum_ETestFlags.InnerSingleton)
+	{
+		UECodeGen_Private::ConstructUEnum(Z_Registration_Info_UEnum_ETestFlags.InnerSingleton, Z_Construct_UEnum_TestModule_ETestFlags_Statics::EnumParams);
+	}
+	return Z_Registration_Info_UEnum_ETestFlags.InnerSingleton;
+}
+// End Enum ETestFlags
+
+// Begin Registration
+struct Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnumForms_h_Statics
+{
+	static constexpr FEnumRegisterCompiledInInfo EnumInfo[] = {
+		{ ETestNamespacedEnum_StaticEnum, TEXT("ETestNamespacedEnum"), &Z_Registration_Info_UEnum_ETestNamespacedEnum, CONSTRUCT_RELOAD_VERSION_INFO(FEnumReloadVersionInfo, 1705868227U) },
+		{ ETestFlags_StaticEnum, TEXT("ETestFlags"), &Z_Registration_Info_UEnum_ETestFlags, CONSTRUCT_RELOAD_VERSION_INFO(FEnumReloadVersionInfo, 3954287763U) },
+	};
+};
+static FRegisterCompiledInInfo Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnumForms_h_2894149122(TEXT("/Script/TestModule"),
+	nullptr, 0,
+	nullptr, 0,
+	Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnumForms_h_Statics::EnumInfo, UE_ARRAY_COUNT(Z_CompiledInDeferFile_FID_test_verification_uht_simple_enum_Public_TestEnumForms_h_Statics::EnumInfo));
+// End Registration
+PRAGMA_ENABLE_DEPRECATION_WARNINGS
@@ -0,0 +1,41 @@
+// Copyright Epic Games, Inc. All Rights Reserved.
+/*===========================================================================
+	Generated code exported from UnrealHeaderTool.
+	DO NOT modify this manually! Edit the corresponding .h files instead!
+===========================================================================*/
+
+// IWYU pragma: private, include "TestEnumForms.h"
+
+#ifdef TESTMODULE_TestEnumForms_generated_h
+#error "TestEnumForms.generated.h already included, missing '#pragma once' in TestEnumForms.h"
+#endif
+#define TESTMODULE_TestEnumForms_generated_h
+
+#include "Templates/IsUEnumClass.h"
+#include "UObject/ObjectMacros.h"
+#include "UObject/ReflectedTypeAccessors.h"
+
+PRAGMA_DISABLE_DEPRECATION_WARNINGS
+
+#undef CURRENT_FILE_ID
+#define CURRENT_FILE_ID FID_test_verification_uht_simple_enum_Public_TestEnumForms_h
+
+
+#define FOREACH_ENUM_ETESTNAMESPACEDENUM(op) \
+	op(ETestNamespacedEnum::First) \
+	op(ETestNamespacedEnum::Second) \
+	op(ETestNamespacedEnum::Max) 
+
+namespace ETestNamespacedEnum { enum Type : int; }
+template<> TESTMODULE_API UEnum* StaticEnum<ETestNamespacedEnum::Type>();
+
+#define FOREACH_ENUM_ETESTFLAGS(op) \
+	op(TF_None) \
+	op(TF_Red) \
+	op(TF_Green) 
+
+enum ETestFlags : uint8;
+template<> TESTMODULE_API UEnum* StaticEnum<ETestFlags>();
+
+PRAGMA_ENABLE_DEPRECATION_WARNINGS
+
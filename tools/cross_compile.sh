@@ -0,0 +1,31 @@
+#!/bin/bash
+# Builds the Go packages for every OS the CLI is expected to build on, so platform-specific files
+# (build tags, syscall fields) can't break one of them unnoticed.
+#
+# Usage:
+#   ./tools/cross_compile.sh
+
+set -euo pipefail
+
+TARGETS=(
+    linux/amd64
+    linux/arm64
+    darwin/amd64
+    darwin/arm64
+    windows/amd64
+    freebsd/amd64
+    dragonfly/amd64
+    netbsd/amd64
+    openbsd/amd64
+    solaris/amd64
+    illumos/amd64
+)
+
+status=0
+for target in "${TARGETS[@]}"; do
+    echo "Building for $target"
+    if ! GOOS="${target%/*}" GOARCH="${target#*/}" go build ./...; then
+        status=1
+    fi
+done
+exit $status